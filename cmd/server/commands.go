@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/backup"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/catalog"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/database"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// runCommand dispatches the operator subcommands that run instead of the web
+// server, e.g. `server backup -o instance.tar.gz`.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "backup":
+		return runBackup(args)
+	case "restore":
+		return runRestore(args)
+	case "verify":
+		return runVerify(args)
+	case "catalog":
+		return runCatalog(args)
+	case "languages":
+		return runLanguages(args)
+	default:
+		return fmt.Errorf("unknown command %q (available: backup, restore, verify, catalog, languages)", name)
+	}
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := flags.String("o", "", "path of the archive to write")
+	flags.Parse(args)
+	if *output == "" {
+		return fmt.Errorf("usage: server backup -o <archive.tar.gz>")
+	}
+
+	db, err := openCommandDatabase()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed backup never leaves a
+	// truncated archive behind under the final name.
+	tmpPath := *output + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := backup.Backup(context.Background(), db, database.Models(), f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, *output); err != nil {
+		return err
+	}
+
+	log.Printf("Backup written to %s", *output)
+	printManifest(manifest)
+	return nil
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	input := flags.String("i", "", "path of the archive to restore")
+	flags.Parse(args)
+	if *input == "" {
+		return fmt.Errorf("usage: server restore -i <archive.tar.gz>")
+	}
+
+	// Check the whole archive before opening a transaction on the database.
+	if _, err := verifyFile(*input); err != nil {
+		return err
+	}
+
+	db, err := openCommandDatabase()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := backup.Restore(context.Background(), db, database.Models(), f)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Restored %s", *input)
+	printManifest(manifest)
+	return nil
+}
+
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	input := flags.String("i", "", "path of the archive to verify")
+	flags.Parse(args)
+	if *input == "" {
+		return fmt.Errorf("usage: server verify -i <archive.tar.gz>")
+	}
+
+	manifest, err := verifyFile(*input)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Archive %s is intact", *input)
+	printManifest(manifest)
+	return nil
+}
+
+// runCatalog loads a Scryfall bulk data file into the shared card catalog
+// used for set completion, then copies the catalog metadata onto the cards of
+// every user. It can be re-run with a newer file at any time.
+func runCatalog(args []string) error {
+	flags := flag.NewFlagSet("catalog", flag.ExitOnError)
+	input := flags.String("i", "", "path of the Scryfall bulk data JSON file")
+	flags.Parse(args)
+	if *input == "" {
+		return fmt.Errorf("usage: server catalog -i <default-cards.json>")
+	}
+
+	db, err := openCommandDatabase()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	catalogRepo := repository.NewCatalogRepository(db)
+	result, err := catalog.Import(context.Background(), catalogRepo, f)
+	if err != nil {
+		return err
+	}
+	log.Printf("Imported %d printings in %d sets and %d translated printings from %s", result.Cards, result.Sets, result.Translations, *input)
+
+	updated, err := catalogRepo.ApplyMetadata(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("Updated the catalog metadata of %d cards", updated)
+	return nil
+}
+
+// runLanguages rewrites the free-text languages of cards saved before
+// languages were normalized to language codes, and prints how each value
+// was mapped. Values it cannot map are listed and left for the owners to
+// correct on the card's edit page.
+func runLanguages(args []string) error {
+	flags := flag.NewFlagSet("languages", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only print the mapping report")
+	flags.Parse(args)
+
+	db, err := openCommandDatabase()
+	if err != nil {
+		return err
+	}
+
+	mappings, err := database.NormalizeLanguages(context.Background(), db, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	var changed, unknown int64
+	for _, mapping := range mappings {
+		switch {
+		case mapping.Code == "":
+			unknown += mapping.Cards
+			fmt.Printf("  %-30q %8d cards  not a known language, left unchanged\n", mapping.Value, mapping.Cards)
+		case mapping.Changed():
+			changed += mapping.Cards
+			fmt.Printf("  %-30q %8d cards  -> %s (%s)\n", mapping.Value, mapping.Cards, mapping.Code, entity.LanguageName(mapping.Code))
+		default:
+			fmt.Printf("  %-30q %8d cards  already normalized\n", mapping.Value, mapping.Cards)
+		}
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d cards would be rewritten, %d cards have an unknown language", changed, unknown)
+	} else {
+		log.Printf("Rewrote the language of %d cards, %d cards have an unknown language", changed, unknown)
+	}
+	return nil
+}
+
+func verifyFile(path string) (*backup.Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	manifest, err := backup.Verify(f)
+	if err != nil {
+		return nil, fmt.Errorf("archive verification failed: %w", err)
+	}
+	return manifest, nil
+}
+
+func openCommandDatabase() (*gorm.DB, error) {
+	db, err := database.NewDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	// Per-query logging would print every dumped or restored row.
+	return db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Warn)}), nil
+}
+
+func printManifest(manifest *backup.Manifest) {
+	fmt.Printf("Format %s v%d, created %s\n", manifest.Format, manifest.Version, manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	for _, table := range manifest.Tables {
+		fmt.Printf("  %-20s %8d rows  sha256:%s\n", table.Name, table.Rows, table.SHA256)
+	}
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"html/template"
 	"log"
 	"os"
+	"time"
 
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
 	"github.com/enter42/mtg-collection-tracker/internal/handler"
 	"github.com/enter42/mtg-collection-tracker/internal/handler/middleware"
 	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/database"
 	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/storage"
 	"github.com/enter42/mtg-collection-tracker/internal/usecase"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -22,6 +26,14 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Operator subcommands (backup, restore, verify, catalog, languages) run instead of the server
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	// Initialize database
 	db, err := database.NewDatabase()
 	if err != nil {
@@ -31,18 +43,86 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	cardRepo := repository.NewCardRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	deckRepo := repository.NewDeckRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	lotRepo := repository.NewLotRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	fieldRepo := repository.NewCustomFieldRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	sealedRepo := repository.NewSealedProductRepository(db)
+	imageRepo := repository.NewCardImageRepository(db)
+	photoRepo := repository.NewCardPhotoRepository(db)
+	transactor := repository.NewTransactor(db)
+
+	// Card images and photos are kept on local disk below IMAGE_DIR
+	imageDir := os.Getenv("IMAGE_DIR")
+	if imageDir == "" {
+		imageDir = "data/images"
+	}
+	blobStore := storage.NewLocalBlobStore(imageDir)
 
 	// Initialize use cases
 	authUseCase := usecase.NewAuthUseCase(userRepo)
-	cardUseCase := usecase.NewCardUseCase(cardRepo)
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, catalogRepo, blobStore)
+	auditUseCase := usecase.NewAuditUseCase(auditRepo)
+	accountUseCase := usecase.NewAccountUseCase(userRepo, cardRepo, auditRepo, deckRepo, locationRepo, wishlistRepo, tradeRepo, lotRepo, tagRepo, fieldRepo, loanRepo, sealedRepo, photoRepo, blobStore, transactor)
+	deckUseCase := usecase.NewDeckUseCase(deckRepo, cardRepo, loanRepo)
+	locationUseCase := usecase.NewLocationUseCase(locationRepo, cardRepo, auditRepo, transactor)
+	wishlistUseCase := usecase.NewWishlistUseCase(wishlistRepo, cardRepo, cardUseCase)
+	tradeUseCase := usecase.NewTradeUseCase(tradeRepo, cardRepo, wishlistRepo, userRepo, auditRepo, loanRepo, blobStore)
+	setUseCase := usecase.NewSetUseCase(catalogRepo, cardRepo)
+	statsUseCase := usecase.NewStatsUseCase(cardRepo, catalogRepo, sealedRepo)
+	duplicateUseCase := usecase.NewDuplicateUseCase(cardRepo, auditRepo)
+	lotUseCase := usecase.NewLotUseCase(lotRepo, cardRepo, userRepo, auditRepo)
+	taxUseCase := usecase.NewTaxUseCase(lotRepo, cardRepo, userRepo)
+	tagUseCase := usecase.NewTagUseCase(tagRepo, fieldRepo, cardRepo)
+	loanUseCase := usecase.NewLoanUseCase(loanRepo, cardRepo)
+	sealedUseCase := usecase.NewSealedUseCase(sealedRepo, auditRepo, catalogRepo)
+	imageUseCase := usecase.NewImageUseCase(imageRepo, cardRepo, catalogRepo, blobStore, storage.NewPublicHTTPClient(30*time.Second))
+	photoUseCase := usecase.NewPhotoUseCase(photoRepo, cardRepo, blobStore)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authUseCase)
-	cardHandler := handler.NewCardHandler(cardUseCase)
+	cardHandler := handler.NewCardHandler(cardUseCase, locationUseCase, accountUseCase, lotUseCase, tagUseCase, loanUseCase, imageUseCase, photoUseCase, setUseCase)
+	auditHandler := handler.NewAuditHandler(auditUseCase)
+	accountHandler := handler.NewAccountHandler(accountUseCase)
+	deckHandler := handler.NewDeckHandler(deckUseCase)
+	locationHandler := handler.NewLocationHandler(locationUseCase)
+	wishlistHandler := handler.NewWishlistHandler(wishlistUseCase)
+	tradeHandler := handler.NewTradeHandler(tradeUseCase)
+	setHandler := handler.NewSetHandler(setUseCase)
+	statsHandler := handler.NewStatsHandler(statsUseCase, loanUseCase)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateUseCase, locationUseCase)
+	saleHandler := handler.NewSaleHandler(lotUseCase, taxUseCase, accountUseCase)
+	tagHandler := handler.NewTagHandler(tagUseCase)
+	loanHandler := handler.NewLoanHandler(loanUseCase)
+	sealedHandler := handler.NewSealedHandler(sealedUseCase)
+	imageHandler := handler.NewImageHandler(imageUseCase, photoUseCase)
+
+	// Purge accounts whose deletion grace period has ended
+	go purgeDeletedAccounts(accountUseCase, time.Hour)
+
+	// Download card images whose URL is new or changed
+	go cacheCardImages(imageUseCase, time.Minute)
 
 	// Initialize Gin
 	router := gin.Default()
 
+	// Bound every request so slow queries are cancelled instead of piling up
+	requestTimeout := 10 * time.Second
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid REQUEST_TIMEOUT %q: %v", value, err)
+		}
+		requestTimeout = parsed
+	}
+	router.Use(middleware.RequestTimeout(requestTimeout))
+
 	// Session middleware
 	sessionSecret := os.Getenv("SESSION_SECRET")
 	if sessionSecret == "" {
@@ -66,6 +146,7 @@ func main() {
 			}
 			return result
 		},
+		"languageName": entity.LanguageName,
 	}
 
 	// Load templates with custom functions
@@ -92,6 +173,79 @@ func main() {
 		protected.GET("/cards/edit/:id", cardHandler.ShowEditCardPage)
 		protected.POST("/cards/edit/:id", cardHandler.EditCard)
 		protected.POST("/cards/delete/:id", cardHandler.DeleteCard)
+		protected.POST("/cards/lots/:id", cardHandler.AddLot)
+		protected.POST("/cards/sell/:id", cardHandler.SellCard)
+		protected.POST("/cards/tags/:id", cardHandler.SetCardTags)
+		protected.POST("/cards/lend/:id", cardHandler.LendCard)
+		protected.POST("/cards/image/:id", cardHandler.UploadImage)
+		protected.GET("/images/cards/:id/:size", imageHandler.CardImage)
+		protected.GET("/images/translated/:id/:size", imageHandler.TranslatedImage)
+		protected.POST("/cards/photos/:id", cardHandler.UploadPhotos)
+		protected.POST("/cards/photos/delete/:id", cardHandler.DeletePhoto)
+		protected.GET("/photos/:id/:size", imageHandler.CardPhoto)
+		protected.POST("/cards/move", locationHandler.MoveCards)
+		protected.POST("/cards/bulk", cardHandler.BulkEdit)
+		protected.GET("/cards/duplicates", duplicateHandler.ListDuplicates)
+		protected.POST("/cards/duplicates/merge", duplicateHandler.MergeCards)
+		protected.POST("/cards/duplicates/merge-all", duplicateHandler.MergeAll)
+		protected.GET("/cards/history/:id", auditHandler.CardHistory)
+		protected.GET("/activity", auditHandler.ActivityFeed)
+		protected.GET("/account", accountHandler.ShowAccountPage)
+		protected.GET("/account/export", accountHandler.ExportAccount)
+		protected.POST("/account/cost-method", accountHandler.SetCostMethod)
+		protected.POST("/account/currency", accountHandler.SetCurrency)
+		protected.POST("/account/delete", accountHandler.RequestDeletion)
+		protected.POST("/account/delete/cancel", accountHandler.CancelDeletion)
+		protected.GET("/decks", deckHandler.ListDecks)
+		protected.POST("/decks/add", deckHandler.CreateDeck)
+		protected.GET("/decks/:id", deckHandler.ShowDeck)
+		protected.POST("/decks/edit/:id", deckHandler.EditDeck)
+		protected.POST("/decks/delete/:id", deckHandler.DeleteDeck)
+		protected.POST("/decks/:id/entries", deckHandler.AddEntry)
+		protected.POST("/decks/:id/entries/delete/:entryID", deckHandler.RemoveEntry)
+		protected.GET("/decks/:id/missing", deckHandler.ShowMissing)
+		protected.GET("/locations", locationHandler.ListLocations)
+		protected.POST("/locations/add", locationHandler.CreateLocation)
+		protected.POST("/locations/edit/:id", locationHandler.RenameLocation)
+		protected.POST("/locations/delete/:id", locationHandler.DeleteLocation)
+		protected.GET("/tags", tagHandler.ListTags)
+		protected.POST("/tags/add", tagHandler.CreateTag)
+		protected.POST("/tags/edit/:id", tagHandler.EditTag)
+		protected.POST("/tags/delete/:id", tagHandler.DeleteTag)
+		protected.POST("/tags/fields/add", tagHandler.CreateField)
+		protected.POST("/tags/fields/edit/:id", tagHandler.EditField)
+		protected.POST("/tags/fields/delete/:id", tagHandler.DeleteField)
+		protected.GET("/wishlist", wishlistHandler.ListWishlist)
+		protected.POST("/wishlist/add", wishlistHandler.AddItem)
+		protected.GET("/wishlist/:id", wishlistHandler.ShowItem)
+		protected.POST("/wishlist/edit/:id", wishlistHandler.EditItem)
+		protected.POST("/wishlist/delete/:id", wishlistHandler.DeleteItem)
+		protected.POST("/wishlist/acquire/:id", wishlistHandler.AcquireItem)
+		protected.GET("/trades", tradeHandler.ListTrades)
+		protected.GET("/trades/match/:userID", tradeHandler.ShowMatch)
+		protected.POST("/trades/propose", tradeHandler.Propose)
+		protected.GET("/trades/:id", tradeHandler.ShowProposal)
+		protected.POST("/trades/:id/accept", tradeHandler.Accept)
+		protected.POST("/trades/:id/decline", tradeHandler.Decline)
+		protected.POST("/trades/:id/cancel", tradeHandler.Cancel)
+		protected.GET("/loans", loanHandler.ListLoans)
+		protected.POST("/loans/borrow", loanHandler.Borrow)
+		protected.POST("/loans/return/:id", loanHandler.MarkReturned)
+		protected.POST("/loans/delete/:id", loanHandler.DeleteLoan)
+		protected.GET("/sealed", sealedHandler.ListProducts)
+		protected.GET("/sealed/add", sealedHandler.ShowAddProductPage)
+		protected.POST("/sealed/add", sealedHandler.AddProduct)
+		protected.GET("/sealed/edit/:id", sealedHandler.ShowEditProductPage)
+		protected.POST("/sealed/edit/:id", sealedHandler.EditProduct)
+		protected.POST("/sealed/delete/:id", sealedHandler.DeleteProduct)
+		protected.POST("/sealed/open/:id", sealedHandler.OpenProduct)
+		protected.GET("/sales", saleHandler.ListSales)
+		protected.GET("/sales/tax/:year", saleHandler.ShowTaxReport)
+		protected.GET("/sales/tax/:year/csv", saleHandler.ExportTaxReport)
+		protected.GET("/sets", setHandler.ListSets)
+		protected.GET("/sets/:code", setHandler.ShowSet)
+		protected.GET("/stats", statsHandler.ShowDashboard)
+		protected.GET("/api/stats", statsHandler.StatsJSON)
 	}
 
 	// Start server
@@ -105,3 +259,29 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+func purgeDeletedAccounts(accountUseCase *usecase.AccountUseCase, interval time.Duration) {
+	for {
+		purged, err := accountUseCase.PurgeDueAccounts(context.Background(), time.Now())
+		if err != nil {
+			log.Printf("Failed to purge deleted accounts: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted account(s)", purged)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func cacheCardImages(imageUseCase *usecase.ImageUseCase, interval time.Duration) {
+	for {
+		cached, err := imageUseCase.CachePending(context.Background(), 50)
+		if err != nil {
+			log.Printf("Failed to cache card images: %v", err)
+		}
+		if cached > 0 {
+			log.Printf("Cached %d card image(s)", cached)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,135 @@
+// Package cardquery parses the collection search syntax, modelled on
+// Scryfall's: `set:mh2 lang:ja qty>=4 price<100 bought>2024-01-01 is:sold
+// -name:"goblin"`. Terms are combined with AND by default; OR, NOT (or a
+// leading "-") and parentheses are supported. Bare words match the card
+// name, set code or collector number. Tags are searched with tag:proxy and
+// custom fields with cf.<name>, e.g. cf.grade>=9. Catalog metadata is
+// searched like on Scryfall: c:rg, id<=wub, t:creature, mv>=3, r>=rare.
+package cardquery
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expr is a parsed query. It is one of *And, *Or, *Not or *Term.
+type Expr interface {
+	isExpr()
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+type Field string
+
+const (
+	// FieldText is a bare word matched against name, set code and collector
+	// number.
+	FieldText     Field = "text"
+	FieldName     Field = "name"
+	FieldSet      Field = "set"
+	FieldNumber   Field = "cn"
+	FieldLanguage Field = "lang"
+	FieldQuantity Field = "qty"
+	FieldPrice    Field = "price"
+	FieldForTrade Field = "trade"
+	FieldBought   Field = "bought"
+	FieldSold     Field = "sold"
+	FieldIs       Field = "is"
+	FieldTag      Field = "tag"
+	// FieldColor and FieldIdentity compare color sets. ":" means ">=" for
+	// colors and "<=" for the color identity, as on Scryfall.
+	FieldColor     Field = "color"
+	FieldIdentity  Field = "id"
+	FieldType      Field = "type"
+	FieldManaValue Field = "mv"
+	FieldRarity    Field = "rarity"
+	FieldOracle    Field = "oracle"
+	// FieldCustom is a user-defined custom field, written as cf.<name>.
+	FieldCustom Field = "cf"
+)
+
+type Op string
+
+const (
+	// OpMatch is ":"; it means "contains" for names and "equals" elsewhere.
+	OpMatch        Op = ":"
+	OpEqual        Op = "="
+	OpNotEqual     Op = "!="
+	OpLess         Op = "<"
+	OpLessEqual    Op = "<="
+	OpGreater      Op = ">"
+	OpGreaterEqual Op = ">="
+)
+
+// Flags accepted by is: (and not:).
+const (
+	FlagSold    = "sold"
+	FlagHeld    = "held"
+	FlagFoil    = "foil"
+	FlagNonfoil = "nonfoil"
+	FlagTrade   = "trade"
+)
+
+// ColorMulticolor is the Text of a color or identity term matching cards
+// with two or more colors. Other color terms have the color letters in WUBRG
+// order as Text, which is empty for colorless.
+const ColorMulticolor = "multicolor"
+
+// Term is a single condition. Which value is set depends on the field: Text
+// for text fields, colors, rarities and is:, Number for numeric fields and
+// Date for dates. Rarities are given by their full name.
+//
+// The parser does not know the custom fields of a user, so a FieldCustom
+// term only has the field name in Name and the value in Text. The caller
+// looks the field up and fills in FieldID and Kind, and Number or Date for
+// number and date fields, before the query is run.
+type Term struct {
+	Field   Field
+	Op      Op
+	Text    string
+	Number  float64
+	Date    time.Time
+	Name    string
+	FieldID uint
+	Kind    string
+}
+
+// Terms returns every term of the query, left to right.
+func Terms(expr Expr) []*Term {
+	switch e := expr.(type) {
+	case *And:
+		return append(Terms(e.Left), Terms(e.Right)...)
+	case *Or:
+		return append(Terms(e.Left), Terms(e.Right)...)
+	case *Not:
+		return Terms(e.Expr)
+	case *Term:
+		return []*Term{e}
+	}
+	return nil
+}
+
+func (*And) isExpr()  {}
+func (*Or) isExpr()   {}
+func (*Not) isExpr()  {}
+func (*Term) isExpr() {}
+
+// Error describes a malformed query. Pos is the byte offset of the problem.
+type Error struct {
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid search at position %d: %s", e.Pos+1, e.Message)
+}
@@ -0,0 +1,163 @@
+package cardquery
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenWord
+	tokenTerm
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	field string
+	op    Op
+	value string
+}
+
+// operators is ordered so two-character operators are tried first.
+var operators = []Op{OpNotEqual, OpLessEqual, OpGreaterEqual, OpMatch, OpEqual, OpLess, OpGreater}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for {
+		for pos < len(input) && unicode.IsSpace(rune(input[pos])) {
+			pos++
+		}
+		if pos == len(input) {
+			return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+		}
+
+		start := pos
+		switch input[pos] {
+		case '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: pos})
+			pos++
+			continue
+		case ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: pos})
+			pos++
+			continue
+		case '-':
+			if pos+1 < len(input) && !unicode.IsSpace(rune(input[pos+1])) {
+				tokens = append(tokens, token{kind: tokenNot, pos: pos})
+				pos++
+				continue
+			}
+		case '"':
+			value, end, err := readQuoted(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenWord, pos: start, value: value})
+			pos = end
+			continue
+		}
+
+		// A field name is a run of letters directly followed by an operator,
+		// or for custom fields letters, a dot and the custom field name
+		name := pos
+		for name < len(input) && isFieldChar(input[name]) {
+			name++
+		}
+		if name > pos && name < len(input) && input[name] == '.' {
+			name++
+			for name < len(input) && (isFieldChar(input[name]) || input[name] == '_' || input[name] >= '0' && input[name] <= '9') {
+				name++
+			}
+		}
+		if name > pos {
+			if op, ok := operatorAt(input, name); ok {
+				tok := token{kind: tokenTerm, pos: start, field: strings.ToLower(input[pos:name]), op: op}
+				pos = name + len(op)
+				if pos < len(input) && input[pos] == '"' {
+					value, end, err := readQuoted(input, pos)
+					if err != nil {
+						return nil, err
+					}
+					tok.value = value
+					pos = end
+				} else {
+					end := pos
+					for end < len(input) && !isWordEnd(input[end]) {
+						end++
+					}
+					tok.value = input[pos:end]
+					pos = end
+				}
+				if tok.value == "" {
+					return nil, &Error{Pos: start, Message: "missing value after " + input[start:pos]}
+				}
+				tokens = append(tokens, tok)
+				continue
+			}
+		}
+
+		end := pos
+		for end < len(input) && !isWordEnd(input[end]) {
+			end++
+		}
+		word := input[pos:end]
+		pos = end
+
+		switch strings.ToLower(word) {
+		case "and":
+			tokens = append(tokens, token{kind: tokenAnd, pos: start})
+		case "or":
+			tokens = append(tokens, token{kind: tokenOr, pos: start})
+		case "not":
+			tokens = append(tokens, token{kind: tokenNot, pos: start})
+		default:
+			tokens = append(tokens, token{kind: tokenWord, pos: start, value: word})
+		}
+	}
+}
+
+// readQuoted reads a double-quoted string starting at pos. A backslash
+// escapes the next character.
+func readQuoted(input string, pos int) (string, int, error) {
+	var value strings.Builder
+	for i := pos + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 < len(input) {
+				i++
+				value.WriteByte(input[i])
+			}
+		case '"':
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(input[i])
+		}
+	}
+	return "", 0, &Error{Pos: pos, Message: "missing closing quote"}
+}
+
+func operatorAt(input string, pos int) (Op, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(input[pos:], string(op)) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func isFieldChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordEnd(c byte) bool {
+	return c == '(' || c == ')' || unicode.IsSpace(rune(c))
+}
@@ -0,0 +1,316 @@
+package cardquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type fieldKind int
+
+const (
+	kindContains fieldKind = iota
+	kindExact
+	kindNumber
+	kindDate
+	kindFlag
+	kindColor
+	kindRarity
+)
+
+var fieldKinds = map[Field]fieldKind{
+	FieldName:      kindContains,
+	FieldSet:       kindExact,
+	FieldNumber:    kindExact,
+	FieldLanguage:  kindExact,
+	FieldQuantity:  kindNumber,
+	FieldPrice:     kindNumber,
+	FieldForTrade:  kindNumber,
+	FieldBought:    kindDate,
+	FieldSold:      kindDate,
+	FieldIs:        kindFlag,
+	FieldTag:       kindExact,
+	FieldColor:     kindColor,
+	FieldIdentity:  kindColor,
+	FieldType:      kindContains,
+	FieldManaValue: kindNumber,
+	FieldRarity:    kindRarity,
+	FieldOracle:    kindExact,
+}
+
+// fieldNames maps every accepted field name, including short aliases, to its
+// field. "not" is handled separately as a negated is:.
+var fieldNames = map[string]Field{
+	"name":      FieldName,
+	"n":         FieldName,
+	"set":       FieldSet,
+	"s":         FieldSet,
+	"e":         FieldSet,
+	"edition":   FieldSet,
+	"cn":        FieldNumber,
+	"number":    FieldNumber,
+	"lang":      FieldLanguage,
+	"l":         FieldLanguage,
+	"language":  FieldLanguage,
+	"qty":       FieldQuantity,
+	"quantity":  FieldQuantity,
+	"price":     FieldPrice,
+	"trade":     FieldForTrade,
+	"fortrade":  FieldForTrade,
+	"bought":    FieldBought,
+	"sold":      FieldSold,
+	"is":        FieldIs,
+	"tag":       FieldTag,
+	"color":     FieldColor,
+	"c":         FieldColor,
+	"identity":  FieldIdentity,
+	"id":        FieldIdentity,
+	"ci":        FieldIdentity,
+	"type":      FieldType,
+	"t":         FieldType,
+	"mv":        FieldManaValue,
+	"cmc":       FieldManaValue,
+	"manavalue": FieldManaValue,
+	"rarity":    FieldRarity,
+	"r":         FieldRarity,
+	"oracle":    FieldOracle,
+}
+
+// colorWords maps the words a color term accepts besides color letters to
+// the letters they stand for.
+var colorWords = map[string]string{
+	"white":      "W",
+	"blue":       "U",
+	"black":      "B",
+	"red":        "R",
+	"green":      "G",
+	"c":          "",
+	"colorless":  "",
+	"m":          ColorMulticolor,
+	"multi":      ColorMulticolor,
+	"multicolor": ColorMulticolor,
+}
+
+var flags = []string{FlagSold, FlagHeld, FlagFoil, FlagNonfoil, FlagTrade}
+
+// Parse parses a search string. An empty or blank string returns a nil Expr
+// and no error, meaning "match everything".
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if tokens[0].kind == tokenEOF {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := p.peek(); tok.kind {
+	case tokenEOF:
+		return expr, nil
+	case tokenRParen:
+		return nil, &Error{Pos: tok.pos, Message: "unexpected \")\" without a matching \"(\""}
+	default:
+		return nil, &Error{Pos: tok.pos, Message: "unexpected input"}
+	}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseAnd joins terms written next to each other, with or without an
+// explicit AND.
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenEOF, tokenOr, tokenRParen:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNot:
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: tok.pos, Message: "missing closing \")\""}
+		}
+		return expr, nil
+	case tokenWord:
+		return &Term{Field: FieldText, Op: OpMatch, Text: tok.value}, nil
+	case tokenTerm:
+		return parseTerm(tok)
+	case tokenEOF:
+		return nil, &Error{Pos: tok.pos, Message: "unexpected end of search"}
+	case tokenRParen:
+		return nil, &Error{Pos: tok.pos, Message: "unexpected \")\""}
+	default:
+		return nil, &Error{Pos: tok.pos, Message: "expected a search term"}
+	}
+}
+
+func parseTerm(tok token) (Expr, error) {
+	if tok.field == "not" {
+		tok.field = "is"
+		term, err := parseTerm(tok)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: term}, nil
+	}
+
+	if name, ok := strings.CutPrefix(tok.field, string(FieldCustom)+"."); ok && name != "" {
+		return &Term{Field: FieldCustom, Op: tok.op, Name: name, Text: tok.value}, nil
+	}
+
+	field, ok := fieldNames[tok.field]
+	if !ok {
+		return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("unknown field %q", tok.field)}
+	}
+	term := &Term{Field: field, Op: tok.op}
+
+	switch fieldKinds[field] {
+	case kindContains, kindExact:
+		if tok.op != OpMatch && tok.op != OpEqual && tok.op != OpNotEqual {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("%s only supports :, = and !=", tok.field)}
+		}
+		term.Text = tok.value
+	case kindNumber:
+		number, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("%s needs a number, got %q", tok.field, tok.value)}
+		}
+		term.Number = number
+	case kindDate:
+		date, err := time.Parse("2006-01-02", tok.value)
+		if err != nil {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("%s needs a date as YYYY-MM-DD, got %q", tok.field, tok.value)}
+		}
+		term.Date = date
+	case kindColor:
+		colors, ok := parseColors(tok.value)
+		if !ok {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("unknown color %q (use letters of wubrg, a color name, colorless or multicolor)", tok.value)}
+		}
+		if colors == ColorMulticolor && tok.op != OpMatch && tok.op != OpEqual && tok.op != OpNotEqual {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("%s:multicolor only supports :, = and !=", tok.field)}
+		}
+		term.Text = colors
+	case kindRarity:
+		rarity, ok := parseRarity(tok.value)
+		if !ok {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("unknown rarity %q", tok.value)}
+		}
+		term.Text = string(rarity)
+	case kindFlag:
+		if tok.op != OpMatch && tok.op != OpEqual {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("%s only supports :", tok.field)}
+		}
+		term.Text = strings.ToLower(tok.value)
+		if !validFlag(term.Text) {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("unknown flag %q (use one of %s)", tok.value, strings.Join(flags, ", "))}
+		}
+	}
+
+	return term, nil
+}
+
+// parseColors returns the color letters of a color value in WUBRG order, or
+// ColorMulticolor. Letters may be given in any order and case.
+func parseColors(value string) (string, bool) {
+	value = strings.ToLower(value)
+	if colors, ok := colorWords[value]; ok {
+		return colors, true
+	}
+
+	var colors strings.Builder
+	for _, color := range entity.Colors {
+		if strings.Contains(value, strings.ToLower(color)) {
+			colors.WriteString(color)
+		}
+	}
+	if strings.Trim(value, "wubrg") != "" {
+		return "", false
+	}
+	return colors.String(), true
+}
+
+// parseRarity reads a rarity given by its name or first letter.
+func parseRarity(value string) (entity.Rarity, bool) {
+	value = strings.ToLower(value)
+	for _, rarity := range entity.Rarities {
+		if value == string(rarity) || value == string(rarity[:1]) {
+			return rarity, true
+		}
+	}
+	return "", false
+}
+
+func validFlag(flag string) bool {
+	for _, f := range flags {
+		if flag == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package cardquery_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+)
+
+func term(field cardquery.Field, op cardquery.Op, text string) *cardquery.Term {
+	return &cardquery.Term{Field: field, Op: op, Text: text}
+}
+
+func TestParse(t *testing.T) {
+	bought := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  cardquery.Expr
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"bolt", term(cardquery.FieldText, cardquery.OpMatch, "bolt")},
+		{`"lightning bolt"`, term(cardquery.FieldText, cardquery.OpMatch, "lightning bolt")},
+		{"set:mh2 lang:ja", &cardquery.And{
+			Left:  term(cardquery.FieldSet, cardquery.OpMatch, "mh2"),
+			Right: term(cardquery.FieldLanguage, cardquery.OpMatch, "ja"),
+		}},
+		{"e=MH2", term(cardquery.FieldSet, cardquery.OpEqual, "MH2")},
+		{"qty>=4", &cardquery.Term{Field: cardquery.FieldQuantity, Op: cardquery.OpGreaterEqual, Number: 4}},
+		{"price<99.5", &cardquery.Term{Field: cardquery.FieldPrice, Op: cardquery.OpLess, Number: 99.5}},
+		{"bought>2024-01-01", &cardquery.Term{Field: cardquery.FieldBought, Op: cardquery.OpGreater, Date: bought}},
+		{"IS:Sold", term(cardquery.FieldIs, cardquery.OpMatch, "sold")},
+		{"not:foil", &cardquery.Not{Expr: term(cardquery.FieldIs, cardquery.OpMatch, "foil")}},
+		{`-name:"goblin guide"`, &cardquery.Not{Expr: term(cardquery.FieldName, cardquery.OpMatch, "goblin guide")}},
+		{"a or b c", &cardquery.Or{
+			Left: term(cardquery.FieldText, cardquery.OpMatch, "a"),
+			Right: &cardquery.And{
+				Left:  term(cardquery.FieldText, cardquery.OpMatch, "b"),
+				Right: term(cardquery.FieldText, cardquery.OpMatch, "c"),
+			},
+		}},
+		{"(a OR b) AND NOT c", &cardquery.And{
+			Left: &cardquery.Or{
+				Left:  term(cardquery.FieldText, cardquery.OpMatch, "a"),
+				Right: term(cardquery.FieldText, cardquery.OpMatch, "b"),
+			},
+			Right: &cardquery.Not{Expr: term(cardquery.FieldText, cardquery.OpMatch, "c")},
+		}},
+		{"-(set:mh2)", &cardquery.Not{Expr: term(cardquery.FieldSet, cardquery.OpMatch, "mh2")}},
+		{`name:"say \"hi\""`, term(cardquery.FieldName, cardquery.OpMatch, `say "hi"`)},
+		{`tag:"for cube"`, term(cardquery.FieldTag, cardquery.OpMatch, "for cube")},
+		{"cf.Grade_2>=9", &cardquery.Term{Field: cardquery.FieldCustom, Op: cardquery.OpGreaterEqual, Name: "grade_2", Text: "9"}},
+		{"c:RG id<=bw", &cardquery.And{
+			Left:  term(cardquery.FieldColor, cardquery.OpMatch, "RG"),
+			Right: term(cardquery.FieldIdentity, cardquery.OpLessEqual, "WB"),
+		}},
+		{"color=colorless", term(cardquery.FieldColor, cardquery.OpEqual, "")},
+		{"c:m", term(cardquery.FieldColor, cardquery.OpMatch, cardquery.ColorMulticolor)},
+		{`t:"legendary creature"`, term(cardquery.FieldType, cardquery.OpMatch, "legendary creature")},
+		{"cmc>=3", &cardquery.Term{Field: cardquery.FieldManaValue, Op: cardquery.OpGreaterEqual, Number: 3}},
+		{"r>=R", term(cardquery.FieldRarity, cardquery.OpGreaterEqual, "rare")},
+		{"rarity:mythic", term(cardquery.FieldRarity, cardquery.OpMatch, "mythic")},
+		{"e.g. bolt", &cardquery.And{
+			Left:  term(cardquery.FieldText, cardquery.OpMatch, "e.g."),
+			Right: term(cardquery.FieldText, cardquery.OpMatch, "bolt"),
+		}},
+	}
+
+	for _, tt := range tests {
+		got, err := cardquery.Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{"(set:mh2", 1, "missing closing"},
+		{"set:mh2)", 8, "unexpected \")\""},
+		{"()", 2, "unexpected \")\""},
+		{"bolt or", 8, "unexpected end"},
+		{"power>3", 1, "unknown field \"power\""},
+		{"c:purple", 1, "unknown color"},
+		{"c>=multi", 1, "only supports"},
+		{"r:epic", 1, "unknown rarity"},
+		{"set.x:1", 1, "unknown field \"set.x\""},
+		{"tag>proxy", 1, "only supports"},
+		{"qty>many", 1, "needs a number"},
+		{"bought>01/02/2024", 1, "YYYY-MM-DD"},
+		{"name>goblin", 1, "only supports"},
+		{"is:shiny", 1, "unknown flag"},
+		{`name:"goblin`, 6, "missing closing quote"},
+		{"set:", 1, "missing value"},
+	}
+
+	for _, tt := range tests {
+		_, err := cardquery.Parse(tt.input)
+		var queryErr *cardquery.Error
+		if !errors.As(err, &queryErr) {
+			t.Errorf("Parse(%q): expected *cardquery.Error, got %v", tt.input, err)
+			continue
+		}
+		if !strings.Contains(queryErr.Message, tt.message) {
+			t.Errorf("Parse(%q): expected message containing %q, got %q", tt.input, tt.message, queryErr.Message)
+		}
+		if !strings.Contains(err.Error(), "position") || queryErr.Pos+1 != tt.pos {
+			t.Errorf("Parse(%q): expected position %d, got %q", tt.input, tt.pos, err.Error())
+		}
+	}
+}
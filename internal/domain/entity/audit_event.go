@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+type AuditSource string
+
+const (
+	AuditSourceWeb   AuditSource = "web"
+	AuditSourceTrade AuditSource = "trade"
+)
+
+// AuditChange describes a single field that differs between the card state
+// before and after an event.
+type AuditChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditEvent is an append-only record of a change to a user's collection.
+// Events are never updated or deleted once written.
+type AuditEvent struct {
+	ID        uint          `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time     `gorm:"index" json:"created_at"`
+	UserID    uint          `gorm:"not null;index" json:"user_id"`
+	ActorID   uint          `gorm:"not null" json:"actor_id"`
+	CardID    uint          `gorm:"not null;index" json:"card_id"`
+	CardName  string        `gorm:"size:255" json:"card_name"`
+	Action    AuditAction   `gorm:"size:20;not null" json:"action"`
+	Source    AuditSource   `gorm:"size:20;not null" json:"source"`
+	Changes   []AuditChange `gorm:"type:text;serializer:json" json:"changes"`
+}
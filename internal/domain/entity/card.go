@@ -6,20 +6,59 @@ import (
 	"gorm.io/gorm"
 )
 
+// Card is a stack of identical copies owned by a user. The index on user and
+// creation time serves the default, newest first, card listing.
+//
+// ImageHash names the cached CardImage shown for the card, if any, and
+// ImageSourceURL is the CardImageURL it was last fetched for, so a changed
+// URL is fetched again.
+//
+// Language is the code of one of Languages, or empty if it was not recorded.
+//
+// The embedded CardMetadata is copied from the catalog printing with the
+// same set code and collector number.
 type Card struct {
 	ID              uint           `gorm:"primarykey" json:"id"`
-	CreatedAt       time.Time      `json:"created_at"`
+	CreatedAt       time.Time      `gorm:"index:idx_cards_user_created,priority:2" json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
-	UserID          uint           `gorm:"not null;index" json:"user_id"`
+	UserID          uint           `gorm:"not null;index;index:idx_cards_user_created,priority:1" json:"user_id"`
 	CardName        string         `gorm:"size:255;not null" json:"card_name"`
 	CardImageURL    string         `gorm:"size:500" json:"card_image_url"`
+	ImageHash       string         `gorm:"size:64" json:"image_hash"`
+	ImageSourceURL  string         `gorm:"size:500;not null;default:''" json:"-"`
 	SetCode         string         `gorm:"size:20" json:"set_code"`
 	CollectorNumber string         `gorm:"size:20" json:"collector_number"`
 	Language        string         `gorm:"size:50" json:"language"`
+	Foil            bool           `gorm:"not null;default:false" json:"foil"`
 	Quantity        int            `gorm:"default:1" json:"quantity"`
+	ForTrade        int            `gorm:"not null;default:0" json:"for_trade"`
 	BuyingPrice     float64        `gorm:"type:decimal(10,2)" json:"buying_price"`
 	BoughtDate      *time.Time     `json:"bought_date"`
 	SellDate        *time.Time     `json:"sell_date"`
+	LocationID      *uint          `gorm:"index" json:"location_id"`
+	Version         uint           `gorm:"not null;default:1" json:"version"`
 	User            User           `gorm:"foreignKey:UserID" json:"-"`
+	Location        *Location      `gorm:"foreignKey:LocationID" json:"-"`
+	CardMetadata
+}
+
+// CardMetadata is what the card catalog knows about the printing of a card,
+// stored with the card so it can be searched and grouped by. Colors and
+// ColorIdentity hold color letters in WUBRG order. Everything is empty for
+// cards whose printing is not in the catalog, which Known tells apart from
+// colorless cards.
+type CardMetadata struct {
+	Colors        string  `gorm:"size:5;not null;default:''" json:"colors"`
+	ColorIdentity string  `gorm:"size:5;not null;default:''" json:"color_identity"`
+	TypeLine      string  `gorm:"size:255;not null;default:''" json:"type_line"`
+	ManaValue     float64 `gorm:"not null;default:0" json:"mana_value"`
+	Rarity        Rarity  `gorm:"size:20;not null;default:''" json:"rarity"`
+	OracleID      string  `gorm:"size:36;not null;default:''" json:"oracle_id"`
+}
+
+// Known reports whether the metadata was found in the catalog. Every
+// catalog printing has a rarity.
+func (m CardMetadata) Known() bool {
+	return m.Rarity != ""
 }
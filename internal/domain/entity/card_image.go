@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// ImageSize names one rendition of a card image.
+type ImageSize string
+
+const (
+	ImageSizeSmall    ImageSize = "small"
+	ImageSizeNormal   ImageSize = "normal"
+	ImageSizeLarge    ImageSize = "large"
+	ImageSizeOriginal ImageSize = "original"
+)
+
+// ThumbnailWidths maps every generated thumbnail size to its width in pixels.
+// The widths follow Scryfall's image sizes; smaller originals are not scaled
+// up.
+var ThumbnailWidths = map[ImageSize]int{
+	ImageSizeSmall:  146,
+	ImageSizeNormal: 488,
+	ImageSizeLarge:  672,
+}
+
+// CardImage is a card picture kept in the blob store. Each distinct file is
+// stored once and shared by every card showing it. Hash is the hex SHA-256
+// of the original file and names its blobs; SourceURL is where it was
+// downloaded from, empty for uploads.
+type CardImage struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Hash        string    `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+	SourceURL   string    `gorm:"size:500;index" json:"source_url"`
+	ContentType string    `gorm:"size:50" json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+}
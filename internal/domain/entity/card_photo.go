@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// PhotoSide says which part of a card a photo shows.
+type PhotoSide string
+
+const (
+	PhotoSideFront  PhotoSide = "front"
+	PhotoSideBack   PhotoSide = "back"
+	PhotoSideDetail PhotoSide = "detail"
+)
+
+var PhotoSides = []PhotoSide{PhotoSideFront, PhotoSideBack, PhotoSideDetail}
+
+var photoSideLabels = map[PhotoSide]string{
+	PhotoSideFront:  "Front",
+	PhotoSideBack:   "Back",
+	PhotoSideDetail: "Detail",
+}
+
+// Label returns the display name of the side, or the raw value if the side
+// is unknown.
+func (s PhotoSide) Label() string {
+	if label, ok := photoSideLabels[s]; ok {
+		return label
+	}
+	return string(s)
+}
+
+// Valid reports whether the side is one of PhotoSides.
+func (s PhotoSide) Valid() bool {
+	_, ok := photoSideLabels[s]
+	return ok
+}
+
+// CardPhoto is a user's own photo of one of their cards, e.g. the front and
+// back of a graded card as proof of condition. The original file and a
+// normal size thumbnail are kept in the blob store.
+type CardPhoto struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	CardID      uint      `gorm:"not null;index" json:"card_id"`
+	Side        PhotoSide `gorm:"size:10;not null" json:"side"`
+	Caption     string    `gorm:"size:255" json:"caption"`
+	ContentType string    `gorm:"size:50" json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+}
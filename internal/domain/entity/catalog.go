@@ -0,0 +1,79 @@
+package entity
+
+import "time"
+
+type Rarity string
+
+const (
+	RarityCommon   Rarity = "common"
+	RarityUncommon Rarity = "uncommon"
+	RarityRare     Rarity = "rare"
+	RarityMythic   Rarity = "mythic"
+	RaritySpecial  Rarity = "special"
+	RarityBonus    Rarity = "bonus"
+)
+
+// Colors lists the five colors in WUBRG order.
+var Colors = []string{"W", "U", "B", "R", "G"}
+
+// Rarities lists the rarities in display order.
+var Rarities = []Rarity{
+	RarityCommon,
+	RarityUncommon,
+	RarityRare,
+	RarityMythic,
+	RaritySpecial,
+	RarityBonus,
+}
+
+// CatalogSet is a set from the local card catalog. The catalog is shared by
+// all users and is loaded with the `catalog` subcommand.
+type CatalogSet struct {
+	Code       string     `gorm:"primarykey;size:20" json:"code"`
+	Name       string     `gorm:"size:255;not null" json:"name"`
+	ReleasedAt *time.Time `json:"released_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CatalogCard is one printing in a catalog set, identified by set code and
+// collector number. Colors and ColorIdentity hold the color letters in WUBRG
+// order and are empty for colorless cards. OracleID is shared by every
+// printing of the same card. Nonfoil and Foil tell which finishes were
+// printed.
+type CatalogCard struct {
+	ID              uint    `gorm:"primarykey" json:"id"`
+	SetCode         string  `gorm:"size:20;not null;uniqueIndex:idx_catalog_printing" json:"set_code"`
+	CollectorNumber string  `gorm:"size:20;not null;uniqueIndex:idx_catalog_printing" json:"collector_number"`
+	Name            string  `gorm:"size:255;not null" json:"name"`
+	Rarity          Rarity  `gorm:"size:20;not null" json:"rarity"`
+	Colors          string  `gorm:"size:5;not null;default:''" json:"colors"`
+	ColorIdentity   string  `gorm:"size:5;not null;default:''" json:"color_identity"`
+	TypeLine        string  `gorm:"size:255;not null;default:''" json:"type_line"`
+	ManaValue       float64 `gorm:"not null;default:0" json:"mana_value"`
+	OracleID        string  `gorm:"size:36;not null;default:''" json:"oracle_id"`
+	Nonfoil         bool    `gorm:"not null" json:"nonfoil"`
+	Foil            bool    `gorm:"not null" json:"foil"`
+}
+
+// Metadata returns what cards of the printing store about it.
+func (c CatalogCard) Metadata() CardMetadata {
+	return CardMetadata{
+		Colors:        c.Colors,
+		ColorIdentity: c.ColorIdentity,
+		TypeLine:      c.TypeLine,
+		ManaValue:     c.ManaValue,
+		Rarity:        c.Rarity,
+		OracleID:      c.OracleID,
+	}
+}
+
+// CatalogTranslation is a printing in a language other than English: the
+// name printed on the card and the URL of its image in that language.
+type CatalogTranslation struct {
+	ID              uint   `gorm:"primarykey" json:"id"`
+	SetCode         string `gorm:"size:20;not null;uniqueIndex:idx_catalog_translation" json:"set_code"`
+	CollectorNumber string `gorm:"size:20;not null;uniqueIndex:idx_catalog_translation" json:"collector_number"`
+	Lang            string `gorm:"size:10;not null;uniqueIndex:idx_catalog_translation" json:"lang"`
+	Name            string `gorm:"size:255;not null" json:"name"`
+	ImageURL        string `gorm:"size:500" json:"image_url"`
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeckZone string
+
+const (
+	DeckZoneMainboard DeckZone = "mainboard"
+	DeckZoneSideboard DeckZone = "sideboard"
+	DeckZoneCommander DeckZone = "commander"
+)
+
+// DeckZones lists the zones in display order.
+var DeckZones = []DeckZone{DeckZoneCommander, DeckZoneMainboard, DeckZoneSideboard}
+
+// DeckFormats lists the formats a deck can be built for.
+var DeckFormats = []string{
+	"standard",
+	"pioneer",
+	"modern",
+	"legacy",
+	"vintage",
+	"pauper",
+	"commander",
+	"brawl",
+	"limited",
+	"casual",
+}
+
+type Deck struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	Format      string         `gorm:"size:50" json:"format"`
+	Description string         `gorm:"type:text" json:"description"`
+	Entries     []DeckEntry    `gorm:"foreignKey:DeckID" json:"entries"`
+	User        User           `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// CardCount returns the number of cards across all zones of the deck.
+func (d *Deck) CardCount() int {
+	total := 0
+	for _, entry := range d.Entries {
+		total += entry.Quantity
+	}
+	return total
+}
+
+// DeckEntry is one line of a decklist. SetCode and CollectorNumber are
+// optional and only record a preferred printing; ownership checks match on
+// the card name.
+type DeckEntry struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	DeckID          uint      `gorm:"not null;index" json:"deck_id"`
+	UserID          uint      `gorm:"not null;index" json:"user_id"`
+	CardName        string    `gorm:"size:255;not null" json:"card_name"`
+	SetCode         string    `gorm:"size:20" json:"set_code"`
+	CollectorNumber string    `gorm:"size:20" json:"collector_number"`
+	Quantity        int       `gorm:"default:1" json:"quantity"`
+	Zone            DeckZone  `gorm:"size:20;not null" json:"zone"`
+	Deck            *Deck     `gorm:"foreignKey:DeckID" json:"-"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "strings"
+
+// Language is a language cards are printed in, identified by the code
+// Scryfall uses for it. Aliases are other lower case spellings people use
+// for the language, such as the codes on MTG price lists.
+type Language struct {
+	Code    string
+	Name    string
+	Aliases []string
+}
+
+// Languages lists every language cards are stored in, in display order.
+var Languages = []Language{
+	{Code: "en", Name: "English", Aliases: []string{"eng", "en-us"}},
+	{Code: "es", Name: "Spanish", Aliases: []string{"sp", "spa", "español", "espanol"}},
+	{Code: "fr", Name: "French", Aliases: []string{"fra", "fre", "français", "francais"}},
+	{Code: "de", Name: "German", Aliases: []string{"ger", "deu", "deutsch"}},
+	{Code: "it", Name: "Italian", Aliases: []string{"ita", "italiano"}},
+	{Code: "pt", Name: "Portuguese", Aliases: []string{"por", "pt-br", "brazilian portuguese", "portuguese (brazil)", "português", "portugues"}},
+	{Code: "ja", Name: "Japanese", Aliases: []string{"jp", "jpn", "日本語"}},
+	{Code: "ko", Name: "Korean", Aliases: []string{"kr", "kor", "한국어"}},
+	{Code: "ru", Name: "Russian", Aliases: []string{"rus", "русский"}},
+	{Code: "zhs", Name: "Simplified Chinese", Aliases: []string{"cs", "zh-cn", "zh-hans", "chinese simplified", "chinese (simplified)", "chinese"}},
+	{Code: "zht", Name: "Traditional Chinese", Aliases: []string{"ct", "zh-tw", "zh-hant", "chinese traditional", "chinese (traditional)"}},
+	{Code: "he", Name: "Hebrew"},
+	{Code: "la", Name: "Latin"},
+	{Code: "grc", Name: "Ancient Greek"},
+	{Code: "ar", Name: "Arabic"},
+	{Code: "sa", Name: "Sanskrit"},
+	{Code: "ph", Name: "Phyrexian"},
+}
+
+// LanguageEnglish is the code of English, the language of most cards and the
+// one the card catalog is kept in.
+const LanguageEnglish = "en"
+
+// FindLanguage looks up a language by its code, name or one of its aliases,
+// ignoring case and surrounding space.
+func FindLanguage(value string) (Language, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, language := range Languages {
+		if value == language.Code || value == strings.ToLower(language.Name) {
+			return language, true
+		}
+		for _, alias := range language.Aliases {
+			if value == alias {
+				return language, true
+			}
+		}
+	}
+	return Language{}, false
+}
+
+// LanguageName returns the name of a language code, or the value itself if
+// it is not a known language.
+func LanguageName(code string) string {
+	if language, ok := FindLanguage(code); ok {
+		return language.Name
+	}
+	return code
+}
@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// LoanDirection tells whether copies were lent out of the collection or
+// borrowed from someone else.
+type LoanDirection string
+
+const (
+	LoanLent     LoanDirection = "lent"
+	LoanBorrowed LoanDirection = "borrowed"
+)
+
+// Loan records copies of a card lent to or borrowed from someone. Lent copies
+// stay in the collection while the loan is outstanding but are not available
+// for decks or trades. Borrowed copies belong to someone else: they have no
+// card row, so CardID is 0 and they never count as owned or towards the
+// collection value, and Borrower is the friend they were borrowed from. The
+// card details are copied so the loan stays readable after the card row
+// changes.
+type Loan struct {
+	ID              uint          `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	UserID          uint          `gorm:"not null;index" json:"user_id"`
+	Direction       LoanDirection `gorm:"size:10;not null;default:lent" json:"direction"`
+	CardID          uint          `gorm:"not null;index" json:"card_id"`
+	CardName        string        `gorm:"size:255;not null" json:"card_name"`
+	SetCode         string        `gorm:"size:20" json:"set_code"`
+	CollectorNumber string        `gorm:"size:20" json:"collector_number"`
+	Borrower        string        `gorm:"size:100;not null" json:"borrower"`
+	Quantity        int           `gorm:"not null" json:"quantity"`
+	LentAt          time.Time     `gorm:"not null" json:"lent_at"`
+	DueAt           *time.Time    `json:"due_at"`
+	ReturnedAt      *time.Time    `gorm:"index" json:"returned_at"`
+	Notes           string        `gorm:"type:text" json:"notes"`
+}
+
+// IsBorrowed reports whether the copies were borrowed rather than lent out.
+func (l *Loan) IsBorrowed() bool {
+	return l.Direction == LoanBorrowed
+}
+
+// IsOutstanding reports whether the copies have not been returned yet.
+func (l *Loan) IsOutstanding() bool {
+	return l.ReturnedAt == nil
+}
+
+// IsOverdue reports whether the loan is outstanding past its expected return
+// date. The due date itself is not overdue.
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return l.IsOutstanding() && l.DueAt != nil && now.After(l.DueAt.AddDate(0, 0, 1))
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LocationKind string
+
+const (
+	LocationKindShelf  LocationKind = "shelf"
+	LocationKindBox    LocationKind = "box"
+	LocationKindBinder LocationKind = "binder"
+	LocationKindPage   LocationKind = "page"
+	LocationKindSlot   LocationKind = "slot"
+)
+
+// LocationKinds lists the kinds from outermost to innermost. A location can
+// only be placed inside a location of an earlier kind, so a page may sit in a
+// binder, box or shelf but never the other way round. A slot is a single
+// pocket of a page, or of a box divider, holding one card row.
+var LocationKinds = []LocationKind{
+	LocationKindShelf,
+	LocationKindBox,
+	LocationKindBinder,
+	LocationKindPage,
+	LocationKindSlot,
+}
+
+// Rank returns the position of the kind in LocationKinds, or -1 if the kind
+// is unknown.
+func (k LocationKind) Rank() int {
+	for i, kind := range LocationKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return -1
+}
+
+// Location is a place where physical cards are stored. Locations form a
+// hierarchy through ParentID, e.g. shelf > box > binder > page > slot.
+type Location struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	ParentID  *uint          `gorm:"index" json:"parent_id"`
+	Kind      LocationKind   `gorm:"size:20;not null" json:"kind"`
+	Name      string         `gorm:"size:255;not null" json:"name"`
+	Parent    *Location      `gorm:"foreignKey:ParentID" json:"-"`
+	User      User           `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,91 @@
+package entity
+
+import "time"
+
+// CostMethod decides which purchase lots a sale consumes and at what cost.
+type CostMethod string
+
+const (
+	// CostMethodFIFO sells the oldest copies first.
+	CostMethodFIFO CostMethod = "fifo"
+	// CostMethodLIFO sells the most recently bought copies first.
+	CostMethodLIFO CostMethod = "lifo"
+	// CostMethodAverage values every sold copy at the average cost of the
+	// copies held, consuming lots oldest first for their dates.
+	CostMethodAverage CostMethod = "average"
+)
+
+var CostMethods = []CostMethod{CostMethodFIFO, CostMethodLIFO, CostMethodAverage}
+
+// PurchaseLot is a number of copies of a card bought together at one unit
+// cost. Remaining counts the copies that are still held; sales and copies
+// leaving the stack otherwise reduce it. HeldUnitCost is the cost basis per
+// held copy: it starts at UnitCost and is only changed by average cost sales,
+// which revalue the copies left over to the average.
+type PurchaseLot struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	CardID       uint       `gorm:"not null;index" json:"card_id"`
+	Quantity     int        `gorm:"not null" json:"quantity"`
+	Remaining    int        `gorm:"not null" json:"remaining"`
+	UnitCost     float64    `gorm:"type:decimal(10,2)" json:"unit_cost"`
+	HeldUnitCost float64    `gorm:"type:decimal(12,4)" json:"held_unit_cost"`
+	AcquiredAt   *time.Time `json:"acquired_at"`
+	Source       string     `gorm:"size:100" json:"source"`
+}
+
+// CardSale records copies sold out of a card stack, with the lots they were
+// taken from. CardID is the stack the copies came from and SoldCardID the row
+// that keeps the sold copies in the collection history. The card details are
+// copied so the sale stays readable on its own.
+type CardSale struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UserID          uint            `gorm:"not null;index" json:"user_id"`
+	CardID          uint            `gorm:"not null;index" json:"card_id"`
+	SoldCardID      uint            `gorm:"not null" json:"sold_card_id"`
+	CardName        string          `gorm:"size:255;not null" json:"card_name"`
+	SetCode         string          `gorm:"size:20" json:"set_code"`
+	CollectorNumber string          `gorm:"size:20" json:"collector_number"`
+	Quantity        int             `gorm:"not null" json:"quantity"`
+	UnitPrice       float64         `gorm:"type:decimal(10,2)" json:"unit_price"`
+	SoldAt          time.Time       `gorm:"not null;index" json:"sold_at"`
+	Method          CostMethod      `gorm:"size:10;not null" json:"method"`
+	Allocations     []LotAllocation `gorm:"foreignKey:SaleID" json:"allocations"`
+}
+
+// Proceeds returns what the sold copies brought in.
+func (s CardSale) Proceeds() float64 {
+	return s.UnitPrice * float64(s.Quantity)
+}
+
+// CostBasis returns the cost of the lots the sale consumed.
+func (s CardSale) CostBasis() float64 {
+	var cost float64
+	for _, allocation := range s.Allocations {
+		cost += allocation.Cost()
+	}
+	return cost
+}
+
+// Gain returns the realized gain, negative for a loss.
+func (s CardSale) Gain() float64 {
+	return s.Proceeds() - s.CostBasis()
+}
+
+// LotAllocation is the part of a purchase lot consumed by a sale. UnitCost is
+// the cost per copy under the sale's cost method, which for average cost
+// differs from the lot's own unit cost.
+type LotAllocation struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	SaleID     uint       `gorm:"not null;index" json:"sale_id"`
+	LotID      uint       `gorm:"not null;index" json:"lot_id"`
+	Quantity   int        `gorm:"not null" json:"quantity"`
+	UnitCost   float64    `gorm:"type:decimal(12,4)" json:"unit_cost"`
+	AcquiredAt *time.Time `json:"acquired_at"`
+}
+
+func (a LotAllocation) Cost() float64 {
+	return a.UnitCost * float64(a.Quantity)
+}
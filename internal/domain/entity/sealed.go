@@ -0,0 +1,83 @@
+package entity
+
+import "time"
+
+type SealedProductType string
+
+const (
+	SealedTypeBoosterBox  SealedProductType = "booster_box"
+	SealedTypeBoosterPack SealedProductType = "booster_pack"
+	SealedTypeBundle      SealedProductType = "bundle"
+	SealedTypePrecon      SealedProductType = "precon"
+	SealedTypeBoxSet      SealedProductType = "box_set"
+	SealedTypeAccessory   SealedProductType = "accessory"
+	SealedTypeOther       SealedProductType = "other"
+)
+
+var SealedProductTypes = []SealedProductType{
+	SealedTypeBoosterBox,
+	SealedTypeBoosterPack,
+	SealedTypeBundle,
+	SealedTypePrecon,
+	SealedTypeBoxSet,
+	SealedTypeAccessory,
+	SealedTypeOther,
+}
+
+var sealedTypeLabels = map[SealedProductType]string{
+	SealedTypeBoosterBox:  "Booster box",
+	SealedTypeBoosterPack: "Booster pack",
+	SealedTypeBundle:      "Bundle",
+	SealedTypePrecon:      "Preconstructed deck",
+	SealedTypeBoxSet:      "Box set",
+	SealedTypeAccessory:   "Accessory",
+	SealedTypeOther:       "Other",
+}
+
+// Label returns the display name of the type, or the raw value if the type
+// is unknown.
+func (t SealedProductType) Label() string {
+	if label, ok := sealedTypeLabels[t]; ok {
+		return label
+	}
+	return string(t)
+}
+
+// Valid reports whether the type is one of SealedProductTypes.
+func (t SealedProductType) Valid() bool {
+	_, ok := sealedTypeLabels[t]
+	return ok
+}
+
+// Openable reports whether the product contains cards, so that opening it
+// turns it into card rows.
+func (t SealedProductType) Openable() bool {
+	return t != SealedTypeAccessory
+}
+
+// SealedProduct is a number of identical sealed items a user holds, such as
+// booster boxes, precons or accessories. Quantity counts the unopened units
+// and Opened the units that were opened into cards. UnitCost is per unit.
+type SealedProduct struct {
+	ID         uint              `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	UserID     uint              `gorm:"not null;index" json:"user_id"`
+	Name       string            `gorm:"size:255;not null" json:"name"`
+	SetCode    string            `gorm:"size:20" json:"set_code"`
+	Type       SealedProductType `gorm:"size:20;not null" json:"type"`
+	Quantity   int               `gorm:"not null;default:1" json:"quantity"`
+	Opened     int               `gorm:"not null;default:0" json:"opened"`
+	UnitCost   float64           `gorm:"type:decimal(10,2)" json:"unit_cost"`
+	BoughtDate *time.Time        `json:"bought_date"`
+	SellDate   *time.Time        `json:"sell_date"`
+	Notes      string            `gorm:"type:text" json:"notes"`
+}
+
+// HeldCost returns the cost of the unopened units that are still held.
+func (p *SealedProduct) HeldCost() float64 {
+	if p.SellDate != nil {
+		return 0
+	}
+	return p.UnitCost * float64(p.Quantity)
+}
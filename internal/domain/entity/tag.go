@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTagColor is used for tags created without a color.
+const DefaultTagColor = "#6c757d"
+
+// Tag is a user-defined label such as "proxy" or "for cube" that can be put
+// on any number of cards. Color is a hex color like "#1e90ff".
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_tags_user_name,priority:1" json:"user_id"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex:idx_tags_user_name,priority:2" json:"name"`
+	Color     string    `gorm:"size:7;not null" json:"color"`
+}
+
+// TextColor returns black or white, whichever reads better on the tag color.
+func (t Tag) TextColor() string {
+	hex := strings.TrimPrefix(t.Color, "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return "#fff"
+	}
+	r, g, b := value>>16, value>>8&0xff, value&0xff
+	// Perceived brightness, as in the WCAG contrast guidance
+	if 299*r+587*g+114*b > 150000 {
+		return "#000"
+	}
+	return "#fff"
+}
+
+// CardTag puts a tag on a card.
+type CardTag struct {
+	ID     uint `gorm:"primarykey" json:"-"`
+	CardID uint `gorm:"not null;uniqueIndex:idx_card_tags_card_tag,priority:1" json:"card_id"`
+	TagID  uint `gorm:"not null;index;uniqueIndex:idx_card_tags_card_tag,priority:2" json:"tag_id"`
+}
+
+type CustomFieldKind string
+
+const (
+	CustomFieldText   CustomFieldKind = "text"
+	CustomFieldNumber CustomFieldKind = "number"
+	CustomFieldDate   CustomFieldKind = "date"
+	// CustomFieldEnum only accepts one of the field's choices.
+	CustomFieldEnum CustomFieldKind = "enum"
+)
+
+var CustomFieldKinds = []CustomFieldKind{CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldEnum}
+
+// CustomField is a user-defined property every card of the user can have a
+// value for, e.g. a grade or who signed it. The name is also the search
+// field: a field "grade" is searched with cf.grade>=9. Options holds the
+// choices of an enum field, one per line.
+type CustomField struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	UserID    uint            `gorm:"not null;uniqueIndex:idx_custom_fields_user_name,priority:1" json:"user_id"`
+	Name      string          `gorm:"size:50;not null;uniqueIndex:idx_custom_fields_user_name,priority:2" json:"name"`
+	Kind      CustomFieldKind `gorm:"size:10;not null" json:"kind"`
+	Options   string          `gorm:"size:1000" json:"options,omitempty"`
+}
+
+// Choices returns the choices of an enum field.
+func (f CustomField) Choices() []string {
+	var choices []string
+	for _, line := range strings.Split(f.Options, "\n") {
+		if choice := strings.TrimSpace(line); choice != "" {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// CustomFieldValue is the value of a custom field on a card. Value holds it
+// as text, dates as YYYY-MM-DD; number and date fields also fill Number or
+// Date so they can be compared in searches.
+type CustomFieldValue struct {
+	ID      uint       `gorm:"primarykey" json:"-"`
+	CardID  uint       `gorm:"not null;uniqueIndex:idx_custom_field_values_card_field,priority:1" json:"card_id"`
+	FieldID uint       `gorm:"not null;index;uniqueIndex:idx_custom_field_values_card_field,priority:2" json:"field_id"`
+	Value   string     `gorm:"size:255;not null" json:"value"`
+	Number  *float64   `json:"-"`
+	Date    *time.Time `json:"-"`
+}
@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+type TradeStatus string
+
+const (
+	TradeStatusPending   TradeStatus = "pending"
+	TradeStatusCountered TradeStatus = "countered"
+	TradeStatusAccepted  TradeStatus = "accepted"
+	TradeStatusDeclined  TradeStatus = "declined"
+	TradeStatusCancelled TradeStatus = "cancelled"
+)
+
+// TradeProposal is an offer from one user to another. A counter-offer is a
+// new proposal in the opposite direction that points at the one it answers
+// through ParentID.
+type TradeProposal struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	ProposerID  uint           `gorm:"not null;index" json:"proposer_id"`
+	RecipientID uint           `gorm:"not null;index" json:"recipient_id"`
+	ParentID    *uint          `gorm:"index" json:"parent_id"`
+	Status      TradeStatus    `gorm:"size:20;not null;index" json:"status"`
+	Message     string         `gorm:"type:text" json:"message"`
+	ResolvedAt  *time.Time     `json:"resolved_at"`
+	Lines       []TradeLine    `gorm:"foreignKey:ProposalID" json:"lines"`
+	Proposer    User           `gorm:"foreignKey:ProposerID" json:"-"`
+	Recipient   User           `gorm:"foreignKey:RecipientID" json:"-"`
+	Parent      *TradeProposal `gorm:"foreignKey:ParentID" json:"-"`
+}
+
+// IsOpen reports whether the proposal can still be accepted, declined or
+// countered.
+func (p *TradeProposal) IsOpen() bool {
+	return p.Status == TradeStatusPending
+}
+
+// TradeLine is a number of copies from one card row that change hands. The
+// card details and value are copied at proposal time, so the line stays
+// readable after the source row is traded away.
+type TradeLine struct {
+	ID              uint    `gorm:"primarykey" json:"id"`
+	ProposalID      uint    `gorm:"not null;index" json:"proposal_id"`
+	FromUserID      uint    `gorm:"not null" json:"from_user_id"`
+	CardID          uint    `gorm:"not null" json:"card_id"`
+	CardName        string  `gorm:"size:255;not null" json:"card_name"`
+	SetCode         string  `gorm:"size:20" json:"set_code"`
+	CollectorNumber string  `gorm:"size:20" json:"collector_number"`
+	Quantity        int     `gorm:"not null" json:"quantity"`
+	UnitValue       float64 `gorm:"type:decimal(10,2)" json:"unit_value"`
+}
+
+// Value returns the total value of the line.
+func (l TradeLine) Value() float64 {
+	return float64(l.Quantity) * l.UnitValue
+}
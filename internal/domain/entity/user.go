@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,4 +15,35 @@ type User struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 	Username  string         `gorm:"uniqueIndex;size:100;not null" json:"username"`
 	Password  string         `gorm:"size:255;not null" json:"-"`
+	// DeleteAfter is set when the user asks for their account to be deleted.
+	// The account and everything tied to it is purged once it has passed.
+	DeleteAfter *time.Time `gorm:"index" json:"delete_after"`
+	// CardListView is the query string of the sort and filters last used on
+	// the card list, restored when the list is opened without any.
+	CardListView string `gorm:"size:1000" json:"card_list_view"`
+	// CostMethod decides which purchase lots sales consume.
+	CostMethod CostMethod `gorm:"size:10;not null;default:fifo" json:"cost_method"`
+	// Currency is the ISO 4217 code of the currency prices are recorded in,
+	// used to label amounts on reports. Amounts are never converted.
+	Currency string `gorm:"size:3;not null;default:THB" json:"currency"`
+}
+
+// DefaultCurrency is the currency of users who never chose one.
+const DefaultCurrency = "THB"
+
+// Currencies lists the currencies a user can choose from.
+var Currencies = []string{"THB", "USD", "EUR", "GBP", "JPY", "SGD", "AUD", "CAD", "CHF"}
+
+// DeletedUsernamePrefix starts the username given to purged accounts. New
+// accounts cannot use it, so a purged username never clashes with a real one.
+const DeletedUsernamePrefix = "deleted-user-"
+
+// DeletedUsername is the username a purged account is renamed to.
+func DeletedUsername(id uint) string {
+	return fmt.Sprintf("%s%d", DeletedUsernamePrefix, id)
+}
+
+// IsReservedUsername reports whether a username is kept for purged accounts.
+func IsReservedUsername(username string) bool {
+	return strings.HasPrefix(strings.ToLower(username), DeletedUsernamePrefix)
 }
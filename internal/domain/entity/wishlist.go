@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WishlistPriority int
+
+const (
+	WishlistPriorityLow    WishlistPriority = 1
+	WishlistPriorityNormal WishlistPriority = 2
+	WishlistPriorityHigh   WishlistPriority = 3
+)
+
+// WishlistPriorities lists the priorities from highest to lowest.
+var WishlistPriorities = []WishlistPriority{
+	WishlistPriorityHigh,
+	WishlistPriorityNormal,
+	WishlistPriorityLow,
+}
+
+func (p WishlistPriority) String() string {
+	switch p {
+	case WishlistPriorityLow:
+		return "low"
+	case WishlistPriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// WishlistItem is a card the user wants to acquire. Without a SetCode any
+// printing of the card will do.
+type WishlistItem struct {
+	ID              uint             `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
+	UserID          uint             `gorm:"not null;index" json:"user_id"`
+	CardName        string           `gorm:"size:255;not null" json:"card_name"`
+	SetCode         string           `gorm:"size:20" json:"set_code"`
+	CollectorNumber string           `gorm:"size:20" json:"collector_number"`
+	Quantity        int              `gorm:"default:1" json:"quantity"`
+	MaxPrice        float64          `gorm:"type:decimal(10,2)" json:"max_price"`
+	Priority        WishlistPriority `gorm:"not null;default:2" json:"priority"`
+	Notes           string           `gorm:"type:text" json:"notes"`
+	// Acquired counts the copies bought through the wishlist.
+	Acquired    int        `gorm:"not null;default:0" json:"acquired"`
+	FulfilledAt *time.Time `json:"fulfilled_at"`
+	User        User       `gorm:"foreignKey:UserID" json:"-"`
+}
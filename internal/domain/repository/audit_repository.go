@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+// AuditRepository stores collection audit events. It is append-only: events
+// are never updated, and the only way to remove them is erasing a whole
+// user's history when their account is deleted.
+type AuditRepository interface {
+	Create(ctx context.Context, event *entity.AuditEvent) error
+	FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.AuditEvent, error)
+	FindByUserID(ctx context.Context, userID uint, page, pageSize int) ([]entity.AuditEvent, int64, error)
+	FindAllByUserID(ctx context.Context, userID uint) ([]entity.AuditEvent, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get for a key that holds no blob.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// ErrInvalidBlobKey is returned for keys that are empty, absolute or not in
+// clean slash-separated form.
+var ErrInvalidBlobKey = errors.New("invalid blob key")
+
+// BlobStore keeps binary files, such as card images, under slash-separated
+// keys like "cards/ab/abcd.../small". Storing to an existing key replaces it.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type CardImageRepository interface {
+	// Create stores image unless an image with the same hash exists, in which
+	// case image is filled with the stored one. A stored image without a
+	// source URL takes the one of image.
+	Create(ctx context.Context, image *entity.CardImage) error
+	FindByHash(ctx context.Context, hash string) (*entity.CardImage, error)
+	// FindBySourceURL returns the image most recently downloaded from url.
+	FindBySourceURL(ctx context.Context, url string) (*entity.CardImage, error)
+	// FindPendingCards returns up to limit cards of any user whose image URL
+	// has not been fetched yet, oldest first.
+	FindPendingCards(ctx context.Context, limit int) ([]entity.Card, error)
+	// SetCardImage records that the card's image was fetched from sourceURL
+	// and shows the image with the given hash. It leaves the card's version
+	// alone, as it is not an edit by the user.
+	SetCardImage(ctx context.Context, cardID uint, sourceURL string, hash string) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type CardPhotoRepository interface {
+	Create(ctx context.Context, photo *entity.CardPhoto) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.CardPhoto, error)
+	// FindByCardID returns the photos of a card in upload order.
+	FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardPhoto, error)
+	// FindByUserID returns every photo of a user, including those of
+	// soft-deleted cards.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.CardPhoto, error)
+	// CountByCardID counts the photos of a card.
+	CountByCardID(ctx context.Context, cardID uint, userID uint) (int64, error)
+	// DeleteByUserID permanently removes every photo row of a user. The files
+	// have to be removed from the blob store first.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -1,11 +1,182 @@
 package repository
 
-import "github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+// ErrVersionConflict is returned by Update when the card was modified since
+// it was loaded.
+var ErrVersionConflict = errors.New("card was modified by another request")
+
+// ErrLocationNotFound is returned by UpdateBatch when a card is assigned a
+// location that does not belong to the user.
+var ErrLocationNotFound = errors.New("location not found")
+
+// OwnedQuantity is the number of unsold copies a user holds of one printing.
+type OwnedQuantity struct {
+	CardName        string
+	SetCode         string
+	CollectorNumber string
+	Quantity        int
+}
+
+// OwnedPrinting is the number of unsold copies a user holds of one printing
+// in one finish.
+type OwnedPrinting struct {
+	SetCode         string
+	CollectorNumber string
+	Foil            bool
+	Quantity        int
+}
+
+// CardSortKey is a field the card list can be ordered by.
+type CardSortKey string
+
+const (
+	SortByCreated  CardSortKey = "created"
+	SortByName     CardSortKey = "name"
+	SortBySet      CardSortKey = "set"
+	SortByPrice    CardSortKey = "price"
+	SortByQuantity CardSortKey = "quantity"
+	SortByBought   CardSortKey = "bought"
+	SortBySold     CardSortKey = "sold"
+)
+
+// CardSortKeys lists every accepted sort key.
+var CardSortKeys = []CardSortKey{SortByCreated, SortByName, SortBySet, SortByPrice, SortByQuantity, SortByBought, SortBySold}
+
+// Color groups of CardFilter.Color besides the color letters.
+const (
+	ColorMulticolor = "multicolor"
+	ColorColorless  = "colorless"
+)
+
+// CardFilter narrows down and orders a card listing. Zero values do not
+// filter; the zero sort lists the newest cards first.
+type CardFilter struct {
+	// Query is a parsed search; nil matches every card.
+	Query cardquery.Expr
+	// LocationIDs limits the listing to cards stored in one of the locations.
+	LocationIDs []uint
+	// Unassigned limits the listing to cards without a location.
+	Unassigned bool
+	// Language matches the language ignoring case; a language code such as
+	// "ja" also matches the full name.
+	Language string
+	// Color limits the listing to one color group of the catalog metadata:
+	// a color letter for mono-colored cards, ColorMulticolor or
+	// ColorColorless.
+	Color string
+	// Rarity limits the listing to cards of the rarity.
+	Rarity entity.Rarity
+	// Sold limits the listing to sold (true) or unsold (false) cards.
+	Sold *bool
+	// MinPrice and MaxPrice bound the buying price, inclusive.
+	MinPrice *float64
+	MaxPrice *float64
+	// BoughtFrom, BoughtTo, SoldFrom and SoldTo bound the dates by whole
+	// days, inclusive. Cards without the date are left out.
+	BoughtFrom *time.Time
+	BoughtTo   *time.Time
+	SoldFrom   *time.Time
+	SoldTo     *time.Time
+	// Sort is the field to order by; unknown keys fall back to
+	// SortByCreated. Ties are broken by ID in the same direction.
+	Sort      CardSortKey
+	Ascending bool
+}
+
+// CardCursor is a position in a card listing: the ID and sort values of the
+// card at the edge of a page. A listing continues after it, or before it when
+// Backward is set, in the order given by the listing's CardFilter.
+type CardCursor struct {
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CardName        string     `json:"card_name,omitempty"`
+	SetCode         string     `json:"set_code,omitempty"`
+	CollectorNumber string     `json:"collector_number,omitempty"`
+	BuyingPrice     float64    `json:"buying_price,omitempty"`
+	Quantity        int        `json:"quantity,omitempty"`
+	BoughtDate      *time.Time `json:"bought_date,omitempty"`
+	SellDate        *time.Time `json:"sell_date,omitempty"`
+	Backward        bool       `json:"backward,omitempty"`
+}
+
+// NewCardCursor returns the position of card in a listing.
+func NewCardCursor(card entity.Card, backward bool) CardCursor {
+	return CardCursor{
+		ID:              card.ID,
+		CreatedAt:       card.CreatedAt,
+		CardName:        card.CardName,
+		SetCode:         card.SetCode,
+		CollectorNumber: card.CollectorNumber,
+		BuyingPrice:     card.BuyingPrice,
+		Quantity:        card.Quantity,
+		BoughtDate:      card.BoughtDate,
+		SellDate:        card.SellDate,
+		Backward:        backward,
+	}
+}
+
+// BatchResult reports what UpdateBatch did not simply save. Skipped holds
+// the rows it left unchanged, by ID, with ErrVersionConflict or
+// ErrCopiesLent. Photos are the photos of the deleted rows; their rows were
+// deleted with the cards, their files are left to the caller.
+type BatchResult struct {
+	Skipped map[uint]error
+	Photos  []entity.CardPhoto
+}
 
 type CardRepository interface {
-	Create(card *entity.Card) error
-	Update(card *entity.Card) error
-	Delete(id uint, userID uint) error
-	FindByID(id uint, userID uint) (*entity.Card, error)
-	FindByUserID(userID uint, page, pageSize int, search string) ([]entity.Card, int64, error)
+	Create(ctx context.Context, card *entity.Card) error
+	// Update and Delete fail with ErrCopiesLent if the card would be left
+	// with fewer copies than are lent out.
+	Update(ctx context.Context, card *entity.Card) error
+	// Delete removes a card and its photo rows in one transaction and
+	// returns the photos, whose files the caller removes once committed.
+	Delete(ctx context.Context, id uint, userID uint) ([]entity.CardPhoto, error)
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.Card, error)
+	// Split saves source, whose quantity has been reduced, and creates part
+	// for the copies taken from it, in one transaction. The source update is
+	// subject to the same version check as Update. Purchase lots covering the
+	// moved copies go with them, oldest first, and both rows are repriced to
+	// the average cost of the lots they hold. The part gets the tags and
+	// custom field values of source. Loans and photos stay with source, so
+	// it fails with ErrCopiesLent if source keeps fewer copies than are lent.
+	Split(ctx context.Context, source *entity.Card, part *entity.Card) error
+	// Merge saves target, which has taken over the copies of merged, and
+	// deletes the merged rows in one transaction. Every row is subject to the
+	// same version check as Update. Purchase lots, loans, photos and open
+	// trade proposals of a merged row are pointed at target instead, and
+	// target gets the tags and custom field values it is missing.
+	Merge(ctx context.Context, target *entity.Card, merged []entity.Card) error
+	// UpdateBatch saves updated and deletes deleted, all owned by userID, in
+	// one transaction. Rows that fail the version check of Update, or would
+	// be left with fewer copies than are lent out, are skipped while the rest
+	// is committed. A location assigned to an updated card that userID does
+	// not own fails the whole batch with ErrLocationNotFound.
+	UpdateBatch(ctx context.Context, userID uint, updated []entity.Card, deleted []entity.Card) (*BatchResult, error)
+	FindByUserID(ctx context.Context, userID uint, page, pageSize int, filter CardFilter) ([]entity.Card, int64, error)
+	// FindPageByUserID lists up to limit cards following the cursor, or the
+	// first cards if it is nil. It uses keyset pagination instead of OFFSET,
+	// so deep pages are as cheap as the first. Cards are always returned in
+	// listing order, also for a backward cursor.
+	FindPageByUserID(ctx context.Context, userID uint, limit int, filter CardFilter, cursor *CardCursor) ([]entity.Card, error)
+	// CountByUserID counts the cards matching the filter.
+	CountByUserID(ctx context.Context, userID uint, filter CardFilter) (int64, error)
+	FindAllByUserID(ctx context.Context, userID uint) ([]entity.Card, error)
+	// OwnedQuantities sums the unsold copies of the given card names per
+	// printing.
+	OwnedQuantities(ctx context.Context, userID uint, names []string) ([]OwnedQuantity, error)
+	// OwnedPrintings sums the unsold copies of every card with a set code per
+	// printing and finish.
+	OwnedPrintings(ctx context.Context, userID uint) ([]OwnedPrinting, error)
+	// DeleteByUserID permanently removes every card of a user, including
+	// soft-deleted ones.
+	DeleteByUserID(ctx context.Context, userID uint) error
 }
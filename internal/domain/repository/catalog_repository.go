@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type CatalogRepository interface {
+	// UpsertSets creates the sets or updates them if they already exist.
+	UpsertSets(ctx context.Context, sets []entity.CatalogSet) error
+	// UpsertCards stores printings keyed by set code and collector number.
+	// Existing printings are only overwritten when overwrite is true.
+	UpsertCards(ctx context.Context, cards []entity.CatalogCard, overwrite bool) error
+	// FindSets returns every catalog set, newest release first.
+	FindSets(ctx context.Context) ([]entity.CatalogSet, error)
+	FindSet(ctx context.Context, code string) (*entity.CatalogSet, error)
+	// FindCardsBySet returns the printings of the given sets.
+	FindCardsBySet(ctx context.Context, codes []string) ([]entity.CatalogCard, error)
+	// FindPrintings returns the printings matching the set code and
+	// collector number of the given cards. Cards without either have none.
+	FindPrintings(ctx context.Context, cards []entity.Card) ([]entity.CatalogCard, error)
+	// ApplyMetadata copies the metadata of every printing onto the cards of
+	// all users, deleted or not, with its set code and collector number, and
+	// returns how many cards changed. Cards whose printing is not in the
+	// catalog keep what they have. Card versions are left alone.
+	ApplyMetadata(ctx context.Context) (int64, error)
+	// UpsertTranslations stores translated printings keyed by set code,
+	// collector number and language, overwriting existing ones.
+	UpsertTranslations(ctx context.Context, translations []entity.CatalogTranslation) error
+	// FindTranslations returns the translations matching the set, collector
+	// number and language of the given cards. Cards in English or without a
+	// set, number or language have none.
+	FindTranslations(ctx context.Context, cards []entity.Card) ([]entity.CatalogTranslation, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type DeckRepository interface {
+	Create(ctx context.Context, deck *entity.Deck) error
+	Update(ctx context.Context, deck *entity.Deck) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	// FindByID loads a deck together with its entries.
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.Deck, error)
+	FindByUserID(ctx context.Context, userID uint) ([]entity.Deck, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+
+	CreateEntry(ctx context.Context, entry *entity.DeckEntry) error
+	DeleteEntry(ctx context.Context, id uint, deckID uint, userID uint) error
+	// FindEntriesByCardNames returns the entries of all of a user's live decks
+	// that use one of the given card names, with their Deck loaded.
+	FindEntriesByCardNames(ctx context.Context, userID uint, names []string) ([]entity.DeckEntry, error)
+}
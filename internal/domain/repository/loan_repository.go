@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+// ErrLoanUnavailable is returned when a card no longer has enough copies
+// that are not already lent out.
+var ErrLoanUnavailable = errors.New("not enough copies are available to lend")
+
+// ErrCopiesLent is returned when a change would leave a card with fewer
+// copies than are lent out.
+var ErrCopiesLent = errors.New("copies of this card are lent out; mark them returned first")
+
+type LoanRepository interface {
+	// Create stores a loan. A lent loan is only stored if the card still has
+	// enough copies that are not lent out, checked against the locked card
+	// row.
+	Create(ctx context.Context, loan *entity.Loan) error
+	Update(ctx context.Context, loan *entity.Loan) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.Loan, error)
+	// FindByUserID returns every loan of a user, most recently lent first.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.Loan, error)
+	// FindOutstanding returns the loans of a user that have not been
+	// returned, oldest due date first; loans without a due date come last.
+	FindOutstanding(ctx context.Context, userID uint) ([]entity.Loan, error)
+	// DeleteByUserID permanently removes every loan of a user.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
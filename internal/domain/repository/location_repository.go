@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type LocationRepository interface {
+	Create(ctx context.Context, location *entity.Location) error
+	Update(ctx context.Context, location *entity.Location) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.Location, error)
+	FindByUserID(ctx context.Context, userID uint) ([]entity.Location, error)
+	// DeleteByUserID permanently removes every location of a user, including
+	// soft-deleted ones.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type LotRepository interface {
+	// FindByCardID returns the purchase lots of a card, oldest first.
+	FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.PurchaseLot, error)
+	// FindByUserID returns every purchase lot of a user, oldest first.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.PurchaseLot, error)
+	// SaveLots saves card, whose quantity and cost were derived from lots,
+	// and creates or updates the lots in one transaction. The card update is
+	// subject to the same version check as CardRepository.Update.
+	SaveLots(ctx context.Context, card *entity.Card, lots []entity.PurchaseLot) error
+	// RecordSale saves the stack the copies were sold from, the lots they
+	// consumed and the sale in one transaction. For a partial sale, sold is
+	// created as a new row for the sold copies; it is nil when the whole
+	// stack was sold and card itself became the sold row. Copies lent out
+	// cannot be sold: if the stack keeps fewer copies than are lent, it
+	// fails with ErrCopiesLent.
+	RecordSale(ctx context.Context, card *entity.Card, sold *entity.Card, lots []entity.PurchaseLot, sale *entity.CardSale) error
+	// FindSalesByUserID returns the sales of a user with their allocations,
+	// oldest first.
+	FindSalesByUserID(ctx context.Context, userID uint) ([]entity.CardSale, error)
+	// FindSalesByCardID returns the sales made out of a stack, oldest first.
+	FindSalesByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardSale, error)
+	// DeleteByUserID removes every lot and sale of a user.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+// ErrNothingToOpen is returned when a sealed product has no unopened units
+// left, e.g. because the last one was opened in the meantime.
+var ErrNothingToOpen = errors.New("no unopened units of this product are left")
+
+type SealedProductRepository interface {
+	Create(ctx context.Context, product *entity.SealedProduct) error
+	Update(ctx context.Context, product *entity.SealedProduct) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.SealedProduct, error)
+	// FindByUserID returns the sealed products of a user, newest first.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.SealedProduct, error)
+	// Open takes one unopened unit of a product and creates the cards it
+	// contained in one transaction. It returns ErrNothingToOpen when no unit
+	// is left.
+	Open(ctx context.Context, product *entity.SealedProduct, cards []entity.Card) error
+	// DeleteByUserID permanently removes every sealed product of a user.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
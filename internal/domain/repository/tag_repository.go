@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type TagRepository interface {
+	Create(ctx context.Context, tag *entity.Tag) error
+	Update(ctx context.Context, tag *entity.Tag) error
+	// Delete removes a tag and takes it off every card.
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.Tag, error)
+	FindByUserID(ctx context.Context, userID uint) ([]entity.Tag, error)
+	// FindCardTags returns the tags of the user put on the given cards, or
+	// on any card when cardIDs is nil.
+	FindCardTags(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CardTag, error)
+	// SetCardTags replaces the tags on a card.
+	SetCardTags(ctx context.Context, cardID uint, tagIDs []uint) error
+	// DeleteByUserID permanently removes every tag of a user and takes them
+	// off the cards.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+type CustomFieldRepository interface {
+	Create(ctx context.Context, field *entity.CustomField) error
+	Update(ctx context.Context, field *entity.CustomField) error
+	// Delete removes a custom field together with its values.
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.CustomField, error)
+	FindByUserID(ctx context.Context, userID uint) ([]entity.CustomField, error)
+	// FindValues returns the values of the user's custom fields on the given
+	// cards, or on any card when cardIDs is nil.
+	FindValues(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CustomFieldValue, error)
+	// SetCardValues replaces the custom field values of a card.
+	SetCardValues(ctx context.Context, cardID uint, values []entity.CustomFieldValue) error
+	// DeleteByUserID permanently removes every custom field of a user with
+	// its values.
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
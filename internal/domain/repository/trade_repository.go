@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+var (
+	// ErrProposalNotPending is returned when a proposal was already resolved.
+	ErrProposalNotPending = errors.New("trade proposal is no longer pending")
+	// ErrTradeUnavailable is returned when a card row no longer has enough
+	// copies marked for trade.
+	ErrTradeUnavailable = errors.New("cards are no longer available for trade")
+)
+
+// CardTransfer moves copies out of one card row into a new row owned by
+// another user.
+type CardTransfer struct {
+	Line     entity.TradeLine
+	ToUserID uint
+}
+
+// CardTransferResult reports the rows touched by a transfer. After.Quantity
+// is zero when the source row was used up and removed; its photos then go to
+// the created row, and Photos lists them as they were before the move so
+// their files can follow.
+type CardTransferResult struct {
+	Before  entity.Card
+	After   entity.Card
+	Created entity.Card
+	Photos  []entity.CardPhoto
+}
+
+type TradeRepository interface {
+	// Create stores a proposal with its lines. If the proposal counters
+	// another one, the parent is marked countered in the same transaction.
+	Create(ctx context.Context, proposal *entity.TradeProposal) error
+	// FindByID loads a proposal with its lines if the user is a party to it.
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.TradeProposal, error)
+	// FindByUserID returns the proposals a user sent or received, newest
+	// first.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.TradeProposal, error)
+	// Resolve moves a pending proposal to a final status.
+	Resolve(ctx context.Context, id uint, status entity.TradeStatus, at time.Time) error
+	// Accept marks a pending proposal accepted and performs all transfers in
+	// one transaction. Nothing changes if any row lacks the copies.
+	Accept(ctx context.Context, id uint, transfers []CardTransfer, at time.Time) ([]CardTransferResult, error)
+	// DetachUser prepares the proposals of a user whose account is purged.
+	// They stay in the history of the other party; pending ones are
+	// cancelled and the user's messages are cleared.
+	DetachUser(ctx context.Context, userID uint, at time.Time) error
+}
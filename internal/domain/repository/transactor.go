@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// Transactor runs several repository calls as one unit of work. Calls made
+// with the context handed to fn take part in the transaction, which commits
+// when fn returns nil and rolls back otherwise.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -1,9 +1,28 @@
 package repository
 
-import "github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
 
 type UserRepository interface {
-	Create(user *entity.User) error
-	FindByUsername(username string) (*entity.User, error)
-	FindByID(id uint) (*entity.User, error)
+	Create(ctx context.Context, user *entity.User) error
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	FindByID(ctx context.Context, id uint) (*entity.User, error)
+	FindAll(ctx context.Context) ([]entity.User, error)
+	// The setters below write a single column, so concurrent requests that
+	// change other settings of the same user never overwrite each other.
+	SetDeleteAfter(ctx context.Context, id uint, deleteAfter *time.Time) error
+	SetCardListView(ctx context.Context, id uint, view string) error
+	SetCostMethod(ctx context.Context, id uint, method entity.CostMethod) error
+	SetCurrency(ctx context.Context, id uint, currency string) error
+	// Anonymize replaces the username, clears the password and settings and
+	// soft-deletes the row. The row is kept so trades with other users still
+	// reference a user.
+	Anonymize(ctx context.Context, id uint, at time.Time) error
+	// FindDueForDeletion returns users whose deletion grace period ended
+	// before the given time.
+	FindDueForDeletion(ctx context.Context, before time.Time) ([]entity.User, error)
 }
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+type WishlistRepository interface {
+	Create(ctx context.Context, item *entity.WishlistItem) error
+	Update(ctx context.Context, item *entity.WishlistItem) error
+	Delete(ctx context.Context, id uint, userID uint) error
+	FindByID(ctx context.Context, id uint, userID uint) (*entity.WishlistItem, error)
+	// FindByUserID returns a user's wishlist, highest priority first.
+	FindByUserID(ctx context.Context, userID uint) ([]entity.WishlistItem, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountUseCase *usecase.AccountUseCase
+}
+
+func NewAccountHandler(accountUseCase *usecase.AccountUseCase) *AccountHandler {
+	return &AccountHandler{accountUseCase: accountUseCase}
+}
+
+func (h *AccountHandler) ShowAccountPage(c *gin.Context) {
+	h.renderAccountPage(c, http.StatusOK, "")
+}
+
+func (h *AccountHandler) renderAccountPage(c *gin.Context, status int, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	user, err := h.accountUseCase.GetAccount(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error loading account: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(status, "account.html", gin.H{
+		"title":       "My Account",
+		"username":    username,
+		"user":        user,
+		"gracePeriod": int(usecase.DeletionGracePeriod.Hours() / 24),
+		"costMethods": entity.CostMethods,
+		"currencies":  entity.Currencies,
+		"error":       errorMessage,
+	})
+}
+
+func (h *AccountHandler) ExportAccount(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	filename := fmt.Sprintf("mtg-collection-%s-%s.zip", username, time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if err := h.accountUseCase.WriteExport(c.Request.Context(), userID, c.Writer); err != nil {
+		// Headers may already be sent, so all we can do is log and abort.
+		log.Printf("Error exporting account: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func (h *AccountHandler) RequestDeletion(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	_, err := h.accountUseCase.RequestDeletion(c.Request.Context(), userID,
+		c.PostForm("confirm_username"), c.PostForm("password"))
+	if err != nil {
+		h.renderAccountPage(c, http.StatusOK, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/account")
+}
+
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	if err := h.accountUseCase.CancelDeletion(c.Request.Context(), userID); err != nil {
+		log.Printf("Error cancelling account deletion: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, "/account")
+}
+
+func (h *AccountHandler) SetCostMethod(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	method := entity.CostMethod(c.PostForm("cost_method"))
+	if err := h.accountUseCase.SetCostMethod(c.Request.Context(), userID, method); err != nil {
+		h.renderAccountPage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/account")
+}
+
+func (h *AccountHandler) SetCurrency(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	if err := h.accountUseCase.SetCurrency(c.Request.Context(), userID, c.PostForm("currency")); err != nil {
+		h.renderAccountPage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/account")
+}
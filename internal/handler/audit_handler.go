@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditUseCase *usecase.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{auditUseCase: auditUseCase}
+}
+
+func (h *AuditHandler) CardHistory(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	events, err := h.auditUseCase.CardHistory(c.Request.Context(), uint(cardID), userID)
+	if err != nil {
+		log.Printf("Error loading card history: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	// Events are per-user, so an empty history means the card is unknown
+	// or belongs to someone else.
+	if len(events) == 0 {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "card_history.html", gin.H{
+		"title":    "Card History",
+		"username": username,
+		"cardID":   cardID,
+		"cardName": events[0].CardName,
+		"events":   events,
+	})
+}
+
+func (h *AuditHandler) ActivityFeed(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize := 50
+
+	events, total, err := h.auditUseCase.ActivityFeed(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		log.Printf("Error loading activity feed: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	c.HTML(http.StatusOK, "activity.html", gin.H{
+		"title":      "Collection Activity",
+		"username":   username,
+		"events":     events,
+		"page":       page,
+		"totalPages": totalPages,
+		"total":      total,
+	})
+}
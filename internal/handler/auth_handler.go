@@ -34,7 +34,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	username := c.PostForm("username")
 	password := c.PostForm("password")
 
-	user, err := h.authUseCase.Login(username, password)
+	user, err := h.authUseCase.Login(c.Request.Context(), username, password)
 	if err != nil {
 		c.HTML(http.StatusOK, "login.html", gin.H{
 			"title": "Login",
@@ -84,7 +84,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if err := h.authUseCase.Register(username, password); err != nil {
+	if err := h.authUseCase.Register(c.Request.Context(), username, password); err != nil {
 		c.HTML(http.StatusOK, "register.html", gin.H{
 			"title": "Register",
 			"error": err.Error(),
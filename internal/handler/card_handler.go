@@ -1,22 +1,51 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
 	"github.com/enter42/mtg-collection-tracker/internal/usecase"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxPhotoUploadFiles and maxPhotoUploadBytes bound one photo upload
+	// request; each file is also checked against the image size limit.
+	maxPhotoUploadFiles = 10
+	maxPhotoUploadBytes = 64 << 20
+	// bulkMoveAction is the bulk action of the collection list that moves
+	// the selected cards to a location.
+	bulkMoveAction = "location"
+	// maxImageUploadBytes bounds a card image upload request. It leaves room
+	// for the multipart framing around a file at the image size limit.
+	maxImageUploadBytes = 11 << 20
+)
+
 type CardHandler struct {
-	cardUseCase *usecase.CardUseCase
+	cardUseCase     *usecase.CardUseCase
+	locationUseCase *usecase.LocationUseCase
+	accountUseCase  *usecase.AccountUseCase
+	lotUseCase      *usecase.LotUseCase
+	tagUseCase      *usecase.TagUseCase
+	loanUseCase     *usecase.LoanUseCase
+	imageUseCase    *usecase.ImageUseCase
+	photoUseCase    *usecase.PhotoUseCase
+	setUseCase      *usecase.SetUseCase
 }
 
-func NewCardHandler(cardUseCase *usecase.CardUseCase) *CardHandler {
-	return &CardHandler{cardUseCase: cardUseCase}
+func NewCardHandler(cardUseCase *usecase.CardUseCase, locationUseCase *usecase.LocationUseCase, accountUseCase *usecase.AccountUseCase, lotUseCase *usecase.LotUseCase, tagUseCase *usecase.TagUseCase, loanUseCase *usecase.LoanUseCase, imageUseCase *usecase.ImageUseCase, photoUseCase *usecase.PhotoUseCase, setUseCase *usecase.SetUseCase) *CardHandler {
+	return &CardHandler{cardUseCase: cardUseCase, locationUseCase: locationUseCase, accountUseCase: accountUseCase, lotUseCase: lotUseCase, tagUseCase: tagUseCase, loanUseCase: loanUseCase, imageUseCase: imageUseCase, photoUseCase: photoUseCase, setUseCase: setUseCase}
 }
 
 func (h *CardHandler) ListCards(c *gin.Context) {
@@ -24,11 +53,68 @@ func (h *CardHandler) ListCards(c *gin.Context) {
 	userID := session.Get("user_id").(uint)
 	username := session.Get("username").(string)
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize := 20
-	search := c.Query("search")
 
-	cards, total, err := h.cardUseCase.ListCards(userID, page, pageSize, search)
+	// Opening the list without parameters restores the last sort and filters.
+	// The cursor only pages through a view, so it does not count.
+	params := c.Request.URL.Query()
+	params.Del("cursor")
+	view := cardListView(params)
+	if len(params) == 0 {
+		if user, err := h.accountUseCase.GetAccount(c.Request.Context(), userID); err == nil {
+			saved, _ := url.ParseQuery(user.CardListView)
+			view = cardListView(saved)
+		}
+	}
+	search := view.Get("search")
+
+	locations, err := h.locationUseCase.ListLocations(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing locations: %v", err)
+	}
+
+	query, err := cardquery.Parse(search)
+	if err == nil {
+		err = h.tagUseCase.ResolveSearch(c.Request.Context(), userID, query)
+	}
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "cards.html", gin.H{
+			"title":     "My Card Collection",
+			"username":  username,
+			"error":     err.Error(),
+			"search":    search,
+			"location":  view.Get("location"),
+			"locations": locations,
+			"languages": entity.Languages,
+			"view":      view,
+			"sortLinks": cardSortLinks(view),
+			"total":     0,
+		})
+		return
+	}
+
+	filter := repository.CardFilter{Query: query}
+	applyCardListView(view, &filter)
+
+	location := view.Get("location")
+	if location == "none" {
+		filter.Unassigned = true
+	} else if locationID, err := strconv.ParseUint(location, 10, 32); err == nil {
+		filter.LocationIDs, err = h.locationUseCase.Subtree(c.Request.Context(), uint(locationID), userID)
+		if err != nil {
+			// The location may have been deleted since the view was saved.
+			view.Del("location")
+			location = ""
+			filter.LocationIDs = nil
+		}
+	}
+
+	page, err := h.cardUseCase.ListCardPage(c.Request.Context(), userID, pageSize, filter, c.Query("cursor"), true)
+	if errors.Is(err, usecase.ErrInvalidCursor) {
+		// The cursor is stale, e.g. from before the sort was changed.
+		c.Redirect(http.StatusFound, "/cards?"+view.Encode())
+		return
+	}
 	if err != nil {
 		log.Printf("Error listing cards: %v", err)
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
@@ -38,16 +124,45 @@ func (h *CardHandler) ListCards(c *gin.Context) {
 		return
 	}
 
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if err := h.accountUseCase.SaveCardListView(c.Request.Context(), userID, view.Encode()); err != nil {
+		log.Printf("Error saving card list view: %v", err)
+	}
+
+	cardIDs := make([]uint, 0, len(page.Cards))
+	for _, card := range page.Cards {
+		cardIDs = append(cardIDs, card.ID)
+	}
+	tags, err := h.tagUseCase.CardTags(c.Request.Context(), userID, cardIDs)
+	if err != nil {
+		log.Printf("Error listing card tags: %v", err)
+	}
+	lent, err := h.loanUseCase.LentQuantities(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting lent copies: %v", err)
+	}
+	translations, err := h.setUseCase.Translations(c.Request.Context(), page.Cards)
+	if err != nil {
+		log.Printf("Error looking up card translations: %v", err)
+	}
 
 	c.HTML(http.StatusOK, "cards.html", gin.H{
-		"title":      "My Card Collection",
-		"username":   username,
-		"cards":      cards,
-		"page":       page,
-		"totalPages": totalPages,
-		"search":     search,
-		"total":      total,
+		"title":        "My Card Collection",
+		"username":     username,
+		"cards":        page.Cards,
+		"next":         page.Next,
+		"prev":         page.Prev,
+		"search":       search,
+		"location":     location,
+		"locations":    locations,
+		"languages":    entity.Languages,
+		"view":         view,
+		"listQuery":    template.URL(view.Encode()),
+		"sortLinks":    cardSortLinks(view),
+		"paths":        cardLocationPaths(page.Cards, locations),
+		"tags":         tags,
+		"lent":         lent,
+		"translations": translations,
+		"total":        page.Total,
 	})
 }
 
@@ -56,8 +171,9 @@ func (h *CardHandler) ShowAddCardPage(c *gin.Context) {
 	username := session.Get("username").(string)
 
 	c.HTML(http.StatusOK, "add_card.html", gin.H{
-		"title":    "Add Card",
-		"username": username,
+		"title":     "Add Card",
+		"username":  username,
+		"languages": entity.Languages,
 	})
 }
 
@@ -71,6 +187,7 @@ func (h *CardHandler) AddCard(c *gin.Context) {
 		quantity = 1
 	}
 
+	forTrade, _ := strconv.Atoi(c.PostForm("for_trade"))
 	buyingPrice, _ := strconv.ParseFloat(c.PostForm("buying_price"), 64)
 
 	var boughtDate *time.Time
@@ -94,18 +211,22 @@ func (h *CardHandler) AddCard(c *gin.Context) {
 		SetCode:         c.PostForm("set_code"),
 		CollectorNumber: c.PostForm("collector_number"),
 		Language:        c.PostForm("language"),
+		Foil:            c.PostForm("foil") != "",
 		Quantity:        quantity,
+		ForTrade:        forTrade,
 		BuyingPrice:     buyingPrice,
 		BoughtDate:      boughtDate,
 		SellDate:        sellDate,
+		Source:          entity.AuditSourceWeb,
 	}
 
-	if err := h.cardUseCase.CreateCard(input); err != nil {
+	if err := h.cardUseCase.CreateCard(c.Request.Context(), input); err != nil {
 		log.Printf("Error creating card: %v", err)
 		c.HTML(http.StatusOK, "add_card.html", gin.H{
-			"title":    "Add Card",
-			"username": username,
-			"error":    "Failed to add card",
+			"title":     "Add Card",
+			"username":  username,
+			"languages": entity.Languages,
+			"error":     "Failed to add card",
 		})
 		return
 	}
@@ -116,7 +237,6 @@ func (h *CardHandler) AddCard(c *gin.Context) {
 func (h *CardHandler) ShowEditCardPage(c *gin.Context) {
 	session := sessions.Default(c)
 	userID := session.Get("user_id").(uint)
-	username := session.Get("username").(string)
 
 	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -124,13 +244,120 @@ func (h *CardHandler) ShowEditCardPage(c *gin.Context) {
 		return
 	}
 
-	card, err := h.cardUseCase.GetCard(uint(cardID), userID)
+	h.renderEditCardPage(c, uint(cardID), userID, "")
+}
+
+// renderEditCardPage shows the edit form of a stored card together with its
+// purchase lots and sales.
+func (h *CardHandler) renderEditCardPage(c *gin.Context, cardID uint, userID uint, errorMessage string) {
+	card, err := h.cardUseCase.GetCard(c.Request.Context(), cardID, userID)
 	if err != nil {
 		log.Printf("Error getting card: %v", err)
 		c.Redirect(http.StatusFound, "/cards")
 		return
 	}
 
+	status := http.StatusOK
+	if errorMessage != "" {
+		status = http.StatusBadRequest
+	}
+
+	data := h.editCardPageData(c, card, userID)
+	data["error"] = errorMessage
+	c.HTML(status, "edit_card.html", data)
+}
+
+// editCardPageData loads everything the edit page shows about a stored card
+// and fills the form with its values.
+func (h *CardHandler) editCardPageData(c *gin.Context, card *entity.Card, userID uint) gin.H {
+	session := sessions.Default(c)
+	username := session.Get("username").(string)
+
+	locations, err := h.locationUseCase.ListLocations(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing locations: %v", err)
+	}
+
+	lots, err := h.lotUseCase.Lots(c.Request.Context(), card.ID, userID)
+	if err != nil {
+		log.Printf("Error listing purchase lots: %v", err)
+	}
+
+	sales, err := h.lotUseCase.CardSales(c.Request.Context(), card.ID, userID)
+	if err != nil {
+		log.Printf("Error listing sales: %v", err)
+	}
+
+	tags, err := h.tagUseCase.ListTags(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing tags: %v", err)
+	}
+	cardTags, err := h.tagUseCase.CardTags(c.Request.Context(), userID, []uint{card.ID})
+	if err != nil {
+		log.Printf("Error listing card tags: %v", err)
+	}
+	tagged := make(map[uint]bool)
+	for _, tag := range cardTags[card.ID] {
+		tagged[tag.ID] = true
+	}
+	fields, err := h.tagUseCase.ListFields(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing custom fields: %v", err)
+	}
+	fieldValues, err := h.tagUseCase.CardFieldValues(c.Request.Context(), userID, card.ID)
+	if err != nil {
+		log.Printf("Error listing custom field values: %v", err)
+	}
+
+	loans, err := h.loanUseCase.CardLoans(c.Request.Context(), card.ID, userID)
+	if err != nil {
+		log.Printf("Error listing loans: %v", err)
+	}
+	available := card.Quantity
+	for _, loan := range loans {
+		available -= loan.Quantity
+	}
+
+	photos, err := h.photoUseCase.CardPhotos(c.Request.Context(), card.ID, userID)
+	if err != nil {
+		log.Printf("Error listing photos: %v", err)
+	}
+
+	var translation *entity.CatalogTranslation
+	translations, err := h.setUseCase.Translations(c.Request.Context(), []entity.Card{*card})
+	if err != nil {
+		log.Printf("Error looking up card translation: %v", err)
+	}
+	if found, ok := translations[card.ID]; ok {
+		translation = &found
+	}
+
+	data := gin.H{
+		"title":       "Edit Card",
+		"username":    username,
+		"languages":   entity.Languages,
+		"translation": translation,
+		"locations":   locations,
+		"locationID":  locationID(card),
+		"lots":        lots,
+		"sales":       sales,
+		"tags":        tags,
+		"tagged":      tagged,
+		"fields":      fields,
+		"fieldValues": fieldValues,
+		"loans":       loans,
+		"lendable":    available,
+		"photos":      photos,
+		"photoSides":  entity.PhotoSides,
+		"today":       time.Now().Format("2006-01-02"),
+	}
+	setEditCardForm(data, card)
+	return data
+}
+
+// setEditCardForm fills the edit form fields of the page data with the values
+// of card.
+func setEditCardForm(data gin.H, card *entity.Card) {
 	var boughtDateStr string
 	if card.BoughtDate != nil {
 		boughtDateStr = card.BoughtDate.Format("2006-01-02")
@@ -141,13 +368,13 @@ func (h *CardHandler) ShowEditCardPage(c *gin.Context) {
 		sellDateStr = card.SellDate.Format("2006-01-02")
 	}
 
-	c.HTML(http.StatusOK, "edit_card.html", gin.H{
-		"title":         "Edit Card",
-		"username":      username,
-		"card":          card,
-		"boughtDateStr": boughtDateStr,
-		"sellDateStr":   sellDateStr,
-	})
+	languageCode, unknownLanguage := languageSelection(card.Language)
+
+	data["card"] = card
+	data["boughtDateStr"] = boughtDateStr
+	data["sellDateStr"] = sellDateStr
+	data["languageCode"] = languageCode
+	data["unknownLanguage"] = unknownLanguage
 }
 
 func (h *CardHandler) EditCard(c *gin.Context) {
@@ -165,6 +392,7 @@ func (h *CardHandler) EditCard(c *gin.Context) {
 		quantity = 1
 	}
 
+	forTrade, _ := strconv.Atoi(c.PostForm("for_trade"))
 	buyingPrice, _ := strconv.ParseFloat(c.PostForm("buying_price"), 64)
 
 	var boughtDate *time.Time
@@ -181,29 +409,418 @@ func (h *CardHandler) EditCard(c *gin.Context) {
 		}
 	}
 
+	version, _ := strconv.ParseUint(c.PostForm("version"), 10, 32)
+
 	input := usecase.UpdateCardInput{
 		ID:              uint(cardID),
 		UserID:          userID,
+		Version:         uint(version),
 		CardName:        c.PostForm("card_name"),
 		CardImageURL:    c.PostForm("card_image_url"),
 		SetCode:         c.PostForm("set_code"),
 		CollectorNumber: c.PostForm("collector_number"),
 		Language:        c.PostForm("language"),
+		Foil:            c.PostForm("foil") != "",
 		Quantity:        quantity,
+		ForTrade:        forTrade,
 		BuyingPrice:     buyingPrice,
 		BoughtDate:      boughtDate,
 		SellDate:        sellDate,
+		Source:          entity.AuditSourceWeb,
 	}
 
-	if err := h.cardUseCase.UpdateCard(input); err != nil {
+	if err := h.cardUseCase.UpdateCard(c.Request.Context(), input); err != nil {
+		var conflict *usecase.CardConflictError
+		if errors.As(err, &conflict) {
+			h.renderEditConflict(c, input, conflict.Current)
+			return
+		}
 		log.Printf("Error updating card: %v", err)
-		c.Redirect(http.StatusFound, "/cards")
+		h.renderEditCardPage(c, input.ID, input.UserID, err.Error())
 		return
 	}
 
 	c.Redirect(http.StatusFound, "/cards")
 }
 
+// renderEditConflict re-renders the edit form after a stale update. The form
+// keeps the user's submitted values but carries the current version, so
+// submitting again deliberately overwrites the other change. Everything else
+// on the page shows the current card.
+func (h *CardHandler) renderEditConflict(c *gin.Context, input usecase.UpdateCardInput, current *entity.Card) {
+	// The image, metadata and location are not part of the form
+	submitted := *current
+	submitted.CardName = input.CardName
+	submitted.CardImageURL = input.CardImageURL
+	submitted.SetCode = input.SetCode
+	submitted.CollectorNumber = input.CollectorNumber
+	submitted.Language = input.Language
+	submitted.Foil = input.Foil
+	submitted.Quantity = input.Quantity
+	submitted.ForTrade = input.ForTrade
+	submitted.BuyingPrice = input.BuyingPrice
+	submitted.BoughtDate = input.BoughtDate
+	submitted.SellDate = input.SellDate
+
+	data := h.editCardPageData(c, current, input.UserID)
+	setEditCardForm(data, &submitted)
+	data["current"] = current
+	data["conflicts"] = usecase.DiffCards(current, &submitted)
+	data["error"] = "This card was changed in another window while you were editing it. Review the differences below and submit again to keep your values."
+	c.HTML(http.StatusConflict, "edit_card.html", data)
+}
+
+// languageSelection returns the language code the edit form preselects for a
+// stored language. Values that are not a known language, left over from
+// before languages were normalized, are returned as unknown so the form can
+// show them until another language is picked.
+func languageSelection(value string) (string, string) {
+	if value == "" {
+		return "", ""
+	}
+	if language, ok := entity.FindLanguage(value); ok {
+		return language.Code, ""
+	}
+	return "", value
+}
+
+// locationID returns the card's location ID, or 0 if it has none.
+func locationID(card *entity.Card) uint {
+	if card.LocationID == nil {
+		return 0
+	}
+	return *card.LocationID
+}
+
+// cardLocationPaths maps card IDs to the full path of their location for
+// display. Cards without a location are left out.
+func cardLocationPaths(cards []entity.Card, locations []usecase.LocationNode) map[uint]string {
+	byID := make(map[uint]string, len(locations))
+	for _, location := range locations {
+		byID[location.ID] = location.Path
+	}
+
+	paths := make(map[uint]string, len(cards))
+	for _, card := range cards {
+		if card.LocationID != nil {
+			paths[card.ID] = byID[*card.LocationID]
+		}
+	}
+	return paths
+}
+
+// AddLot records a purchase of more copies for a card.
+func (h *CardHandler) AddLot(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	unitCost, _ := strconv.ParseFloat(c.PostForm("unit_cost"), 64)
+
+	var acquiredAt *time.Time
+	if acquiredStr := c.PostForm("acquired_at"); acquiredStr != "" {
+		if t, err := time.Parse("2006-01-02", acquiredStr); err == nil {
+			acquiredAt = &t
+		}
+	}
+
+	err = h.lotUseCase.AddLot(c.Request.Context(), usecase.AddLotInput{
+		UserID:     userID,
+		CardID:     uint(cardID),
+		Quantity:   quantity,
+		UnitCost:   unitCost,
+		AcquiredAt: acquiredAt,
+		Source:     strings.TrimSpace(c.PostForm("source")),
+	})
+	if err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// SetCardTags saves the tags and custom field values of a card.
+func (h *CardHandler) SetCardTags(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	input := usecase.SetCardTagsInput{UserID: userID, CardID: uint(cardID), Fields: make(map[uint]string)}
+	for _, value := range c.PostFormArray("tag_ids") {
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			input.TagIDs = append(input.TagIDs, uint(id))
+		}
+	}
+	for key, values := range c.Request.PostForm {
+		name, ok := strings.CutPrefix(key, "field_")
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+			input.Fields[uint(id)] = values[0]
+		}
+	}
+
+	if err := h.tagUseCase.SetCardTags(c.Request.Context(), input); err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// LendCard records copies of a card lent to someone.
+func (h *CardHandler) LendCard(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	lentAt, _ := time.Parse("2006-01-02", c.PostForm("lent_at"))
+
+	var dueAt *time.Time
+	if dueStr := c.PostForm("due_at"); dueStr != "" {
+		if t, err := time.Parse("2006-01-02", dueStr); err == nil {
+			dueAt = &t
+		}
+	}
+
+	_, err = h.loanUseCase.Lend(c.Request.Context(), usecase.LendInput{
+		UserID:   userID,
+		CardID:   uint(cardID),
+		Borrower: c.PostForm("borrower"),
+		Quantity: quantity,
+		LentAt:   lentAt,
+		DueAt:    dueAt,
+		Notes:    c.PostForm("notes"),
+	})
+	if err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// UploadImage stores an uploaded picture as the image of a card.
+func (h *CardHandler) UploadImage(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadBytes)
+	header, err := c.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		h.renderEditCardPage(c, uint(cardID), userID, fmt.Sprintf("Uploads can be at most %d MB", maxImageUploadBytes>>20))
+		return
+	}
+	if err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, "Choose an image file to upload")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		log.Printf("Error opening uploaded image: %v", err)
+		h.renderEditCardPage(c, uint(cardID), userID, "Failed to read the uploaded file")
+		return
+	}
+	defer file.Close()
+
+	if err := h.imageUseCase.UploadCardImage(c.Request.Context(), userID, uint(cardID), file); err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// UploadPhotos adds one or more photos to a card. Photos stored before a
+// failing file are kept.
+func (h *CardHandler) UploadPhotos(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPhotoUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, fmt.Sprintf("Uploads can be at most %d MB at once", maxPhotoUploadBytes>>20))
+		return
+	}
+	files := form.File["photos"]
+	if len(files) == 0 {
+		h.renderEditCardPage(c, uint(cardID), userID, "Choose at least one photo to upload")
+		return
+	}
+	if len(files) > maxPhotoUploadFiles {
+		h.renderEditCardPage(c, uint(cardID), userID, fmt.Sprintf("Upload at most %d photos at once", maxPhotoUploadFiles))
+		return
+	}
+
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			log.Printf("Error opening uploaded photo: %v", err)
+			h.renderEditCardPage(c, uint(cardID), userID, "Failed to read "+header.Filename)
+			return
+		}
+		_, err = h.photoUseCase.UploadPhoto(c.Request.Context(), usecase.UploadPhotoInput{
+			UserID:  userID,
+			CardID:  uint(cardID),
+			Side:    entity.PhotoSide(c.PostForm("side")),
+			Caption: c.PostForm("caption"),
+			File:    file,
+		})
+		file.Close()
+		if err != nil {
+			h.renderEditCardPage(c, uint(cardID), userID, header.Filename+": "+err.Error())
+			return
+		}
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// DeletePhoto removes a photo from its card.
+func (h *CardHandler) DeletePhoto(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	photoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	photo, err := h.photoUseCase.GetPhoto(c.Request.Context(), uint(photoID), userID)
+	if err != nil {
+		log.Printf("Error getting photo: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+	if err := h.photoUseCase.DeletePhoto(c.Request.Context(), photo.ID, userID); err != nil {
+		log.Printf("Error deleting photo: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/cards/edit/%d", photo.CardID))
+}
+
+// SellCard records a sale of some or all copies of a card.
+func (h *CardHandler) SellCard(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	unitPrice, _ := strconv.ParseFloat(c.PostForm("unit_price"), 64)
+	soldAt, _ := time.Parse("2006-01-02", c.PostForm("sold_at"))
+
+	_, err = h.lotUseCase.RecordSale(c.Request.Context(), usecase.RecordSaleInput{
+		UserID:    userID,
+		CardID:    uint(cardID),
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		SoldAt:    soldAt,
+	})
+	if err != nil {
+		h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/edit/"+c.Param("id"))
+}
+
+// BulkEdit applies the bulk action chosen on the collection list to the
+// selected cards and shows what was changed.
+func (h *CardHandler) BulkEdit(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	var cardIDs []uint
+	for _, value := range c.PostFormArray("card_ids") {
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			cardIDs = append(cardIDs, uint(id))
+		}
+	}
+
+	var result *usecase.BulkEditResult
+	var err error
+	if c.PostForm("action") == bulkMoveAction {
+		// Moves share the move path of the edit page
+		var moved int
+		moved, err = h.locationUseCase.MoveCards(c.Request.Context(), usecase.MoveCardsInput{
+			UserID:     userID,
+			CardIDs:    cardIDs,
+			LocationID: optionalID(c.PostForm("location_id")),
+			Source:     entity.AuditSourceWeb,
+		})
+		if err == nil {
+			result = &usecase.BulkEditResult{Applied: moved}
+		}
+	} else {
+		input := usecase.BulkEditInput{
+			UserID:   userID,
+			CardIDs:  cardIDs,
+			Action:   usecase.BulkAction(c.PostForm("action")),
+			Language: c.PostForm("language"),
+			Source:   entity.AuditSourceWeb,
+		}
+		input.QuantityDelta, _ = strconv.Atoi(c.PostForm("quantity_delta"))
+		if t, err := time.Parse("2006-01-02", c.PostForm("sell_date")); err == nil {
+			input.SellDate = &t
+		}
+		result, err = h.cardUseCase.BulkEdit(c.Request.Context(), input)
+	}
+
+	status := http.StatusOK
+	errorMessage := ""
+	if err != nil {
+		status = http.StatusBadRequest
+		errorMessage = err.Error()
+	}
+
+	c.HTML(status, "bulk_result.html", gin.H{
+		"title":    "Bulk Edit",
+		"username": username,
+		"result":   result,
+		"error":    errorMessage,
+	})
+}
+
 func (h *CardHandler) DeleteCard(c *gin.Context) {
 	session := sessions.Default(c)
 	userID := session.Get("user_id").(uint)
@@ -214,9 +831,169 @@ func (h *CardHandler) DeleteCard(c *gin.Context) {
 		return
 	}
 
-	if err := h.cardUseCase.DeleteCard(uint(cardID), userID); err != nil {
+	if err := h.cardUseCase.DeleteCard(c.Request.Context(), uint(cardID), userID, entity.AuditSourceWeb); err != nil {
+		// The edit page lists the loans to mark returned
+		if errors.Is(err, repository.ErrCopiesLent) {
+			h.renderEditCardPage(c, uint(cardID), userID, err.Error())
+			return
+		}
 		log.Printf("Error deleting card: %v", err)
 	}
 
 	c.Redirect(http.StatusFound, "/cards")
 }
+
+// cardListParams are the query parameters that make up a card list view.
+// Anything else, including the page number, is not part of the view.
+var cardListParams = []string{
+	"search", "location", "sort", "dir", "lang", "status", "color", "rarity",
+	"min_price", "max_price", "bought_from", "bought_to", "sold_from", "sold_to",
+}
+
+// cardListView keeps the non-empty card list parameters.
+func cardListView(query url.Values) url.Values {
+	view := url.Values{}
+	for _, key := range cardListParams {
+		if value := strings.TrimSpace(query.Get(key)); value != "" {
+			view.Set(key, value)
+		}
+	}
+	return view
+}
+
+// applyCardListView sets the sort and structured filters from the view.
+// Invalid values are dropped from the view, so they are neither applied nor
+// saved.
+func applyCardListView(view url.Values, filter *repository.CardFilter) {
+	if sort := repository.CardSortKey(view.Get("sort")); sort != "" {
+		if validCardSortKey(sort) {
+			filter.Sort = sort
+		} else {
+			view.Del("sort")
+		}
+	}
+	switch view.Get("dir") {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		view.Del("dir")
+	}
+
+	filter.Language = view.Get("lang")
+
+	switch color := view.Get("color"); color {
+	case "W", "U", "B", "R", "G", repository.ColorMulticolor, repository.ColorColorless:
+		filter.Color = color
+	default:
+		view.Del("color")
+	}
+	if rarity := entity.Rarity(view.Get("rarity")); validRarity(rarity) {
+		filter.Rarity = rarity
+	} else {
+		view.Del("rarity")
+	}
+
+	switch view.Get("status") {
+	case "sold":
+		sold := true
+		filter.Sold = &sold
+	case "held":
+		sold := false
+		filter.Sold = &sold
+	default:
+		view.Del("status")
+	}
+
+	filter.MinPrice = viewPrice(view, "min_price")
+	filter.MaxPrice = viewPrice(view, "max_price")
+	filter.BoughtFrom = viewDate(view, "bought_from")
+	filter.BoughtTo = viewDate(view, "bought_to")
+	filter.SoldFrom = viewDate(view, "sold_from")
+	filter.SoldTo = viewDate(view, "sold_to")
+}
+
+func validRarity(rarity entity.Rarity) bool {
+	for _, r := range entity.Rarities {
+		if rarity == r {
+			return true
+		}
+	}
+	return false
+}
+
+func validCardSortKey(key repository.CardSortKey) bool {
+	for _, k := range repository.CardSortKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+func viewPrice(view url.Values, key string) *float64 {
+	value := view.Get(key)
+	if value == "" {
+		return nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		view.Del(key)
+		return nil
+	}
+	return &price
+}
+
+func viewDate(view url.Values, key string) *time.Time {
+	value := view.Get(key)
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		view.Del(key)
+		return nil
+	}
+	return &date
+}
+
+// cardSortLink is a column header link of the card list. Following it sorts
+// by the column, or reverses the direction if the list is already sorted by
+// it.
+type cardSortLink struct {
+	Query     template.URL
+	Active    bool
+	Ascending bool
+}
+
+func cardSortLinks(view url.Values) map[string]cardSortLink {
+	current := view.Get("sort")
+	if current == "" {
+		current = string(repository.SortByCreated)
+	}
+	ascending := view.Get("dir") == "asc"
+
+	links := make(map[string]cardSortLink, len(repository.CardSortKeys))
+	for _, key := range repository.CardSortKeys {
+		link := cardSortLink{Active: string(key) == current, Ascending: ascending}
+
+		query := url.Values{}
+		for k, v := range view {
+			query[k] = v
+		}
+		query.Set("sort", string(key))
+		if link.Active && ascending {
+			query.Set("dir", "desc")
+		} else if link.Active {
+			query.Set("dir", "asc")
+		} else if key == repository.SortByName || key == repository.SortBySet {
+			query.Set("dir", "asc")
+		} else {
+			query.Set("dir", "desc")
+		}
+		link.Query = template.URL(query.Encode())
+
+		links[string(key)] = link
+	}
+	return links
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type DeckHandler struct {
+	deckUseCase *usecase.DeckUseCase
+}
+
+func NewDeckHandler(deckUseCase *usecase.DeckUseCase) *DeckHandler {
+	return &DeckHandler{deckUseCase: deckUseCase}
+}
+
+func (h *DeckHandler) ListDecks(c *gin.Context) {
+	h.renderDeckList(c, "")
+}
+
+func (h *DeckHandler) renderDeckList(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	decks, err := h.deckUseCase.ListDecks(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing decks: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "decks.html", gin.H{
+		"title":    "My Decks",
+		"username": username,
+		"decks":    decks,
+		"formats":  entity.DeckFormats,
+		"error":    errorMessage,
+	})
+}
+
+func (h *DeckHandler) CreateDeck(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	deck, err := h.deckUseCase.CreateDeck(c.Request.Context(), usecase.DeckInput{
+		UserID:      userID,
+		Name:        c.PostForm("name"),
+		Format:      c.PostForm("format"),
+		Description: c.PostForm("description"),
+	})
+	if err != nil {
+		h.renderDeckList(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/decks/%d", deck.ID))
+}
+
+func (h *DeckHandler) ShowDeck(c *gin.Context) {
+	h.renderDeck(c, "")
+}
+
+func (h *DeckHandler) renderDeck(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	deck, err := h.deckUseCase.GetDeck(c.Request.Context(), uint(deckID), userID)
+	if err != nil {
+		log.Printf("Error getting deck: %v", err)
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	// Group entries by zone for display
+	var zones []gin.H
+	for _, zone := range entity.DeckZones {
+		var entries []entity.DeckEntry
+		count := 0
+		for _, entry := range deck.Entries {
+			if entry.Zone == zone {
+				entries = append(entries, entry)
+				count += entry.Quantity
+			}
+		}
+		if len(entries) > 0 {
+			zones = append(zones, gin.H{"zone": zone, "entries": entries, "count": count})
+		}
+	}
+
+	c.HTML(http.StatusOK, "deck.html", gin.H{
+		"title":     deck.Name,
+		"username":  username,
+		"deck":      deck,
+		"zones":     zones,
+		"zoneNames": entity.DeckZones,
+		"formats":   entity.DeckFormats,
+		"error":     errorMessage,
+	})
+}
+
+func (h *DeckHandler) EditDeck(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	err = h.deckUseCase.UpdateDeck(c.Request.Context(), usecase.DeckInput{
+		ID:          uint(deckID),
+		UserID:      userID,
+		Name:        c.PostForm("name"),
+		Format:      c.PostForm("format"),
+		Description: c.PostForm("description"),
+	})
+	if err != nil {
+		h.renderDeck(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/decks/%d", deckID))
+}
+
+func (h *DeckHandler) DeleteDeck(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	if err := h.deckUseCase.DeleteDeck(c.Request.Context(), uint(deckID), userID); err != nil {
+		log.Printf("Error deleting deck: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, "/decks")
+}
+
+func (h *DeckHandler) AddEntry(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+
+	err = h.deckUseCase.AddEntry(c.Request.Context(), usecase.AddDeckEntryInput{
+		DeckID:          uint(deckID),
+		UserID:          userID,
+		CardName:        c.PostForm("card_name"),
+		SetCode:         c.PostForm("set_code"),
+		CollectorNumber: c.PostForm("collector_number"),
+		Quantity:        quantity,
+		Zone:            entity.DeckZone(c.PostForm("zone")),
+	})
+	if err != nil {
+		h.renderDeck(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/decks/%d", deckID))
+}
+
+func (h *DeckHandler) RemoveEntry(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("entryID"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/decks/%d", deckID))
+		return
+	}
+
+	if err := h.deckUseCase.RemoveEntry(c.Request.Context(), uint(entryID), uint(deckID), userID); err != nil {
+		log.Printf("Error removing deck entry: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/decks/%d", deckID))
+}
+
+func (h *DeckHandler) ShowMissing(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	deckID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	report, err := h.deckUseCase.OwnershipReport(c.Request.Context(), uint(deckID), userID)
+	if err != nil {
+		log.Printf("Error building ownership report: %v", err)
+		c.Redirect(http.StatusFound, "/decks")
+		return
+	}
+
+	c.HTML(http.StatusOK, "deck_missing.html", gin.H{
+		"title":    "What am I missing? - " + report.Deck.Name,
+		"username": username,
+		"report":   report,
+	})
+}
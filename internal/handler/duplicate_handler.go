@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type DuplicateHandler struct {
+	duplicateUseCase *usecase.DuplicateUseCase
+	locationUseCase  *usecase.LocationUseCase
+}
+
+func NewDuplicateHandler(duplicateUseCase *usecase.DuplicateUseCase, locationUseCase *usecase.LocationUseCase) *DuplicateHandler {
+	return &DuplicateHandler{duplicateUseCase: duplicateUseCase, locationUseCase: locationUseCase}
+}
+
+func (h *DuplicateHandler) ListDuplicates(c *gin.Context) {
+	h.renderDuplicates(c, "", c.Query("merged"))
+}
+
+func (h *DuplicateHandler) renderDuplicates(c *gin.Context, errorMessage, merged string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	groups, err := h.duplicateUseCase.FindDuplicates(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error finding duplicates: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	locations, err := h.locationUseCase.ListLocations(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing locations: %v", err)
+	}
+	var cards []entity.Card
+	for _, group := range groups {
+		cards = append(cards, group.Merged)
+	}
+
+	c.HTML(http.StatusOK, "duplicates.html", gin.H{
+		"title":    "Duplicate Cards",
+		"username": username,
+		"groups":   groups,
+		"paths":    cardLocationPaths(cards, locations),
+		"merged":   merged,
+		"error":    errorMessage,
+	})
+}
+
+func (h *DuplicateHandler) MergeCards(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	var cardIDs []uint
+	for _, value := range c.PostFormArray("card_ids") {
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			cardIDs = append(cardIDs, uint(id))
+		}
+	}
+
+	if _, err := h.duplicateUseCase.MergeCards(c.Request.Context(), userID, cardIDs); err != nil {
+		h.renderDuplicates(c, "Failed to merge cards: "+err.Error(), "")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/cards/duplicates?merged=1")
+}
+
+func (h *DuplicateHandler) MergeAll(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	merged, err := h.duplicateUseCase.MergeAll(c.Request.Context(), userID)
+	if err != nil {
+		h.renderDuplicates(c, fmt.Sprintf("Merged %d groups, then failed: %v", merged, err), "")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/cards/duplicates?merged=%d", merged))
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type ImageHandler struct {
+	imageUseCase *usecase.ImageUseCase
+	photoUseCase *usecase.PhotoUseCase
+}
+
+func NewImageHandler(imageUseCase *usecase.ImageUseCase, photoUseCase *usecase.PhotoUseCase) *ImageHandler {
+	return &ImageHandler{imageUseCase: imageUseCase, photoUseCase: photoUseCase}
+}
+
+// CardImage serves a cached card image. Image files never change under a
+// hash, so the response may be cached for a year; pages link to it with the
+// hash in the query string, which changes the URL when the card's image is
+// replaced.
+func (h *ImageHandler) CardImage(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	blob, err := h.imageUseCase.OpenCardImage(c.Request.Context(), userID, uint(cardID), entity.ImageSize(c.Param("size")))
+	if err != nil {
+		if !errors.Is(err, usecase.ErrNoCardImage) && !errors.Is(err, repository.ErrBlobNotFound) {
+			log.Printf("Error opening card image: %v", err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	serveImageBlob(c, blob)
+}
+
+// TranslatedImage serves the catalog image of a card's printing in the
+// card's language, cached like CardImage.
+func (h *ImageHandler) TranslatedImage(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	blob, err := h.imageUseCase.OpenTranslatedImage(c.Request.Context(), userID, uint(cardID), entity.ImageSize(c.Param("size")))
+	if err != nil {
+		if !errors.Is(err, usecase.ErrNoCardImage) && !errors.Is(err, repository.ErrBlobNotFound) {
+			log.Printf("Error opening translated card image: %v", err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	serveImageBlob(c, blob)
+}
+
+// CardPhoto serves a photo of a card, either the original or the normal size
+// thumbnail. Photos are never changed once uploaded.
+func (h *ImageHandler) CardPhoto(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	photoID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	blob, err := h.photoUseCase.OpenPhoto(c.Request.Context(), uint(photoID), userID, entity.ImageSize(c.Param("size")))
+	if err != nil {
+		if !errors.Is(err, repository.ErrBlobNotFound) {
+			log.Printf("Error opening photo: %v", err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	serveImageBlob(c, blob)
+}
+
+// serveImageBlob writes an image with caching headers for content that never
+// changes under its ETag, answering revalidations with 304 Not Modified.
+func serveImageBlob(c *gin.Context, blob *usecase.ImageBlob) {
+	defer blob.Body.Close()
+
+	etag := `"` + blob.ETag + `"`
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, -1, blob.ContentType, blob.Body, nil)
+}
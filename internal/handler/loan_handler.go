@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type LoanHandler struct {
+	loanUseCase *usecase.LoanUseCase
+}
+
+func NewLoanHandler(loanUseCase *usecase.LoanUseCase) *LoanHandler {
+	return &LoanHandler{loanUseCase: loanUseCase}
+}
+
+func (h *LoanHandler) ListLoans(c *gin.Context) {
+	h.renderLoans(c, "")
+}
+
+func (h *LoanHandler) renderLoans(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	outstanding, returned, err := h.loanUseCase.Loans(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing loans: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	now := time.Now()
+	overdue := make(map[uint]bool)
+	for _, loan := range outstanding {
+		if loan.IsOverdue(now) {
+			overdue[loan.ID] = true
+		}
+	}
+
+	status := http.StatusOK
+	if errorMessage != "" {
+		status = http.StatusBadRequest
+	}
+
+	c.HTML(status, "loans.html", gin.H{
+		"title":       "Loans",
+		"username":    username,
+		"outstanding": outstanding,
+		"returned":    returned,
+		"overdue":     overdue,
+		"today":       now.Format("2006-01-02"),
+		"error":       errorMessage,
+	})
+}
+
+// Borrow records copies of a card borrowed from a friend.
+func (h *LoanHandler) Borrow(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	borrowedAt, _ := time.Parse("2006-01-02", c.PostForm("borrowed_at"))
+
+	var dueAt *time.Time
+	if dueStr := c.PostForm("due_at"); dueStr != "" {
+		if t, err := time.Parse("2006-01-02", dueStr); err == nil {
+			dueAt = &t
+		}
+	}
+
+	_, err := h.loanUseCase.Borrow(c.Request.Context(), usecase.BorrowInput{
+		UserID:          userID,
+		CardName:        c.PostForm("card_name"),
+		SetCode:         c.PostForm("set_code"),
+		CollectorNumber: c.PostForm("collector_number"),
+		Lender:          c.PostForm("lender"),
+		Quantity:        quantity,
+		BorrowedAt:      borrowedAt,
+		DueAt:           dueAt,
+		Notes:           c.PostForm("notes"),
+	})
+	if err != nil {
+		h.renderLoans(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/loans")
+}
+
+// MarkReturned closes a loan on the date given in the form.
+func (h *LoanHandler) MarkReturned(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	loanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/loans")
+		return
+	}
+
+	returnedAt, _ := time.Parse("2006-01-02", c.PostForm("returned_at"))
+	if err := h.loanUseCase.MarkReturned(c.Request.Context(), uint(loanID), userID, returnedAt); err != nil {
+		h.renderLoans(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/loans")
+}
+
+func (h *LoanHandler) DeleteLoan(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	loanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/loans")
+		return
+	}
+
+	if err := h.loanUseCase.DeleteLoan(c.Request.Context(), uint(loanID), userID); err != nil {
+		h.renderLoans(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/loans")
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type LocationHandler struct {
+	locationUseCase *usecase.LocationUseCase
+}
+
+func NewLocationHandler(locationUseCase *usecase.LocationUseCase) *LocationHandler {
+	return &LocationHandler{locationUseCase: locationUseCase}
+}
+
+func (h *LocationHandler) ListLocations(c *gin.Context) {
+	h.renderLocations(c, "")
+}
+
+func (h *LocationHandler) renderLocations(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	locations, err := h.locationUseCase.ListLocations(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing locations: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "locations.html", gin.H{
+		"title":     "Storage Locations",
+		"username":  username,
+		"locations": locations,
+		"kinds":     entity.LocationKinds,
+		"error":     errorMessage,
+	})
+}
+
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	_, err := h.locationUseCase.CreateLocation(c.Request.Context(), usecase.CreateLocationInput{
+		UserID:   userID,
+		ParentID: optionalID(c.PostForm("parent_id")),
+		Kind:     entity.LocationKind(c.PostForm("kind")),
+		Name:     c.PostForm("name"),
+	})
+	if err != nil {
+		h.renderLocations(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/locations")
+}
+
+func (h *LocationHandler) RenameLocation(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/locations")
+		return
+	}
+
+	if err := h.locationUseCase.RenameLocation(c.Request.Context(), uint(locationID), userID, c.PostForm("name")); err != nil {
+		h.renderLocations(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/locations")
+}
+
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/locations")
+		return
+	}
+
+	if err := h.locationUseCase.DeleteLocation(c.Request.Context(), uint(locationID), userID); err != nil {
+		h.renderLocations(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/locations")
+}
+
+// MoveCards handles the move form on the edit page, which can also split off
+// some of the copies.
+func (h *LocationHandler) MoveCards(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	var cardIDs []uint
+	for _, value := range c.PostFormArray("card_ids") {
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			cardIDs = append(cardIDs, uint(id))
+		}
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	locationID := optionalID(c.PostForm("location_id"))
+
+	_, err := h.locationUseCase.MoveCards(c.Request.Context(), usecase.MoveCardsInput{
+		UserID:     userID,
+		CardIDs:    cardIDs,
+		LocationID: locationID,
+		Quantity:   quantity,
+		Source:     entity.AuditSourceWeb,
+	})
+	if err != nil {
+		h.renderLocations(c, "Failed to move cards: "+err.Error())
+		return
+	}
+
+	if locationID != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/cards?location=%d", *locationID))
+		return
+	}
+	c.Redirect(http.StatusFound, "/cards?location=none")
+}
+
+// optionalID parses an optional ID form value; empty or invalid values give
+// nil.
+func optionalID(value string) *uint {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return nil
+	}
+	result := uint(id)
+	return &result
+}
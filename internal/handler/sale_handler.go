@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type SaleHandler struct {
+	lotUseCase     *usecase.LotUseCase
+	taxUseCase     *usecase.TaxUseCase
+	accountUseCase *usecase.AccountUseCase
+}
+
+func NewSaleHandler(lotUseCase *usecase.LotUseCase, taxUseCase *usecase.TaxUseCase, accountUseCase *usecase.AccountUseCase) *SaleHandler {
+	return &SaleHandler{lotUseCase: lotUseCase, taxUseCase: taxUseCase, accountUseCase: accountUseCase}
+}
+
+func (h *SaleHandler) ListSales(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	gains, err := h.lotUseCase.RealizedGains(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing sales: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	user, err := h.accountUseCase.GetAccount(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error loading account: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+	method := user.CostMethod
+	if method == "" {
+		method = entity.CostMethodFIFO
+	}
+	currency := user.Currency
+	if currency == "" {
+		currency = entity.DefaultCurrency
+	}
+
+	years, err := h.taxUseCase.TaxYears(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing tax years: %v", err)
+	}
+
+	c.HTML(http.StatusOK, "sales.html", gin.H{
+		"title":      "Sales",
+		"username":   username,
+		"gains":      gains,
+		"costMethod": method,
+		"currency":   currency,
+		"taxYears":   years,
+	})
+}
+
+// taxReport builds the report for the :year parameter. It writes the
+// response itself when the report cannot be built.
+func (h *SaleHandler) taxReport(c *gin.Context) *usecase.TaxReport {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil || year < 1900 || year > 9999 {
+		c.Redirect(http.StatusFound, "/sales")
+		return nil
+	}
+
+	report, err := h.taxUseCase.Report(c.Request.Context(), userID, year)
+	if err != nil {
+		log.Printf("Error building tax report: %v", err)
+		c.Redirect(http.StatusFound, "/sales")
+		return nil
+	}
+	return report
+}
+
+func (h *SaleHandler) ShowTaxReport(c *gin.Context) {
+	report := h.taxReport(c)
+	if report == nil {
+		return
+	}
+
+	session := sessions.Default(c)
+	c.HTML(http.StatusOK, "tax_report.html", gin.H{
+		"title":    fmt.Sprintf("Tax Report %d", report.Year),
+		"username": session.Get("username").(string),
+		"report":   report,
+	})
+}
+
+func (h *SaleHandler) ExportTaxReport(c *gin.Context) {
+	report := h.taxReport(c)
+	if report == nil {
+		return
+	}
+
+	filename := fmt.Sprintf("mtg-realized-gains-%d.csv", report.Year)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if err := report.WriteCSV(c.Writer); err != nil {
+		log.Printf("Error writing tax report: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type SealedHandler struct {
+	sealedUseCase *usecase.SealedUseCase
+}
+
+func NewSealedHandler(sealedUseCase *usecase.SealedUseCase) *SealedHandler {
+	return &SealedHandler{sealedUseCase: sealedUseCase}
+}
+
+func (h *SealedHandler) ListProducts(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	inventory, err := h.sealedUseCase.ListProducts(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing sealed products: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "sealed.html", gin.H{
+		"title":     "Sealed Products",
+		"username":  username,
+		"inventory": inventory,
+	})
+}
+
+func (h *SealedHandler) ShowAddProductPage(c *gin.Context) {
+	h.renderAddProductPage(c, "")
+}
+
+func (h *SealedHandler) renderAddProductPage(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+
+	status := http.StatusOK
+	if errorMessage != "" {
+		status = http.StatusBadRequest
+	}
+
+	c.HTML(status, "add_sealed.html", gin.H{
+		"title":    "Add Sealed Product",
+		"username": session.Get("username").(string),
+		"types":    entity.SealedProductTypes,
+		"error":    errorMessage,
+	})
+}
+
+// optionalDate parses a date form value, returning nil when it is empty or
+// not a date.
+func optionalDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func sealedInputFromForm(c *gin.Context) usecase.SealedProductInput {
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	unitCost, _ := strconv.ParseFloat(c.PostForm("unit_cost"), 64)
+
+	return usecase.SealedProductInput{
+		Name:       c.PostForm("name"),
+		SetCode:    c.PostForm("set_code"),
+		Type:       entity.SealedProductType(c.PostForm("type")),
+		Quantity:   quantity,
+		UnitCost:   unitCost,
+		BoughtDate: optionalDate(c.PostForm("bought_date")),
+		SellDate:   optionalDate(c.PostForm("sell_date")),
+		Notes:      c.PostForm("notes"),
+	}
+}
+
+func (h *SealedHandler) AddProduct(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	input := sealedInputFromForm(c)
+	input.UserID = userID
+
+	if _, err := h.sealedUseCase.CreateProduct(c.Request.Context(), input); err != nil {
+		h.renderAddProductPage(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/sealed")
+}
+
+func (h *SealedHandler) ShowEditProductPage(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/sealed")
+		return
+	}
+
+	h.renderEditProductPage(c, uint(productID), userID, "")
+}
+
+func (h *SealedHandler) renderEditProductPage(c *gin.Context, productID uint, userID uint, errorMessage string) {
+	session := sessions.Default(c)
+
+	product, err := h.sealedUseCase.GetProduct(c.Request.Context(), productID, userID)
+	if err != nil {
+		log.Printf("Error getting sealed product: %v", err)
+		c.Redirect(http.StatusFound, "/sealed")
+		return
+	}
+
+	var boughtDateStr, sellDateStr string
+	if product.BoughtDate != nil {
+		boughtDateStr = product.BoughtDate.Format("2006-01-02")
+	}
+	if product.SellDate != nil {
+		sellDateStr = product.SellDate.Format("2006-01-02")
+	}
+
+	status := http.StatusOK
+	if errorMessage != "" {
+		status = http.StatusBadRequest
+	}
+
+	c.HTML(status, "edit_sealed.html", gin.H{
+		"title":         "Edit Sealed Product",
+		"username":      session.Get("username").(string),
+		"product":       product,
+		"types":         entity.SealedProductTypes,
+		"languages":     entity.Languages,
+		"boughtDateStr": boughtDateStr,
+		"sellDateStr":   sellDateStr,
+		"opened":        c.Query("opened"),
+		"cardsText":     c.PostForm("cards"),
+		"error":         errorMessage,
+	})
+}
+
+func (h *SealedHandler) EditProduct(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/sealed")
+		return
+	}
+
+	input := sealedInputFromForm(c)
+	input.ID = uint(productID)
+	input.UserID = userID
+
+	if err := h.sealedUseCase.UpdateProduct(c.Request.Context(), input); err != nil {
+		h.renderEditProductPage(c, uint(productID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/sealed")
+}
+
+func (h *SealedHandler) DeleteProduct(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/sealed")
+		return
+	}
+
+	if err := h.sealedUseCase.DeleteProduct(c.Request.Context(), uint(productID), userID); err != nil {
+		log.Printf("Error deleting sealed product: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, "/sealed")
+}
+
+// OpenProduct opens one unit of a product into the cards listed in the form.
+func (h *SealedHandler) OpenProduct(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/sealed")
+		return
+	}
+
+	cards, err := h.sealedUseCase.OpenProduct(c.Request.Context(), usecase.OpenProductInput{
+		UserID:    userID,
+		ProductID: uint(productID),
+		Cards:     c.PostForm("cards"),
+		Language:  c.PostForm("language"),
+	})
+	if err != nil {
+		h.renderEditProductPage(c, uint(productID), userID, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/sealed/edit/"+c.Param("id")+"?opened="+strconv.Itoa(len(cards)))
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type SetHandler struct {
+	setUseCase *usecase.SetUseCase
+}
+
+func NewSetHandler(setUseCase *usecase.SetUseCase) *SetHandler {
+	return &SetHandler{setUseCase: setUseCase}
+}
+
+func (h *SetHandler) ListSets(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	master := c.Query("master") == "1"
+
+	sets, err := h.setUseCase.Overview(c.Request.Context(), userID, master)
+	if err != nil {
+		log.Printf("Error getting set overview: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "sets.html", gin.H{
+		"title":    "Sets",
+		"username": username,
+		"sets":     sets,
+		"master":   master,
+	})
+}
+
+func (h *SetHandler) ShowSet(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	master := c.Query("master") == "1"
+	missingOnly := c.Query("missing") == "1"
+
+	detail, err := h.setUseCase.Detail(c.Request.Context(), userID, c.Param("code"), master)
+	if err != nil {
+		log.Printf("Error getting set %s: %v", c.Param("code"), err)
+		c.Redirect(http.StatusFound, "/sets")
+		return
+	}
+
+	cards := detail.Cards
+	if missingOnly {
+		cards = make([]usecase.SetCardStatus, 0, len(detail.Cards))
+		for _, status := range detail.Cards {
+			if status.Missing {
+				cards = append(cards, status)
+			}
+		}
+	}
+
+	c.HTML(http.StatusOK, "set.html", gin.H{
+		"title":    detail.Name(),
+		"username": username,
+		"detail":   detail,
+		"cards":    cards,
+		"master":   master,
+		"missing":  missingOnly,
+	})
+}
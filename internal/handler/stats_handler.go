@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	statsUseCase *usecase.StatsUseCase
+	loanUseCase  *usecase.LoanUseCase
+}
+
+func NewStatsHandler(statsUseCase *usecase.StatsUseCase, loanUseCase *usecase.LoanUseCase) *StatsHandler {
+	return &StatsHandler{statsUseCase: statsUseCase, loanUseCase: loanUseCase}
+}
+
+// statsDistribution is one distribution panel on the dashboard.
+type statsDistribution struct {
+	Title   string
+	Buckets []usecase.StatBucket
+}
+
+func (h *StatsHandler) ShowDashboard(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	stats, err := h.statsUseCase.Stats(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error computing collection stats: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	overdue, err := h.loanUseCase.Overdue(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		log.Printf("Error listing overdue loans: %v", err)
+	}
+
+	c.HTML(http.StatusOK, "stats.html", gin.H{
+		"title":        "Statistics",
+		"username":     username,
+		"stats":        stats,
+		"overdueLoans": overdue,
+		"distributions": []statsDistribution{
+			{"By Set", stats.BySet},
+			{"By Language", stats.ByLanguage},
+			{"By Color", stats.ByColor},
+			{"By Rarity", stats.ByRarity},
+			{"By Type", stats.ByType},
+			{"By Mana Value", stats.ByManaValue},
+		},
+	})
+}
+
+// StatsJSON serves the same aggregates as the dashboard for scripts and
+// external tools.
+func (h *StatsHandler) StatsJSON(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	stats, err := h.statsUseCase.Stats(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error computing collection stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
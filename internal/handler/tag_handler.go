@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagUseCase *usecase.TagUseCase
+}
+
+func NewTagHandler(tagUseCase *usecase.TagUseCase) *TagHandler {
+	return &TagHandler{tagUseCase: tagUseCase}
+}
+
+func (h *TagHandler) ListTags(c *gin.Context) {
+	h.renderTags(c, "")
+}
+
+func (h *TagHandler) renderTags(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	tags, err := h.tagUseCase.ListTags(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing tags: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+	fields, err := h.tagUseCase.ListFields(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing custom fields: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "tags.html", gin.H{
+		"title":    "Tags & Fields",
+		"username": username,
+		"tags":     tags,
+		"fields":   fields,
+		"kinds":    entity.CustomFieldKinds,
+		"default":  entity.DefaultTagColor,
+		"error":    errorMessage,
+	})
+}
+
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	_, err := h.tagUseCase.CreateTag(c.Request.Context(), usecase.TagInput{
+		UserID: userID,
+		Name:   c.PostForm("name"),
+		Color:  c.PostForm("color"),
+	})
+	if err != nil {
+		h.renderTags(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+func (h *TagHandler) EditTag(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/tags")
+		return
+	}
+
+	err = h.tagUseCase.UpdateTag(c.Request.Context(), uint(tagID), usecase.TagInput{
+		UserID: userID,
+		Name:   c.PostForm("name"),
+		Color:  c.PostForm("color"),
+	})
+	if err != nil {
+		h.renderTags(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/tags")
+		return
+	}
+
+	if err := h.tagUseCase.DeleteTag(c.Request.Context(), uint(tagID), userID); err != nil {
+		h.renderTags(c, "Failed to delete tag")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+func (h *TagHandler) CreateField(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	_, err := h.tagUseCase.CreateField(c.Request.Context(), usecase.CustomFieldInput{
+		UserID:  userID,
+		Name:    c.PostForm("name"),
+		Kind:    entity.CustomFieldKind(c.PostForm("kind")),
+		Options: c.PostForm("options"),
+	})
+	if err != nil {
+		h.renderTags(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+func (h *TagHandler) EditField(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	fieldID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/tags")
+		return
+	}
+
+	err = h.tagUseCase.UpdateField(c.Request.Context(), uint(fieldID), usecase.CustomFieldInput{
+		UserID:  userID,
+		Name:    c.PostForm("name"),
+		Options: c.PostForm("options"),
+	})
+	if err != nil {
+		h.renderTags(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+func (h *TagHandler) DeleteField(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	fieldID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/tags")
+		return
+	}
+
+	if err := h.tagUseCase.DeleteField(c.Request.Context(), uint(fieldID), userID); err != nil {
+		h.renderTags(c, "Failed to delete field")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/tags")
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type TradeHandler struct {
+	tradeUseCase *usecase.TradeUseCase
+}
+
+func NewTradeHandler(tradeUseCase *usecase.TradeUseCase) *TradeHandler {
+	return &TradeHandler{tradeUseCase: tradeUseCase}
+}
+
+func (h *TradeHandler) ListTrades(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	partners, err := h.tradeUseCase.Partners(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing trade partners: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	proposals, err := h.tradeUseCase.ListProposals(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing trade proposals: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "trades.html", gin.H{
+		"title":     "Trades",
+		"username":  username,
+		"partners":  partners,
+		"proposals": proposals,
+	})
+}
+
+func (h *TradeHandler) ShowMatch(c *gin.Context) {
+	partnerID, err := strconv.ParseUint(c.Param("userID"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+
+	h.renderMatch(c, uint(partnerID), optionalID(c.Query("counter")), "")
+}
+
+func (h *TradeHandler) renderMatch(c *gin.Context, partnerID uint, counterOf *uint, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	var match *usecase.TradeMatch
+	var err error
+	if counterOf != nil {
+		match, err = h.tradeUseCase.CounterMatch(c.Request.Context(), *counterOf, userID)
+	} else {
+		match, err = h.tradeUseCase.Match(c.Request.Context(), userID, partnerID)
+	}
+	if err != nil {
+		log.Printf("Error matching trades: %v", err)
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+
+	c.HTML(http.StatusOK, "trade_match.html", gin.H{
+		"title":     "Trade with " + match.Partner.Username,
+		"username":  username,
+		"match":     match,
+		"counterOf": counterOf,
+		"error":     errorMessage,
+	})
+}
+
+func (h *TradeHandler) Propose(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	recipientID, err := strconv.ParseUint(c.PostForm("recipient_id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+	counterOf := optionalID(c.PostForm("counter_of"))
+
+	cardIDs := c.PostFormArray("card_id")
+	quantities := c.PostFormArray("quantity")
+	var lines []usecase.TradeLineInput
+	for i, value := range cardIDs {
+		cardID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || i >= len(quantities) {
+			continue
+		}
+		quantity, _ := strconv.Atoi(quantities[i])
+		lines = append(lines, usecase.TradeLineInput{CardID: uint(cardID), Quantity: quantity})
+	}
+
+	proposal, err := h.tradeUseCase.Propose(c.Request.Context(), usecase.ProposeTradeInput{
+		ProposerID:  userID,
+		RecipientID: uint(recipientID),
+		Lines:       lines,
+		Message:     c.PostForm("message"),
+		CounterOf:   counterOf,
+	})
+	if err != nil {
+		h.renderMatch(c, uint(recipientID), counterOf, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/trades/%d", proposal.ID))
+}
+
+func (h *TradeHandler) ShowProposal(c *gin.Context) {
+	h.renderProposal(c, "")
+}
+
+func (h *TradeHandler) renderProposal(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	proposalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+
+	view, err := h.tradeUseCase.GetProposal(c.Request.Context(), uint(proposalID), userID)
+	if err != nil {
+		log.Printf("Error getting trade proposal: %v", err)
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+
+	c.HTML(http.StatusOK, "trade.html", gin.H{
+		"title":    "Trade Proposal",
+		"username": username,
+		"view":     view,
+		"error":    errorMessage,
+	})
+}
+
+func (h *TradeHandler) Accept(c *gin.Context) {
+	h.resolve(c, h.tradeUseCase.Accept)
+}
+
+func (h *TradeHandler) Decline(c *gin.Context) {
+	h.resolve(c, h.tradeUseCase.Decline)
+}
+
+func (h *TradeHandler) Cancel(c *gin.Context) {
+	h.resolve(c, h.tradeUseCase.Cancel)
+}
+
+func (h *TradeHandler) resolve(c *gin.Context, action func(ctx context.Context, id uint, userID uint) error) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	proposalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/trades")
+		return
+	}
+
+	if err := action(c.Request.Context(), uint(proposalID), userID); err != nil {
+		h.renderProposal(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/trades/%d", proposalID))
+}
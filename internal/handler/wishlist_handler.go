@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type WishlistHandler struct {
+	wishlistUseCase *usecase.WishlistUseCase
+}
+
+func NewWishlistHandler(wishlistUseCase *usecase.WishlistUseCase) *WishlistHandler {
+	return &WishlistHandler{wishlistUseCase: wishlistUseCase}
+}
+
+func (h *WishlistHandler) ListWishlist(c *gin.Context) {
+	h.renderWishlist(c, "")
+}
+
+func (h *WishlistHandler) renderWishlist(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	open, fulfilled, err := h.wishlistUseCase.ListItems(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing wishlist: %v", err)
+		c.Redirect(http.StatusFound, "/cards")
+		return
+	}
+
+	c.HTML(http.StatusOK, "wishlist.html", gin.H{
+		"title":      "Wishlist",
+		"username":   username,
+		"open":       open,
+		"fulfilled":  fulfilled,
+		"priorities": entity.WishlistPriorities,
+		"error":      errorMessage,
+	})
+}
+
+func wishlistInputFromForm(c *gin.Context) usecase.WishlistItemInput {
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	maxPrice, _ := strconv.ParseFloat(c.PostForm("max_price"), 64)
+	priority, err := strconv.Atoi(c.PostForm("priority"))
+	if err != nil {
+		priority = int(entity.WishlistPriorityNormal)
+	}
+
+	return usecase.WishlistItemInput{
+		CardName:        c.PostForm("card_name"),
+		SetCode:         c.PostForm("set_code"),
+		CollectorNumber: c.PostForm("collector_number"),
+		Quantity:        quantity,
+		MaxPrice:        maxPrice,
+		Priority:        entity.WishlistPriority(priority),
+		Notes:           c.PostForm("notes"),
+	}
+}
+
+func (h *WishlistHandler) AddItem(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	input := wishlistInputFromForm(c)
+	input.UserID = userID
+
+	if err := h.wishlistUseCase.AddItem(c.Request.Context(), input); err != nil {
+		h.renderWishlist(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/wishlist")
+}
+
+func (h *WishlistHandler) ShowItem(c *gin.Context) {
+	h.renderItem(c, "")
+}
+
+func (h *WishlistHandler) renderItem(c *gin.Context, errorMessage string) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+	username := session.Get("username").(string)
+
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/wishlist")
+		return
+	}
+
+	status, err := h.wishlistUseCase.GetItem(c.Request.Context(), uint(itemID), userID)
+	if err != nil {
+		log.Printf("Error getting wishlist item: %v", err)
+		c.Redirect(http.StatusFound, "/wishlist")
+		return
+	}
+
+	c.HTML(http.StatusOK, "wishlist_item.html", gin.H{
+		"title":      status.Item.CardName,
+		"username":   username,
+		"status":     status,
+		"item":       status.Item,
+		"priorities": entity.WishlistPriorities,
+		"languages":  entity.Languages,
+		"today":      time.Now().Format("2006-01-02"),
+		"error":      errorMessage,
+	})
+}
+
+func (h *WishlistHandler) EditItem(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/wishlist")
+		return
+	}
+
+	input := wishlistInputFromForm(c)
+	input.ID = uint(itemID)
+	input.UserID = userID
+
+	if err := h.wishlistUseCase.UpdateItem(c.Request.Context(), input); err != nil {
+		h.renderItem(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/wishlist")
+}
+
+func (h *WishlistHandler) DeleteItem(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/wishlist")
+		return
+	}
+
+	if err := h.wishlistUseCase.DeleteItem(c.Request.Context(), uint(itemID), userID); err != nil {
+		log.Printf("Error deleting wishlist item: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, "/wishlist")
+}
+
+// AcquireItem turns a wishlist item into owned cards.
+func (h *WishlistHandler) AcquireItem(c *gin.Context) {
+	session := sessions.Default(c)
+	userID := session.Get("user_id").(uint)
+
+	itemID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/wishlist")
+		return
+	}
+
+	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
+	price, _ := strconv.ParseFloat(c.PostForm("price"), 64)
+
+	var boughtDate *time.Time
+	if boughtDateStr := c.PostForm("bought_date"); boughtDateStr != "" {
+		if t, err := time.Parse("2006-01-02", boughtDateStr); err == nil {
+			boughtDate = &t
+		}
+	}
+
+	err = h.wishlistUseCase.Acquire(c.Request.Context(), usecase.AcquireWishlistItemInput{
+		ItemID:          uint(itemID),
+		UserID:          userID,
+		Quantity:        quantity,
+		Price:           price,
+		BoughtDate:      boughtDate,
+		SetCode:         c.PostForm("set_code"),
+		CollectorNumber: c.PostForm("collector_number"),
+		Language:        c.PostForm("language"),
+		CardImageURL:    c.PostForm("card_image_url"),
+	})
+	if err != nil {
+		h.renderItem(c, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/wishlist")
+}
@@ -0,0 +1,387 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// FormatName identifies backup archives written by this package.
+	FormatName = "mtg-collection-backup"
+	// FormatVersion is bumped whenever the archive layout changes in a way
+	// older readers cannot handle.
+	FormatVersion = 1
+
+	manifestFile = "manifest.json"
+	batchSize    = 500
+)
+
+// Manifest is stored as the first entry of every archive and describes the
+// table files that follow it.
+type Manifest struct {
+	Format    string          `json:"format"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Tables    []TableManifest `json:"tables"`
+}
+
+// TableManifest describes one JSON lines file in the archive.
+type TableManifest struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Backup dumps every row of the given models, including soft-deleted ones,
+// into a gzipped tar archive written to w. Rows are read through gorm rather
+// than with driver-specific SQL, so the archive can be restored into any
+// database gorm supports. Models must be listed in dependency order.
+func Backup(ctx context.Context, db *gorm.DB, models []interface{}, w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:    FormatName,
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	// A single read transaction gives a consistent snapshot across tables.
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range models {
+			sch, err := parseSchema(tx, model)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.CreateTemp("", "mtg-backup-*.jsonl")
+			if err != nil {
+				return fmt.Errorf("failed to create temp file: %w", err)
+			}
+			files = append(files, f)
+
+			hasher := sha256.New()
+			buffered := bufio.NewWriter(io.MultiWriter(f, hasher))
+			rows, err := dumpTable(ctx, tx, sch, buffered)
+			if err != nil {
+				return fmt.Errorf("failed to dump table %s: %w", sch.Table, err)
+			}
+			if err := buffered.Flush(); err != nil {
+				return err
+			}
+
+			manifest.Tables = append(manifest.Tables, TableManifest{
+				Name:   sch.Table,
+				File:   sch.Table + ".jsonl",
+				Rows:   rows,
+				SHA256: hex.EncodeToString(hasher.Sum(nil)),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestFile, int64(len(manifestJSON)), manifest.CreatedAt, bytes.NewReader(manifestJSON)); err != nil {
+		return nil, err
+	}
+
+	for i, table := range manifest.Tables {
+		f := files[i]
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := writeEntry(tw, table.File, info.Size(), manifest.CreatedAt, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Verify reads a whole archive and checks every table file against the row
+// count and checksum recorded in its manifest, without touching a database.
+func Verify(r io.Reader) (*Manifest, error) {
+	return readArchive(r, func(table TableManifest, rows *json.Decoder) (int64, error) {
+		var count int64
+		for rows.More() {
+			var record map[string]json.RawMessage
+			if err := rows.Decode(&record); err != nil {
+				return count, err
+			}
+			count++
+		}
+		return count, nil
+	})
+}
+
+// Restore loads an archive into db. The target tables must exist and be
+// empty. Everything happens in one transaction, so a corrupt or truncated
+// archive leaves the database untouched.
+func Restore(ctx context.Context, db *gorm.DB, models []interface{}, r io.Reader) (*Manifest, error) {
+	var manifest *Manifest
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		schemas := make(map[string]*schema.Schema)
+		for _, model := range models {
+			sch, err := parseSchema(tx, model)
+			if err != nil {
+				return err
+			}
+
+			var count int64
+			if err := tx.Unscoped().Model(model).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to inspect table %s: %w", sch.Table, err)
+			}
+			if count > 0 {
+				return fmt.Errorf("table %s is not empty; restore requires an empty database", sch.Table)
+			}
+			schemas[sch.Table] = sch
+		}
+
+		var err error
+		manifest, err = readArchive(r, func(table TableManifest, rows *json.Decoder) (int64, error) {
+			sch, ok := schemas[table.Name]
+			if !ok {
+				return 0, fmt.Errorf("archive contains unknown table %s", table.Name)
+			}
+			return restoreTable(ctx, tx, sch, rows)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+	}
+	return stmt.Schema, nil
+}
+
+// columns returns the fields of a schema that map to database columns.
+func columns(sch *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range sch.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func dumpTable(ctx context.Context, tx *gorm.DB, sch *schema.Schema, w io.Writer) (int64, error) {
+	fields := columns(sch)
+	encoder := json.NewEncoder(w)
+	batch := reflect.New(reflect.SliceOf(sch.ModelType))
+
+	var count int64
+	result := tx.Unscoped().FindInBatches(batch.Interface(), batchSize, func(_ *gorm.DB, _ int) error {
+		rows := batch.Elem()
+		for i := 0; i < rows.Len(); i++ {
+			row := rows.Index(i)
+			record := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				record[field.DBName] = field.ReflectValueOf(ctx, row).Interface()
+			}
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+func restoreTable(ctx context.Context, tx *gorm.DB, sch *schema.Schema, rows *json.Decoder) (int64, error) {
+	fields := columns(sch)
+	batch := make([]map[string]interface{}, 0, batchSize)
+
+	// Rows are inserted as column maps rather than models: gorm replaces zero
+	// values of columns with a default by that default when creating models,
+	// which would turn e.g. a quantity of 0 into 1.
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.Table(sch.Table).Create(&batch).Error; err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", sch.Table, err)
+		}
+		batch = make([]map[string]interface{}, 0, batchSize)
+		return nil
+	}
+
+	var count int64
+	for rows.More() {
+		var record map[string]json.RawMessage
+		if err := rows.Decode(&record); err != nil {
+			return count, err
+		}
+
+		// Decoding into the model gives every value its column's Go type
+		row := reflect.New(sch.ModelType).Elem()
+		for _, field := range fields {
+			raw, ok := record[field.DBName]
+			if !ok {
+				continue
+			}
+			value := reflect.New(field.FieldType)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return count, fmt.Errorf("invalid value for %s.%s: %w", sch.Table, field.DBName, err)
+			}
+			field.ReflectValueOf(ctx, row).Set(value.Elem())
+		}
+
+		values := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			values[field.DBName], _ = field.ValueOf(ctx, row)
+		}
+
+		batch = append(batch, values)
+		count++
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	return count, flush()
+}
+
+// readArchive walks an archive, handing each table file to handle and
+// checking its row count and checksum against the manifest afterwards.
+func readArchive(r io.Reader, handle func(table TableManifest, rows *json.Decoder) (int64, error)) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if header.Name != manifestFile {
+		return nil, fmt.Errorf("expected %s as first archive entry, found %s", manifestFile, header.Name)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Format != FormatName {
+		return nil, fmt.Errorf("unknown archive format %q", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d (this build reads up to %d)", manifest.Version, FormatVersion)
+	}
+
+	for _, table := range manifest.Tables {
+		header, err := tr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("archive is missing %s: %w", table.File, err)
+		}
+		if header.Name != table.File {
+			return nil, fmt.Errorf("expected %s in archive, found %s", table.File, header.Name)
+		}
+
+		hasher := sha256.New()
+		body := io.TeeReader(tr, hasher)
+		rows, err := handle(table, json.NewDecoder(body))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.File, err)
+		}
+		if err := checkTable(table, rows, body, hasher); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("archive contains entries not listed in the manifest")
+	}
+
+	return &manifest, nil
+}
+
+func checkTable(table TableManifest, rows int64, body io.Reader, hasher hash.Hash) error {
+	// Hash whatever the decoder did not consume, such as the trailing newline.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+
+	if rows != table.Rows {
+		return fmt.Errorf("%s: expected %d rows, found %d", table.File, table.Rows, rows)
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != table.SHA256 {
+		return fmt.Errorf("%s: checksum mismatch (expected %s, got %s)", table.File, table.SHA256, sum)
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, body io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, body)
+	return err
+}
@@ -0,0 +1,112 @@
+package backup_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/backup"
+)
+
+const usersJSONL = `{"id":1,"username":"alice","password":"hash"}
+{"id":2,"username":"bob","password":"hash"}
+`
+
+// buildArchive assembles an archive by hand the same way backup.Backup lays
+// it out: the manifest first, then one JSON lines file per table.
+func buildArchive(t *testing.T, manifest backup.Manifest, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, body []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(body))}); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write(body); err != nil {
+			t.Fatalf("Failed to write body: %v", err)
+		}
+	}
+
+	manifestJSON, _ := json.Marshal(manifest)
+	write("manifest.json", manifestJSON)
+	for _, table := range manifest.Tables {
+		write(table.File, []byte(files[table.File]))
+	}
+
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func usersManifest() backup.Manifest {
+	return backup.Manifest{
+		Format:    backup.FormatName,
+		Version:   backup.FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Tables: []backup.TableManifest{
+			{Name: "users", File: "users.jsonl", Rows: 2, SHA256: checksum(usersJSONL)},
+		},
+	}
+}
+
+func TestVerify_IntactArchive(t *testing.T) {
+	archive := buildArchive(t, usersManifest(), map[string]string{"users.jsonl": usersJSONL})
+
+	manifest, err := backup.Verify(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(manifest.Tables) != 1 || manifest.Tables[0].Rows != 2 {
+		t.Errorf("Expected one table with 2 rows, got %+v", manifest.Tables)
+	}
+}
+
+func TestVerify_TamperedTable(t *testing.T) {
+	tampered := strings.Replace(usersJSONL, "bob", "eve", 1)
+	archive := buildArchive(t, usersManifest(), map[string]string{"users.jsonl": tampered})
+
+	_, err := backup.Verify(bytes.NewReader(archive))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestVerify_RowCountMismatch(t *testing.T) {
+	manifest := usersManifest()
+	manifest.Tables[0].Rows = 3
+	archive := buildArchive(t, manifest, map[string]string{"users.jsonl": usersJSONL})
+
+	if _, err := backup.Verify(bytes.NewReader(archive)); err == nil {
+		t.Error("Expected row count mismatch error, got nil")
+	}
+}
+
+func TestVerify_NewerFormatVersion(t *testing.T) {
+	manifest := usersManifest()
+	manifest.Version = backup.FormatVersion + 1
+	archive := buildArchive(t, manifest, map[string]string{"users.jsonl": usersJSONL})
+
+	if _, err := backup.Verify(bytes.NewReader(archive)); err == nil {
+		t.Error("Expected unsupported version error, got nil")
+	}
+}
+
+func TestVerify_TruncatedArchive(t *testing.T) {
+	archive := buildArchive(t, usersManifest(), map[string]string{"users.jsonl": usersJSONL})
+
+	if _, err := backup.Verify(bytes.NewReader(archive[:len(archive)/2])); err == nil {
+		t.Error("Expected error for truncated archive, got nil")
+	}
+}
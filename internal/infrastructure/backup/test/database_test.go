@@ -0,0 +1,175 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/backup"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var fixtureTime = time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC)
+
+func newSQLiteDB(t *testing.T, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(database.Models()...); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db
+}
+
+// fill sets every column of a model to a non-zero value, so a column that
+// does not survive a backup round trip shows up as a difference. Pointers are
+// allocated and DeletedAt is set, which also covers soft-deleted rows.
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(3)
+	case reflect.Uint, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float64:
+		v.SetFloat(1.25)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(fixtureTime))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	}
+}
+
+// seed inserts one fully populated row into every table. Every primary and
+// foreign key is 1, so the rows reference each other consistently. With
+// zeroDefaults, columns that have a default are left at their zero value
+// instead, e.g. a sealed product with every unit opened.
+func seed(t *testing.T, db *gorm.DB, zeroDefaults bool) {
+	for _, model := range database.Models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("Failed to parse %T: %v", model, err)
+		}
+
+		// Rows are inserted as column maps, as gorm would otherwise replace
+		// zero values of columns with a default
+		row := reflect.New(stmt.Schema.ModelType).Elem()
+		values := make(map[string]interface{})
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !zeroDefaults || field.DefaultValue == "" {
+				fill(field.ReflectValueOf(context.Background(), row))
+			}
+			values[field.DBName], _ = field.ValueOf(context.Background(), row)
+		}
+		if err := db.Table(stmt.Schema.Table).Create(values).Error; err != nil {
+			t.Fatalf("Failed to seed %s: %v", stmt.Schema.Table, err)
+		}
+	}
+}
+
+func loadTable(t *testing.T, db *gorm.DB, model interface{}) interface{} {
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	if err := db.Unscoped().Find(rows.Interface()).Error; err != nil {
+		t.Fatalf("Failed to load %T: %v", model, err)
+	}
+	return rows.Elem().Interface()
+}
+
+func TestBackupRestore_EveryModel(t *testing.T) {
+	for _, zeroDefaults := range []bool{false, true} {
+		name := "filled"
+		if zeroDefaults {
+			name = "zero defaults"
+		}
+		t.Run(name, func(t *testing.T) {
+			testBackupRestore(t, zeroDefaults)
+		})
+	}
+}
+
+func testBackupRestore(t *testing.T, zeroDefaults bool) {
+	ctx := context.Background()
+	source := newSQLiteDB(t, "source.db")
+	seed(t, source, zeroDefaults)
+
+	var archive bytes.Buffer
+	manifest, err := backup.Backup(ctx, source, database.Models(), &archive)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	models := database.Models()
+	if len(manifest.Tables) != len(models) {
+		t.Fatalf("Expected %d tables in manifest, got %d", len(models), len(manifest.Tables))
+	}
+	for _, table := range manifest.Tables {
+		if table.Rows != 1 {
+			t.Errorf("Expected 1 row in %s, got %d", table.Name, table.Rows)
+		}
+	}
+
+	target := newSQLiteDB(t, "target.db")
+	if _, err := backup.Restore(ctx, target, database.Models(), bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	for _, model := range models {
+		want := loadTable(t, source, model)
+		got := loadTable(t, target, model)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%T differs after restore:\nwant %+v\ngot  %+v", model, want, got)
+		}
+	}
+}
+
+func TestRestore_RefusesNonEmptyDatabase(t *testing.T) {
+	ctx := context.Background()
+	source := newSQLiteDB(t, "source.db")
+	seed(t, source, false)
+
+	var archive bytes.Buffer
+	if _, err := backup.Backup(ctx, source, database.Models(), &archive); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	_, err := backup.Restore(ctx, source, database.Models(), bytes.NewReader(archive.Bytes()))
+	if err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Fatalf("Expected non-empty database error, got %v", err)
+	}
+
+	for _, model := range database.Models() {
+		var count int64
+		source.Unscoped().Model(model).Count(&count)
+		if count != 1 {
+			t.Errorf("Expected %T to still hold 1 row, got %d", model, count)
+		}
+	}
+}
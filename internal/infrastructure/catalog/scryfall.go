@@ -0,0 +1,304 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+const importBatchSize = 500
+
+// scryfallCard holds the fields of a Scryfall card object that the catalog
+// uses. Everything else in the bulk file is ignored.
+type scryfallCard struct {
+	Set             string   `json:"set"`
+	SetName         string   `json:"set_name"`
+	CollectorNumber string   `json:"collector_number"`
+	Name            string   `json:"name"`
+	PrintedName     string   `json:"printed_name"`
+	Lang            string   `json:"lang"`
+	Rarity          string   `json:"rarity"`
+	Colors          []string `json:"colors"`
+	ColorIdentity   []string `json:"color_identity"`
+	TypeLine        string   `json:"type_line"`
+	CMC             float64  `json:"cmc"`
+	OracleID        string   `json:"oracle_id"`
+	ImageURIs       struct {
+		Normal string `json:"normal"`
+	} `json:"image_uris"`
+	CardFaces []struct {
+		Colors      []string `json:"colors"`
+		TypeLine    string   `json:"type_line"`
+		OracleID    string   `json:"oracle_id"`
+		PrintedName string   `json:"printed_name"`
+		ImageURIs   struct {
+			Normal string `json:"normal"`
+		} `json:"image_uris"`
+	} `json:"card_faces"`
+	ReleasedAt string   `json:"released_at"`
+	Finishes   []string `json:"finishes"`
+	Foil       bool     `json:"foil"`
+	Nonfoil    bool     `json:"nonfoil"`
+}
+
+// Printing is one card read from a Scryfall bulk file together with its set.
+type Printing struct {
+	Set  entity.CatalogSet
+	Card entity.CatalogCard
+	// English is false for printings that only exist in another language.
+	English bool
+	// Translation holds the printed name and image of printings in another
+	// language; it is nil for English printings.
+	Translation *entity.CatalogTranslation
+}
+
+// ReadScryfall streams a Scryfall bulk data file (a JSON array of card
+// objects, e.g. "Default Cards") and calls fn for every printing, without
+// loading the whole file into memory.
+func ReadScryfall(r io.Reader, fn func(Printing) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("invalid catalog file: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.New("invalid catalog file: expected a JSON array of cards")
+	}
+
+	for index := 0; decoder.More(); index++ {
+		var card scryfallCard
+		if err := decoder.Decode(&card); err != nil {
+			return fmt.Errorf("invalid card at index %d: %w", index, err)
+		}
+		if card.Set == "" || card.CollectorNumber == "" {
+			continue
+		}
+		if err := fn(newPrinting(card)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("invalid catalog file: %w", err)
+	}
+	return nil
+}
+
+func newPrinting(card scryfallCard) Printing {
+	printing := Printing{
+		Set: entity.CatalogSet{
+			Code: strings.ToLower(card.Set),
+			Name: card.SetName,
+		},
+		Card: entity.CatalogCard{
+			SetCode:         strings.ToLower(card.Set),
+			CollectorNumber: card.CollectorNumber,
+			Name:            card.Name,
+			Rarity:          entity.Rarity(card.Rarity),
+			Colors:          colorString(card),
+			ColorIdentity:   colorLetters(card.ColorIdentity),
+			TypeLine:        typeLine(card),
+			ManaValue:       card.CMC,
+			OracleID:        card.OracleID,
+			Nonfoil:         card.Nonfoil,
+			Foil:            card.Foil,
+		},
+		English: card.Lang == "" || card.Lang == "en",
+	}
+
+	if printing.Card.OracleID == "" && len(card.CardFaces) > 0 {
+		printing.Card.OracleID = card.CardFaces[0].OracleID
+	}
+
+	if t, err := time.Parse("2006-01-02", card.ReleasedAt); err == nil {
+		printing.Set.ReleasedAt = &t
+	}
+
+	if !printing.English {
+		printing.Translation = &entity.CatalogTranslation{
+			SetCode:         printing.Card.SetCode,
+			CollectorNumber: card.CollectorNumber,
+			Lang:            strings.ToLower(card.Lang),
+			Name:            printedName(card),
+			ImageURL:        card.ImageURIs.Normal,
+		}
+		if printing.Translation.ImageURL == "" && len(card.CardFaces) > 0 {
+			printing.Translation.ImageURL = card.CardFaces[0].ImageURIs.Normal
+		}
+	}
+
+	// finishes replaced the foil/nonfoil flags; etched cards count as foil
+	if len(card.Finishes) > 0 {
+		printing.Card.Nonfoil, printing.Card.Foil = false, false
+		for _, finish := range card.Finishes {
+			if finish == "nonfoil" {
+				printing.Card.Nonfoil = true
+			} else {
+				printing.Card.Foil = true
+			}
+		}
+	}
+
+	return printing
+}
+
+// printedName returns the name printed on a card in its language. Cards
+// with several faces only have it per face, and printings without a
+// translated name carry the English one.
+func printedName(card scryfallCard) string {
+	if card.PrintedName != "" {
+		return card.PrintedName
+	}
+	var faces []string
+	for _, face := range card.CardFaces {
+		if face.PrintedName != "" {
+			faces = append(faces, face.PrintedName)
+		}
+	}
+	if len(faces) > 0 {
+		return strings.Join(faces, " // ")
+	}
+	return card.Name
+}
+
+// typeLine returns the card's type line. Reversible cards only have one per
+// face, so those are joined like the faces of other cards.
+func typeLine(card scryfallCard) string {
+	if card.TypeLine != "" {
+		return card.TypeLine
+	}
+	var faces []string
+	for _, face := range card.CardFaces {
+		if face.TypeLine != "" {
+			faces = append(faces, face.TypeLine)
+		}
+	}
+	return strings.Join(faces, " // ")
+}
+
+// colorString returns the card's colors in WUBRG order. Double-faced cards
+// only list colors per face, so those are combined.
+func colorString(card scryfallCard) string {
+	colors := card.Colors
+	if len(colors) == 0 {
+		for _, face := range card.CardFaces {
+			colors = append(colors, face.Colors...)
+		}
+	}
+	return colorLetters(colors)
+}
+
+// colorLetters returns the distinct color letters in WUBRG order.
+func colorLetters(colors []string) string {
+	present := make(map[string]bool)
+	for _, color := range colors {
+		present[color] = true
+	}
+
+	var letters strings.Builder
+	for _, color := range entity.Colors {
+		if present[color] {
+			letters.WriteString(color)
+		}
+	}
+	return letters.String()
+}
+
+// ImportResult counts the sets, printings and translated printings read
+// from the file.
+type ImportResult struct {
+	Sets         int
+	Cards        int
+	Translations int
+}
+
+// Import loads a Scryfall bulk data file into the catalog. English printings
+// replace existing catalog entries; printings in other languages are only
+// added where no entry exists yet, so a file with every language does not
+// overwrite English names. Printings in other languages are also stored as
+// translations of the English printing with the same set and number.
+func Import(ctx context.Context, catalogRepo repository.CatalogRepository, r io.Reader) (*ImportResult, error) {
+	result := &ImportResult{}
+	sets := make(map[string]entity.CatalogSet)
+	var english, other []entity.CatalogCard
+	var translations []entity.CatalogTranslation
+
+	flush := func(cards []entity.CatalogCard, overwrite bool) error {
+		if err := catalogRepo.UpsertCards(ctx, cards, overwrite); err != nil {
+			return err
+		}
+		result.Cards += len(cards)
+		return nil
+	}
+	flushTranslations := func() error {
+		if err := catalogRepo.UpsertTranslations(ctx, translations); err != nil {
+			return err
+		}
+		result.Translations += len(translations)
+		translations = translations[:0]
+		return nil
+	}
+
+	err := ReadScryfall(r, func(printing Printing) error {
+		if _, ok := sets[printing.Set.Code]; !ok || printing.English {
+			sets[printing.Set.Code] = printing.Set
+		}
+
+		if printing.English {
+			english = append(english, printing.Card)
+			if len(english) == importBatchSize {
+				err := flush(english, true)
+				english = english[:0]
+				return err
+			}
+			return nil
+		}
+
+		translations = append(translations, *printing.Translation)
+		if len(translations) == importBatchSize {
+			if err := flushTranslations(); err != nil {
+				return err
+			}
+		}
+
+		other = append(other, printing.Card)
+		if len(other) == importBatchSize {
+			err := flush(other, false)
+			other = other[:0]
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]entity.CatalogSet, 0, len(sets))
+	for _, set := range sets {
+		list = append(list, set)
+	}
+	if err := catalogRepo.UpsertSets(ctx, list); err != nil {
+		return nil, err
+	}
+	result.Sets = len(list)
+
+	if err := flush(english, true); err != nil {
+		return nil, err
+	}
+	if err := flush(other, false); err != nil {
+		return nil, err
+	}
+	if err := flushTranslations(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package catalog_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/catalog"
+)
+
+const bulkJSON = `[
+  {"object":"card","name":"Solitude","lang":"en","set":"MH2","set_name":"Modern Horizons 2","released_at":"2021-06-18","collector_number":"32","rarity":"mythic","colors":["W"],"color_identity":["W"],"type_line":"Creature — Elemental Incarnation","cmc":5.0,"oracle_id":"2d8c3a23","finishes":["nonfoil","foil"]},
+  {"object":"card","name":"Solitude","printed_name":"孤独","lang":"ja","set":"mh2","set_name":"Modern Horizons 2","released_at":"2021-06-18","collector_number":"32","rarity":"mythic","finishes":["nonfoil"],"image_uris":{"normal":"https://cards.scryfall.io/normal/front/ja/solitude.jpg"}},
+  {"object":"card","name":"Delver of Secrets // Insectile Aberration","lang":"de","set":"isd","set_name":"Innistrad","released_at":"2011-09-30","collector_number":"51","rarity":"common","finishes":["nonfoil"],"card_faces":[{"printed_name":"Entdecker der Geheimnisse","image_uris":{"normal":"https://cards.scryfall.io/normal/front/de/delver.jpg"}},{"printed_name":"Insektenhafte Abart","image_uris":{"normal":"https://cards.scryfall.io/normal/back/de/delver.jpg"}}]},
+  {"object":"card","name":"Sol Ring","lang":"en","set":"cmr","set_name":"Commander Legends","released_at":"2020-11-20","collector_number":"472","rarity":"uncommon","finishes":["etched"]},
+  {"object":"card","name":"Old Card","lang":"en","set":"lea","set_name":"Limited Edition Alpha","released_at":"1993-08-05","collector_number":"1","rarity":"rare","color_identity":["G","U"],"nonfoil":true,"foil":false,"card_faces":[{"type_line":"Creature — Elf","oracle_id":"9f1c0e2a"},{"type_line":"Creature — Elf"}]}
+]`
+
+type recordingCatalogRepository struct {
+	sets         []entity.CatalogSet
+	overwritten  []entity.CatalogCard
+	added        []entity.CatalogCard
+	translations []entity.CatalogTranslation
+}
+
+func (r *recordingCatalogRepository) UpsertSets(ctx context.Context, sets []entity.CatalogSet) error {
+	r.sets = append(r.sets, sets...)
+	return nil
+}
+
+func (r *recordingCatalogRepository) UpsertCards(ctx context.Context, cards []entity.CatalogCard, overwrite bool) error {
+	if overwrite {
+		r.overwritten = append(r.overwritten, cards...)
+	} else {
+		r.added = append(r.added, cards...)
+	}
+	return nil
+}
+
+func (r *recordingCatalogRepository) FindSets(ctx context.Context) ([]entity.CatalogSet, error) {
+	return r.sets, nil
+}
+
+func (r *recordingCatalogRepository) FindSet(ctx context.Context, code string) (*entity.CatalogSet, error) {
+	return nil, nil
+}
+
+func (r *recordingCatalogRepository) FindCardsBySet(ctx context.Context, codes []string) ([]entity.CatalogCard, error) {
+	return nil, nil
+}
+
+func (r *recordingCatalogRepository) FindPrintings(ctx context.Context, cards []entity.Card) ([]entity.CatalogCard, error) {
+	return nil, nil
+}
+
+func (r *recordingCatalogRepository) ApplyMetadata(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *recordingCatalogRepository) UpsertTranslations(ctx context.Context, translations []entity.CatalogTranslation) error {
+	r.translations = append(r.translations, translations...)
+	return nil
+}
+
+func (r *recordingCatalogRepository) FindTranslations(ctx context.Context, cards []entity.Card) ([]entity.CatalogTranslation, error) {
+	return nil, nil
+}
+
+func TestReadScryfall(t *testing.T) {
+	var printings []catalog.Printing
+	err := catalog.ReadScryfall(strings.NewReader(bulkJSON), func(p catalog.Printing) error {
+		printings = append(printings, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(printings) != 5 {
+		t.Fatalf("Expected 5 printings, got %d", len(printings))
+	}
+
+	solitude := printings[0]
+	if solitude.Set.Code != "mh2" || solitude.Set.ReleasedAt == nil || !solitude.English {
+		t.Errorf("Expected lower-case English mh2 printing with release date, got %+v", solitude)
+	}
+	if !solitude.Card.Nonfoil || !solitude.Card.Foil || solitude.Card.Rarity != entity.RarityMythic {
+		t.Errorf("Expected nonfoil and foil mythic, got %+v", solitude.Card)
+	}
+	if m := solitude.Card.Metadata(); m.Colors != "W" || m.ColorIdentity != "W" || m.TypeLine != "Creature — Elemental Incarnation" || m.ManaValue != 5 || m.OracleID != "2d8c3a23" {
+		t.Errorf("Expected Solitude's colors, type line, mana value and oracle ID, got %+v", m)
+	}
+	if solitude.Translation != nil {
+		t.Errorf("Expected no translation of an English printing, got %+v", solitude.Translation)
+	}
+	if printings[1].English {
+		t.Error("Expected Japanese printing not to be English")
+	}
+	if ja := printings[1].Translation; ja == nil || ja.Lang != "ja" || ja.Name != "孤独" || ja.SetCode != "mh2" || ja.ImageURL != "https://cards.scryfall.io/normal/front/ja/solitude.jpg" {
+		t.Errorf("Expected the Japanese name and image, got %+v", ja)
+	}
+	if de := printings[2].Translation; de == nil || de.Name != "Entdecker der Geheimnisse // Insektenhafte Abart" || de.ImageURL != "https://cards.scryfall.io/normal/front/de/delver.jpg" {
+		t.Errorf("Expected the German face names and front image, got %+v", de)
+	}
+	if solRing := printings[3].Card; solRing.Nonfoil || !solRing.Foil {
+		t.Errorf("Expected etched-only card to count as foil only, got %+v", solRing)
+	}
+	if old := printings[4].Card; !old.Nonfoil || old.Foil {
+		t.Errorf("Expected legacy foil/nonfoil flags to be used, got %+v", old)
+	}
+	if old := printings[4].Card; old.TypeLine != "Creature — Elf // Creature — Elf" || old.OracleID != "9f1c0e2a" || old.ColorIdentity != "UG" {
+		t.Errorf("Expected type lines and oracle ID from the faces and identity in WUBRG order, got %+v", old)
+	}
+}
+
+func TestReadScryfall_Malformed(t *testing.T) {
+	for _, input := range []string{`{"object":"list"}`, `[{"name":"Opt","set":`, ``} {
+		err := catalog.ReadScryfall(strings.NewReader(input), func(catalog.Printing) error { return nil })
+		if err == nil {
+			t.Errorf("Expected error for %q, got nil", input)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	repo := &recordingCatalogRepository{}
+
+	result, err := catalog.Import(context.Background(), repo, strings.NewReader(bulkJSON))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Sets != 4 || result.Cards != 5 || result.Translations != 2 {
+		t.Errorf("Expected 4 sets, 5 cards and 2 translations, got %+v", result)
+	}
+	if len(repo.translations) != 2 {
+		t.Errorf("Expected 2 stored translations, got %d", len(repo.translations))
+	}
+	if len(repo.overwritten) != 3 || len(repo.added) != 2 {
+		t.Errorf("Expected English printings to overwrite and others to only be added, got %d/%d", len(repo.overwritten), len(repo.added))
+	}
+}
@@ -11,6 +11,37 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// Models lists every persisted entity in dependency order: a model only
+// references models listed before it. Migrations and backups both rely on
+// this list, so new entities must be added here.
+func Models() []interface{} {
+	return []interface{}{
+		&entity.User{},
+		&entity.Location{},
+		&entity.Card{},
+		&entity.AuditEvent{},
+		&entity.Deck{},
+		&entity.DeckEntry{},
+		&entity.WishlistItem{},
+		&entity.TradeProposal{},
+		&entity.TradeLine{},
+		&entity.PurchaseLot{},
+		&entity.CardSale{},
+		&entity.LotAllocation{},
+		&entity.Tag{},
+		&entity.CardTag{},
+		&entity.CustomField{},
+		&entity.CustomFieldValue{},
+		&entity.Loan{},
+		&entity.SealedProduct{},
+		&entity.CardImage{},
+		&entity.CardPhoto{},
+		&entity.CatalogSet{},
+		&entity.CatalogCard{},
+		&entity.CatalogTranslation{},
+	}
+}
+
 func NewDatabase() (*gorm.DB, error) {
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
@@ -31,7 +62,7 @@ func NewDatabase() (*gorm.DB, error) {
 	log.Println("Database connected successfully")
 
 	// Auto migrate
-	if err := db.AutoMigrate(&entity.User{}, &entity.Card{}); err != nil {
+	if err := db.AutoMigrate(Models()...); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
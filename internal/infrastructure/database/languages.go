@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+// LanguageMapping reports how one distinct language value stored on cards
+// maps to a language code. Code is empty for values that are not a known
+// language.
+type LanguageMapping struct {
+	Value string
+	Code  string
+	Cards int64
+}
+
+// Changed reports whether cards with the value are rewritten.
+func (m LanguageMapping) Changed() bool {
+	return m.Code != "" && m.Code != m.Value
+}
+
+// NormalizeLanguages rewrites the free-text language of every card,
+// including deleted ones, to its language code, and returns how each
+// distinct value was mapped. Values that are not a known language are left
+// as they are. With dryRun set only the report is built.
+func NormalizeLanguages(ctx context.Context, db *gorm.DB, dryRun bool) ([]LanguageMapping, error) {
+	var mappings []LanguageMapping
+	err := db.WithContext(ctx).Unscoped().
+		Model(&entity.Card{}).
+		Select("language AS value, COUNT(*) AS cards").
+		Where("language <> ''").
+		Group("language").
+		Order("language").
+		Scan(&mappings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range mappings {
+		if language, ok := entity.FindLanguage(mappings[i].Value); ok {
+			mappings[i].Code = language.Code
+		}
+	}
+	if dryRun {
+		return mappings, nil
+	}
+
+	// Card versions are left alone: the language means the same as before,
+	// so an edit form opened earlier can still be saved.
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, mapping := range mappings {
+			if !mapping.Changed() {
+				continue
+			}
+			err := tx.Unscoped().
+				Model(&entity.Card{}).
+				Where("language = ?", mapping.Value).
+				UpdateColumn("language", mapping.Code).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
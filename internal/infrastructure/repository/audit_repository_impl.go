@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *entity.AuditEvent) error {
+	return dbFor(ctx, r.db).Create(event).Error
+}
+
+func (r *auditRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.AuditEvent, error) {
+	var events []entity.AuditEvent
+	err := dbFor(ctx, r.db).Where("card_id = ? AND user_id = ?", cardID, userID).
+		Order("created_at DESC, id DESC").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditRepository) FindByUserID(ctx context.Context, userID uint, page, pageSize int) ([]entity.AuditEvent, int64, error) {
+	var events []entity.AuditEvent
+	var total int64
+
+	query := dbFor(ctx, r.db).Model(&entity.AuditEvent{}).Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *auditRepository) FindAllByUserID(ctx context.Context, userID uint) ([]entity.AuditEvent, error) {
+	var events []entity.AuditEvent
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.AuditEvent{}).Error
+}
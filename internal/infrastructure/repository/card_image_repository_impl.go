@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type cardImageRepository struct {
+	db *gorm.DB
+}
+
+func NewCardImageRepository(db *gorm.DB) repository.CardImageRepository {
+	return &cardImageRepository{db: db}
+}
+
+func (r *cardImageRepository) Create(ctx context.Context, image *entity.CardImage) error {
+	sourceURL := image.SourceURL
+	db := dbFor(ctx, r.db)
+	if err := db.Where(entity.CardImage{Hash: image.Hash}).FirstOrCreate(image).Error; err != nil {
+		return err
+	}
+	// A file uploaded first and downloaded later keeps the download's URL,
+	// so the next card pointing at that URL finds it without downloading.
+	if image.SourceURL == "" && sourceURL != "" {
+		image.SourceURL = sourceURL
+		return db.Model(image).Update("source_url", sourceURL).Error
+	}
+	return nil
+}
+
+func (r *cardImageRepository) FindByHash(ctx context.Context, hash string) (*entity.CardImage, error) {
+	var image entity.CardImage
+	err := dbFor(ctx, r.db).Where("hash = ?", hash).First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *cardImageRepository) FindBySourceURL(ctx context.Context, url string) (*entity.CardImage, error) {
+	var image entity.CardImage
+	err := dbFor(ctx, r.db).Where("source_url = ?", url).Order("id DESC").First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *cardImageRepository) FindPendingCards(ctx context.Context, limit int) ([]entity.Card, error) {
+	var cards []entity.Card
+	err := dbFor(ctx, r.db).
+		Where("card_image_url <> '' AND card_image_url <> image_source_url").
+		Order("id").
+		Limit(limit).
+		Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *cardImageRepository) SetCardImage(ctx context.Context, cardID uint, sourceURL string, hash string) error {
+	return dbFor(ctx, r.db).Model(&entity.Card{}).Where("id = ?", cardID).
+		UpdateColumns(map[string]interface{}{"image_source_url": sourceURL, "image_hash": hash}).Error
+}
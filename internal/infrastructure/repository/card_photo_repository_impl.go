@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type cardPhotoRepository struct {
+	db *gorm.DB
+}
+
+func NewCardPhotoRepository(db *gorm.DB) repository.CardPhotoRepository {
+	return &cardPhotoRepository{db: db}
+}
+
+func (r *cardPhotoRepository) Create(ctx context.Context, photo *entity.CardPhoto) error {
+	return dbFor(ctx, r.db).Create(photo).Error
+}
+
+func (r *cardPhotoRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	result := dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.CardPhoto{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *cardPhotoRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.CardPhoto, error) {
+	var photo entity.CardPhoto
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&photo, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *cardPhotoRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	err := dbFor(ctx, r.db).Where("card_id = ? AND user_id = ?", cardID, userID).Order("id").Find(&photos).Error
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *cardPhotoRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&photos).Error
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *cardPhotoRepository) CountByCardID(ctx context.Context, cardID uint, userID uint) (int64, error) {
+	var count int64
+	err := dbFor(ctx, r.db).Model(&entity.CardPhoto{}).Where("card_id = ? AND user_id = ?", cardID, userID).Count(&count).Error
+	return count, err
+}
+
+func (r *cardPhotoRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.CardPhoto{}).Error
+}
+
+// deletePhotoRows removes the photo rows of cards deleted in tx and returns
+// them, so their files can be removed once the delete is committed.
+func deletePhotoRows(tx *gorm.DB, cardIDs []uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	if len(cardIDs) == 0 {
+		return photos, nil
+	}
+	if err := tx.Where("card_id IN ?", cardIDs).Order("id").Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return photos, nil
+	}
+	if err := tx.Where("card_id IN ?", cardIDs).Delete(&entity.CardPhoto{}).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+)
+
+var numberColumns = map[cardquery.Field]string{
+	cardquery.FieldQuantity: "quantity",
+	cardquery.FieldPrice:    "buying_price",
+	cardquery.FieldForTrade: "for_trade",
+}
+
+var dateColumns = map[cardquery.Field]string{
+	cardquery.FieldBought: "bought_date",
+	cardquery.FieldSold:   "sell_date",
+}
+
+var textColumns = map[cardquery.Field]string{
+	cardquery.FieldName:   "card_name",
+	cardquery.FieldSet:    "set_code",
+	cardquery.FieldNumber: "collector_number",
+	cardquery.FieldType:   "type_line",
+	cardquery.FieldOracle: "oracle_id",
+}
+
+var colorColumns = map[cardquery.Field]string{
+	cardquery.FieldColor:    "colors",
+	cardquery.FieldIdentity: "color_identity",
+}
+
+// knownMetadata matches cards whose printing was found in the catalog; see
+// entity.CardMetadata.Known.
+const knownMetadata = "rarity <> ''"
+
+// hasTagCondition matches cards carrying the user's tag of the given name.
+const hasTagCondition = "EXISTS (SELECT 1 FROM card_tags JOIN tags ON tags.id = card_tags.tag_id " +
+	"WHERE card_tags.card_id = cards.id AND tags.user_id = cards.user_id AND LOWER(tags.name) = ?)"
+
+// compileCardQuery turns a parsed search into a WHERE condition. Every value
+// from the query is passed as a bind argument; only column names and
+// operators chosen here end up in the SQL text.
+func compileCardQuery(expr cardquery.Expr) (string, []interface{}) {
+	switch e := expr.(type) {
+	case *cardquery.And:
+		left, leftArgs := compileCardQuery(e.Left)
+		right, rightArgs := compileCardQuery(e.Right)
+		return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+	case *cardquery.Or:
+		left, leftArgs := compileCardQuery(e.Left)
+		right, rightArgs := compileCardQuery(e.Right)
+		return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+	case *cardquery.Not:
+		inner, args := compileCardQuery(e.Expr)
+		return "NOT " + inner, args
+	case *cardquery.Term:
+		return compileCardTerm(e)
+	}
+	return "1 = 1", nil
+}
+
+func compileCardTerm(t *cardquery.Term) (string, []interface{}) {
+	switch t.Field {
+	case cardquery.FieldText:
+		pattern := likePattern(t.Text)
+		return "(card_name LIKE ? ESCAPE '!' OR set_code LIKE ? ESCAPE '!' OR collector_number LIKE ? ESCAPE '!')",
+			[]interface{}{pattern, pattern, pattern}
+	case cardquery.FieldName, cardquery.FieldSet, cardquery.FieldNumber, cardquery.FieldType, cardquery.FieldOracle:
+		column := textColumns[t.Field]
+		if (t.Field == cardquery.FieldName || t.Field == cardquery.FieldType) && t.Op == cardquery.OpMatch {
+			return "(" + column + " LIKE ? ESCAPE '!')", []interface{}{likePattern(t.Text)}
+		}
+		return negate(t.Op, "(LOWER("+column+") = ?)"), []interface{}{strings.ToLower(t.Text)}
+	case cardquery.FieldLanguage:
+		return negate(t.Op, "(LOWER(language) IN ?)"), []interface{}{languageNames(t.Text)}
+	case cardquery.FieldQuantity, cardquery.FieldPrice, cardquery.FieldForTrade:
+		return "(" + numberColumns[t.Field] + " " + sqlOperator(t.Op) + " ?)", []interface{}{t.Number}
+	case cardquery.FieldBought, cardquery.FieldSold:
+		return compileDateTerm(dateColumns[t.Field], t.Op, t.Date)
+	case cardquery.FieldColor, cardquery.FieldIdentity:
+		return compileColorTerm(t)
+	case cardquery.FieldManaValue:
+		return "(" + knownMetadata + " AND mana_value " + sqlOperator(t.Op) + " ?)", []interface{}{t.Number}
+	case cardquery.FieldRarity:
+		return compileRarityTerm(t)
+	case cardquery.FieldIs:
+		switch t.Text {
+		case cardquery.FlagSold:
+			return "(sell_date IS NOT NULL)", nil
+		case cardquery.FlagHeld:
+			return "(sell_date IS NULL)", nil
+		case cardquery.FlagFoil:
+			return "(foil = ?)", []interface{}{true}
+		case cardquery.FlagNonfoil:
+			return "(foil = ?)", []interface{}{false}
+		case cardquery.FlagTrade:
+			return "(for_trade > 0)", nil
+		}
+	case cardquery.FieldTag:
+		return negate(t.Op, hasTagCondition), []interface{}{strings.ToLower(t.Text)}
+	case cardquery.FieldCustom:
+		return compileCustomTerm(t)
+	}
+	return "1 = 1", nil
+}
+
+// compileCustomTerm matches the value of a resolved custom field. Like the
+// built-in fields, cards without a value never match a comparison, so
+// negating one selects them.
+func compileCustomTerm(t *cardquery.Term) (string, []interface{}) {
+	if t.FieldID == 0 {
+		return "1 = 0", nil
+	}
+
+	op := t.Op
+	if op == cardquery.OpNotEqual {
+		op = cardquery.OpEqual
+	}
+
+	var condition string
+	var args []interface{}
+	switch entity.CustomFieldKind(t.Kind) {
+	case entity.CustomFieldNumber:
+		condition = "custom_field_values.number " + sqlOperator(op) + " ?"
+		args = []interface{}{t.Number}
+	case entity.CustomFieldDate:
+		condition, args = compileDateTerm("custom_field_values.date", op, t.Date)
+	case entity.CustomFieldText:
+		if op == cardquery.OpMatch {
+			condition = "custom_field_values.value LIKE ? ESCAPE '!'"
+			args = []interface{}{likePattern(t.Text)}
+			break
+		}
+		fallthrough
+	default:
+		condition = "LOWER(custom_field_values.value) = ?"
+		args = []interface{}{strings.ToLower(t.Text)}
+	}
+
+	exists := "EXISTS (SELECT 1 FROM custom_field_values WHERE custom_field_values.card_id = cards.id " +
+		"AND custom_field_values.field_id = ? AND " + condition + ")"
+	return negate(t.Op, exists), append([]interface{}{t.FieldID}, args...)
+}
+
+// compileColorTerm compares the colors or color identity of a card with the
+// colors of the term as sets: ">=" means "at least these colors", "<=" means
+// "no other colors". A plain ":" is ">=" for colors, except for colorless,
+// and "<=" for the identity. Cards without catalog metadata never match.
+func compileColorTerm(t *cardquery.Term) (string, []interface{}) {
+	column := colorColumns[t.Field]
+	if t.Text == cardquery.ColorMulticolor {
+		return negate(t.Op, "("+knownMetadata+" AND LENGTH("+column+") > 1)"), nil
+	}
+
+	op := t.Op
+	if op == cardquery.OpMatch {
+		op = cardquery.OpGreaterEqual
+		if t.Field == cardquery.FieldIdentity {
+			op = cardquery.OpLessEqual
+		} else if t.Text == "" {
+			op = cardquery.OpEqual
+		}
+	}
+
+	if op == cardquery.OpEqual || op == cardquery.OpNotEqual {
+		return negate(op, "("+knownMetadata+" AND "+column+" = ?)"), []interface{}{t.Text}
+	}
+
+	conditions := []string{knownMetadata}
+	var args []interface{}
+	if op == cardquery.OpGreater || op == cardquery.OpLess {
+		conditions = append(conditions, column+" <> ?")
+		args = append(args, t.Text)
+	}
+	for _, color := range entity.Colors {
+		contained := strings.Contains(t.Text, color)
+		switch {
+		case contained && (op == cardquery.OpGreaterEqual || op == cardquery.OpGreater):
+			conditions = append(conditions, column+" LIKE ?")
+		case !contained && (op == cardquery.OpLessEqual || op == cardquery.OpLess):
+			conditions = append(conditions, column+" NOT LIKE ?")
+		default:
+			continue
+		}
+		args = append(args, "%"+color+"%")
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// compileRarityTerm matches rarities, which are ordered as in
+// entity.Rarities, so r>=rare also matches mythics.
+func compileRarityTerm(t *cardquery.Term) (string, []interface{}) {
+	if t.Op == cardquery.OpMatch || t.Op == cardquery.OpEqual || t.Op == cardquery.OpNotEqual {
+		return negate(t.Op, "(rarity = ?)"), []interface{}{t.Text}
+	}
+
+	var index int
+	for i, rarity := range entity.Rarities {
+		if string(rarity) == t.Text {
+			index = i
+		}
+	}
+
+	var rarities []string
+	for i, rarity := range entity.Rarities {
+		var matches bool
+		switch t.Op {
+		case cardquery.OpLess:
+			matches = i < index
+		case cardquery.OpLessEqual:
+			matches = i <= index
+		case cardquery.OpGreater:
+			matches = i > index
+		case cardquery.OpGreaterEqual:
+			matches = i >= index
+		}
+		if matches {
+			rarities = append(rarities, string(rarity))
+		}
+	}
+	if len(rarities) == 0 {
+		return "1 = 0", nil
+	}
+	return "(rarity IN ?)", []interface{}{rarities}
+}
+
+// compileDateTerm compares whole days. Cards without the date never match a
+// comparison, so negating one selects them.
+func compileDateTerm(column string, op cardquery.Op, day time.Time) (string, []interface{}) {
+	nextDay := day.AddDate(0, 0, 1)
+	switch op {
+	case cardquery.OpLess:
+		return fmt.Sprintf("(%[1]s IS NOT NULL AND %[1]s < ?)", column), []interface{}{day}
+	case cardquery.OpLessEqual:
+		return fmt.Sprintf("(%[1]s IS NOT NULL AND %[1]s < ?)", column), []interface{}{nextDay}
+	case cardquery.OpGreater:
+		return fmt.Sprintf("(%[1]s IS NOT NULL AND %[1]s >= ?)", column), []interface{}{nextDay}
+	case cardquery.OpGreaterEqual:
+		return fmt.Sprintf("(%[1]s IS NOT NULL AND %[1]s >= ?)", column), []interface{}{day}
+	}
+	sameDay := fmt.Sprintf("(%[1]s IS NOT NULL AND %[1]s >= ? AND %[1]s < ?)", column)
+	return negate(op, sameDay), []interface{}{day, nextDay}
+}
+
+func negate(op cardquery.Op, condition string) string {
+	if op == cardquery.OpNotEqual {
+		return "NOT " + condition
+	}
+	return condition
+}
+
+func sqlOperator(op cardquery.Op) string {
+	switch op {
+	case cardquery.OpMatch, cardquery.OpEqual:
+		return "="
+	case cardquery.OpNotEqual:
+		return "<>"
+	}
+	return string(op)
+}
+
+// likePattern builds a "contains" pattern with LIKE wildcards in the value
+// escaped, to be used with ESCAPE '!'.
+func likePattern(value string) string {
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+	return "%" + escaped + "%"
+}
+
+// languageNames returns the stored values that mean the given language: its
+// code and, for cards saved before languages were normalized, its name.
+func languageNames(value string) []string {
+	if language, ok := entity.FindLanguage(value); ok {
+		return []string{language.Code, strings.ToLower(language.Name)}
+	}
+	return []string{strings.ToLower(strings.TrimSpace(value))}
+}
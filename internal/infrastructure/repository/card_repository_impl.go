@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"strings"
+
 	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
 	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type cardRepository struct {
@@ -14,39 +19,236 @@ func NewCardRepository(db *gorm.DB) repository.CardRepository {
 	return &cardRepository{db: db}
 }
 
-func (r *cardRepository) Create(card *entity.Card) error {
-	return r.db.Create(card).Error
+func (r *cardRepository) Create(ctx context.Context, card *entity.Card) error {
+	return dbFor(ctx, r.db).Create(card).Error
+}
+
+// Update writes every field of the card, but only if the stored version still
+// matches card.Version. On success the version is incremented; if another
+// request updated the card first, repository.ErrVersionConflict is returned
+// and the card is left untouched. A quantity below the copies lent out fails
+// with repository.ErrCopiesLent.
+func (r *cardRepository) Update(ctx context.Context, card *entity.Card) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkLent(tx, card.ID, card.Quantity); err != nil {
+			return err
+		}
+		return updateVersioned(tx, card)
+	})
+}
+
+func updateVersioned(db *gorm.DB, card *entity.Card) error {
+	expectedVersion := card.Version
+	card.Version++
+
+	result := db.Model(card).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Where("version = ?", expectedVersion).
+		Updates(card)
+	if result.Error != nil {
+		card.Version = expectedVersion
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		card.Version = expectedVersion
+		return repository.ErrVersionConflict
+	}
+
+	return nil
 }
 
-func (r *cardRepository) Update(card *entity.Card) error {
-	return r.db.Save(card).Error
+func (r *cardRepository) Split(ctx context.Context, source *entity.Card, part *entity.Card) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Loans stay with the source row, so it has to keep the lent copies
+		if err := checkLent(tx, source.ID, source.Quantity); err != nil {
+			return err
+		}
+		if err := tx.Create(part).Error; err != nil {
+			return err
+		}
+		if err := moveLots(tx, source.ID, part.ID, part.Quantity); err != nil {
+			return err
+		}
+		if err := copyTagsAndFields(tx, source.ID, part.ID); err != nil {
+			return err
+		}
+
+		// Each half is now worth the average cost of the lots it holds.
+		if err := priceFromLots(tx, part); err != nil {
+			return err
+		}
+		if err := tx.Model(part).Update("buying_price", part.BuyingPrice).Error; err != nil {
+			return err
+		}
+		if err := priceFromLots(tx, source); err != nil {
+			return err
+		}
+		return updateVersioned(tx, source)
+	})
 }
 
-func (r *cardRepository) Delete(id uint, userID uint) error {
-	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Card{}).Error
+func (r *cardRepository) Merge(ctx context.Context, target *entity.Card, merged []entity.Card) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, target); err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(merged))
+		for _, card := range merged {
+			result := tx.Where("id = ? AND user_id = ? AND version = ?", card.ID, card.UserID, card.Version).Delete(&entity.Card{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return repository.ErrVersionConflict
+			}
+			if err := copyTagsAndFields(tx, card.ID, target.ID); err != nil {
+				return err
+			}
+			ids = append(ids, card.ID)
+		}
+
+		if err := tx.Model(&entity.PurchaseLot{}).Where("card_id IN ?", ids).Update("card_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Loan{}).Where("card_id IN ?", ids).Update("card_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.CardPhoto{}).Where("card_id IN ?", ids).Update("card_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		pending := tx.Model(&entity.TradeProposal{}).Select("id").Where("status = ?", entity.TradeStatusPending)
+		return tx.Model(&entity.TradeLine{}).
+			Where("card_id IN ? AND proposal_id IN (?)", ids, pending).
+			Update("card_id", target.ID).Error
+	})
 }
 
-func (r *cardRepository) FindByID(id uint, userID uint) (*entity.Card, error) {
+func (r *cardRepository) UpdateBatch(ctx context.Context, userID uint, updated []entity.Card, deleted []entity.Card) (*repository.BatchResult, error) {
+	var result *repository.BatchResult
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result = &repository.BatchResult{Skipped: make(map[uint]error)}
+		skipped := result.Skipped
+		checked := make(map[uint]bool)
+		for i := range updated {
+			card := &updated[i]
+			if card.UserID != userID {
+				return gorm.ErrRecordNotFound
+			}
+			if card.LocationID != nil && !checked[*card.LocationID] {
+				var count int64
+				err := tx.Model(&entity.Location{}).Where("id = ? AND user_id = ?", *card.LocationID, userID).Count(&count).Error
+				if err != nil {
+					return err
+				}
+				if count == 0 {
+					return repository.ErrLocationNotFound
+				}
+				checked[*card.LocationID] = true
+			}
+
+			if err := batchCheckLent(tx, card.ID, card.Quantity); err != nil {
+				if !isSkipped(err) {
+					return err
+				}
+				skipped[card.ID] = err
+				continue
+			}
+			err := updateVersioned(tx, card)
+			if errors.Is(err, repository.ErrVersionConflict) {
+				skipped[card.ID] = err
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var removed []uint
+		for _, card := range deleted {
+			if err := batchCheckLent(tx, card.ID, 0); err != nil {
+				if !isSkipped(err) {
+					return err
+				}
+				skipped[card.ID] = err
+				continue
+			}
+			deletion := tx.Where("id = ? AND user_id = ? AND version = ?", card.ID, userID, card.Version).Delete(&entity.Card{})
+			if deletion.Error != nil {
+				return deletion.Error
+			}
+			if deletion.RowsAffected == 0 {
+				skipped[card.ID] = repository.ErrVersionConflict
+				continue
+			}
+			removed = append(removed, card.ID)
+		}
+
+		var err error
+		result.Photos, err = deletePhotoRows(tx, removed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// batchCheckLent is checkLent for a row of UpdateBatch, which may have been
+// deleted since it was read; that counts as a version conflict.
+func batchCheckLent(tx *gorm.DB, cardID uint, quantity int) error {
+	err := checkLent(tx, cardID, quantity)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrVersionConflict
+	}
+	return err
+}
+
+// isSkipped reports whether UpdateBatch skips a row failing with err rather
+// than failing the whole batch.
+func isSkipped(err error) bool {
+	return errors.Is(err, repository.ErrVersionConflict) || errors.Is(err, repository.ErrCopiesLent)
+}
+
+// Delete removes a card of the user together with its photo rows. Cards with
+// copies lent out are kept and repository.ErrCopiesLent is returned.
+func (r *cardRepository) Delete(ctx context.Context, id uint, userID uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkLent(tx, id, 0); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Card{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var err error
+		photos, err = deletePhotoRows(tx, []uint{id})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *cardRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Card, error) {
 	var card entity.Card
-	err := r.db.Where("user_id = ?", userID).First(&card, id).Error
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&card, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &card, nil
 }
 
-func (r *cardRepository) FindByUserID(userID uint, page, pageSize int, search string) ([]entity.Card, int64, error) {
+func (r *cardRepository) FindByUserID(ctx context.Context, userID uint, page, pageSize int, filter repository.CardFilter) ([]entity.Card, int64, error) {
 	var cards []entity.Card
 	var total int64
 
-	query := r.db.Model(&entity.Card{}).Where("user_id = ?", userID)
-
-	// Apply search filter if provided
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		query = query.Where("card_name LIKE ? OR set_code LIKE ? OR collector_number LIKE ?",
-			searchPattern, searchPattern, searchPattern)
-	}
+	query := r.listing(ctx, userID, filter)
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
@@ -55,9 +257,276 @@ func (r *cardRepository) FindByUserID(userID uint, page, pageSize int, search st
 
 	// Get paginated results
 	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&cards).Error; err != nil {
+	if err := query.Order(cardOrder(filter.Sort, filter.Ascending, false)).Offset(offset).Limit(pageSize).Find(&cards).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return cards, total, nil
 }
+
+func (r *cardRepository) FindPageByUserID(ctx context.Context, userID uint, limit int, filter repository.CardFilter, cursor *repository.CardCursor) ([]entity.Card, error) {
+	var cards []entity.Card
+
+	backward := cursor != nil && cursor.Backward
+	query := r.listing(ctx, userID, filter)
+	if cursor != nil {
+		condition, args := keysetCondition(keysetParts(filter.Sort, cursor), filter.Ascending != backward)
+		query = query.Where(condition, args...)
+	}
+
+	if err := query.Order(cardOrder(filter.Sort, filter.Ascending, backward)).Limit(limit).Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	if backward {
+		for i, j := 0, len(cards)-1; i < j; i, j = i+1, j-1 {
+			cards[i], cards[j] = cards[j], cards[i]
+		}
+	}
+	return cards, nil
+}
+
+func (r *cardRepository) CountByUserID(ctx context.Context, userID uint, filter repository.CardFilter) (int64, error) {
+	var total int64
+	err := r.listing(ctx, userID, filter).Count(&total).Error
+	return total, err
+}
+
+// listing selects the cards of a user that match the filter.
+func (r *cardRepository) listing(ctx context.Context, userID uint, filter repository.CardFilter) *gorm.DB {
+	query := dbFor(ctx, r.db).Model(&entity.Card{}).Where("user_id = ?", userID)
+
+	// Apply search filter if provided
+	if filter.Query != nil {
+		condition, args := compileCardQuery(filter.Query)
+		query = query.Where(condition, args...)
+	}
+
+	// Apply location filter if provided
+	if len(filter.LocationIDs) > 0 {
+		query = query.Where("location_id IN ?", filter.LocationIDs)
+	} else if filter.Unassigned {
+		query = query.Where("location_id IS NULL")
+	}
+
+	return applyCardFilter(query, filter)
+}
+
+// applyCardFilter adds the structured filters of the card list.
+func applyCardFilter(query *gorm.DB, filter repository.CardFilter) *gorm.DB {
+	if filter.Language != "" {
+		query = query.Where("LOWER(language) IN ?", languageNames(filter.Language))
+	}
+	switch filter.Color {
+	case "":
+	case repository.ColorMulticolor:
+		query = query.Where(knownMetadata + " AND LENGTH(colors) > 1")
+	case repository.ColorColorless:
+		query = query.Where(knownMetadata + " AND colors = ''")
+	default:
+		query = query.Where("colors = ?", filter.Color)
+	}
+	if filter.Rarity != "" {
+		query = query.Where("rarity = ?", filter.Rarity)
+	}
+	if filter.Sold != nil {
+		if *filter.Sold {
+			query = query.Where("sell_date IS NOT NULL")
+		} else {
+			query = query.Where("sell_date IS NULL")
+		}
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("buying_price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("buying_price <= ?", *filter.MaxPrice)
+	}
+	if filter.BoughtFrom != nil {
+		query = query.Where("bought_date >= ?", *filter.BoughtFrom)
+	}
+	if filter.BoughtTo != nil {
+		query = query.Where("bought_date < ?", filter.BoughtTo.AddDate(0, 0, 1))
+	}
+	if filter.SoldFrom != nil {
+		query = query.Where("sell_date >= ?", *filter.SoldFrom)
+	}
+	if filter.SoldTo != nil {
+		query = query.Where("sell_date < ?", filter.SoldTo.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+// cardSortColumns maps the whitelisted sort keys to their columns.
+var cardSortColumns = map[repository.CardSortKey][]string{
+	repository.SortByCreated:  {"created_at"},
+	repository.SortByName:     {"card_name"},
+	repository.SortBySet:      {"set_code", "collector_number"},
+	repository.SortByPrice:    {"buying_price"},
+	repository.SortByQuantity: {"quantity"},
+	repository.SortByBought:   {"bought_date"},
+	repository.SortBySold:     {"sell_date"},
+}
+
+// nullableSortColumns always sort their NULLs last.
+var nullableSortColumns = map[string]bool{"bought_date": true, "sell_date": true}
+
+func sortColumns(key repository.CardSortKey) []string {
+	if columns, ok := cardSortColumns[key]; ok {
+		return columns
+	}
+	return cardSortColumns[repository.SortByCreated]
+}
+
+// cardOrder builds the ORDER BY clause for a sort key. Cards without a date
+// always come last when sorting by that date. Backward turns the whole order
+// around, for reading the page before a cursor.
+func cardOrder(key repository.CardSortKey, ascending, backward bool) string {
+	direction, nulls := " DESC", ""
+	if ascending != backward {
+		direction = " ASC"
+	}
+	if backward {
+		nulls = " DESC"
+	}
+
+	var order []string
+	for _, column := range sortColumns(key) {
+		if nullableSortColumns[column] {
+			order = append(order, column+" IS NULL"+nulls)
+		}
+		order = append(order, column+direction)
+	}
+	order = append(order, "id"+direction)
+	return strings.Join(order, ", ")
+}
+
+// keysetPart is one column of a keyset comparison and the cursor's value for
+// it; a nil value stands for NULL.
+type keysetPart struct {
+	column   string
+	value    interface{}
+	nullable bool
+	backward bool
+}
+
+func keysetParts(key repository.CardSortKey, cursor *repository.CardCursor) []keysetPart {
+	values := map[string]interface{}{
+		"created_at":       cursor.CreatedAt,
+		"card_name":        cursor.CardName,
+		"set_code":         cursor.SetCode,
+		"collector_number": cursor.CollectorNumber,
+		"buying_price":     cursor.BuyingPrice,
+		"quantity":         cursor.Quantity,
+	}
+	if cursor.BoughtDate != nil {
+		values["bought_date"] = *cursor.BoughtDate
+	}
+	if cursor.SellDate != nil {
+		values["sell_date"] = *cursor.SellDate
+	}
+
+	var parts []keysetPart
+	for _, column := range sortColumns(key) {
+		parts = append(parts, keysetPart{
+			column:   column,
+			value:    values[column],
+			nullable: nullableSortColumns[column],
+			backward: cursor.Backward,
+		})
+	}
+	return append(parts, keysetPart{column: "id", value: cursor.ID})
+}
+
+// keysetCondition selects the rows that come after the cursor in a scan
+// ordered by the parts, ascending or descending:
+// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?).
+func keysetCondition(parts []keysetPart, ascending bool) (string, []interface{}) {
+	op := " < ?"
+	if ascending {
+		op = " > ?"
+	}
+
+	var alternatives []string
+	var args []interface{}
+	var prefix []string
+	var prefixArgs []interface{}
+	for _, part := range parts {
+		after, afterArgs := part.after(op)
+		alternatives = append(alternatives, "("+strings.Join(append(append([]string{}, prefix...), after), " AND ")+")")
+		args = append(append(args, prefixArgs...), afterArgs...)
+
+		equal, equalArgs := part.equal()
+		prefix = append(prefix, equal)
+		prefixArgs = append(prefixArgs, equalArgs...)
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// after compares a column with the cursor. NULLs sort last, so going forward
+// they come after every value, and going backward before every value.
+func (p keysetPart) after(op string) (string, []interface{}) {
+	if !p.nullable {
+		return p.column + op, []interface{}{p.value}
+	}
+	switch {
+	case p.value == nil && p.backward:
+		return p.column + " IS NOT NULL", nil
+	case p.value == nil:
+		return "1 = 0", nil
+	case p.backward:
+		return "(" + p.column + " IS NOT NULL AND " + p.column + op + ")", []interface{}{p.value}
+	default:
+		return "(" + p.column + " IS NULL OR " + p.column + op + ")", []interface{}{p.value}
+	}
+}
+
+func (p keysetPart) equal() (string, []interface{}) {
+	if p.value == nil {
+		return p.column + " IS NULL", nil
+	}
+	return p.column + " = ?", []interface{}{p.value}
+}
+
+func (r *cardRepository) FindAllByUserID(ctx context.Context, userID uint) ([]entity.Card, error) {
+	var cards []entity.Card
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *cardRepository) OwnedQuantities(ctx context.Context, userID uint, names []string) ([]repository.OwnedQuantity, error) {
+	var owned []repository.OwnedQuantity
+	if len(names) == 0 {
+		return owned, nil
+	}
+
+	err := dbFor(ctx, r.db).Model(&entity.Card{}).
+		Select("card_name, set_code, collector_number, SUM(quantity) AS quantity").
+		Where("user_id = ? AND sell_date IS NULL AND card_name IN ?", userID, names).
+		Group("card_name, set_code, collector_number").
+		Scan(&owned).Error
+	if err != nil {
+		return nil, err
+	}
+	return owned, nil
+}
+
+func (r *cardRepository) OwnedPrintings(ctx context.Context, userID uint) ([]repository.OwnedPrinting, error) {
+	var owned []repository.OwnedPrinting
+	err := dbFor(ctx, r.db).Model(&entity.Card{}).
+		Select("set_code, collector_number, foil, SUM(quantity) AS quantity").
+		Where("user_id = ? AND sell_date IS NULL AND set_code <> ''", userID).
+		Group("set_code, collector_number, foil").
+		Scan(&owned).Error
+	if err != nil {
+		return nil, err
+	}
+	return owned, nil
+}
+
+func (r *cardRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Unscoped().Where("user_id = ?", userID).Delete(&entity.Card{}).Error
+}
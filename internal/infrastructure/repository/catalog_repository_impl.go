@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const catalogBatchSize = 500
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) repository.CatalogRepository {
+	return &catalogRepository{db: db}
+}
+
+func (r *catalogRepository) UpsertSets(ctx context.Context, sets []entity.CatalogSet) error {
+	if len(sets) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		CreateInBatches(sets, catalogBatchSize).Error
+}
+
+func (r *catalogRepository) UpsertCards(ctx context.Context, cards []entity.CatalogCard, overwrite bool) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	conflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "set_code"}, {Name: "collector_number"}},
+		DoNothing: true,
+	}
+	if overwrite {
+		conflict = clause.OnConflict{
+			Columns:   []clause.Column{{Name: "set_code"}, {Name: "collector_number"}},
+			DoUpdates: clause.AssignmentColumns(append([]string{"name", "nonfoil", "foil"}, metadataColumns...)),
+		}
+	}
+
+	return dbFor(ctx, r.db).Clauses(conflict).CreateInBatches(cards, catalogBatchSize).Error
+}
+
+func (r *catalogRepository) FindSets(ctx context.Context) ([]entity.CatalogSet, error) {
+	var sets []entity.CatalogSet
+	err := dbFor(ctx, r.db).Order("released_at DESC, code").Find(&sets).Error
+	if err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+func (r *catalogRepository) FindSet(ctx context.Context, code string) (*entity.CatalogSet, error) {
+	var set entity.CatalogSet
+	err := dbFor(ctx, r.db).Where("code = ?", code).First(&set).Error
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (r *catalogRepository) FindCardsBySet(ctx context.Context, codes []string) ([]entity.CatalogCard, error) {
+	var cards []entity.CatalogCard
+	if len(codes) == 0 {
+		return cards, nil
+	}
+
+	err := dbFor(ctx, r.db).Where("set_code IN ?", codes).Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *catalogRepository) FindPrintings(ctx context.Context, cards []entity.Card) ([]entity.CatalogCard, error) {
+	var printings []entity.CatalogCard
+	seen := make(map[[2]string]bool)
+	var keys [][]interface{}
+	for _, card := range cards {
+		key := printingKey(card)
+		if key[0] == "" || key[1] == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, []interface{}{key[0], key[1]})
+	}
+	if len(keys) == 0 {
+		return printings, nil
+	}
+
+	err := dbFor(ctx, r.db).Where("(set_code, collector_number) IN ?", keys).Find(&printings).Error
+	if err != nil {
+		return nil, err
+	}
+	return printings, nil
+}
+
+// metadataColumns are the columns printings and cards share. Updates list
+// them by name so that empty values are written too.
+var metadataColumns = []string{"colors", "color_identity", "type_line", "mana_value", "rarity", "oracle_id"}
+
+func (r *catalogRepository) ApplyMetadata(ctx context.Context) (int64, error) {
+	var changed int64
+	var lastID uint
+	for {
+		var cards []entity.Card
+		err := dbFor(ctx, r.db).Unscoped().
+			Where("id > ? AND set_code <> '' AND collector_number <> ''", lastID).
+			Order("id").
+			Limit(catalogBatchSize).
+			Find(&cards).Error
+		if err != nil {
+			return changed, err
+		}
+		if len(cards) == 0 {
+			return changed, nil
+		}
+		lastID = cards[len(cards)-1].ID
+
+		printings, err := r.FindPrintings(ctx, cards)
+		if err != nil {
+			return changed, err
+		}
+		metadata := make(map[[2]string]entity.CardMetadata, len(printings))
+		for _, printing := range printings {
+			metadata[[2]string{printing.SetCode, printing.CollectorNumber}] = printing.Metadata()
+		}
+
+		for _, card := range cards {
+			found, ok := metadata[printingKey(card)]
+			if !ok || found == card.CardMetadata {
+				continue
+			}
+			err := dbFor(ctx, r.db).Unscoped().
+				Model(&entity.Card{}).
+				Where("id = ?", card.ID).
+				Select(metadataColumns).
+				UpdateColumns(entity.Card{CardMetadata: found}).Error
+			if err != nil {
+				return changed, err
+			}
+			changed++
+		}
+	}
+}
+
+// printingKey returns the catalog set code and collector number of a card.
+func printingKey(card entity.Card) [2]string {
+	return [2]string{strings.ToLower(strings.TrimSpace(card.SetCode)), strings.TrimSpace(card.CollectorNumber)}
+}
+
+func (r *catalogRepository) UpsertTranslations(ctx context.Context, translations []entity.CatalogTranslation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "set_code"}, {Name: "collector_number"}, {Name: "lang"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "image_url"}),
+		}).
+		CreateInBatches(translations, catalogBatchSize).Error
+}
+
+func (r *catalogRepository) FindTranslations(ctx context.Context, cards []entity.Card) ([]entity.CatalogTranslation, error) {
+	var translations []entity.CatalogTranslation
+	seen := make(map[[3]string]bool)
+	var keys [][]interface{}
+	for _, card := range cards {
+		key := [3]string{strings.ToLower(card.SetCode), card.CollectorNumber, card.Language}
+		if key[0] == "" || key[1] == "" || key[2] == "" || key[2] == entity.LanguageEnglish || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, []interface{}{key[0], key[1], key[2]})
+	}
+	if len(keys) == 0 {
+		return translations, nil
+	}
+
+	err := dbFor(ctx, r.db).Where("(set_code, collector_number, lang) IN ?", keys).Find(&translations).Error
+	if err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
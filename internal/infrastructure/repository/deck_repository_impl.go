@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type deckRepository struct {
+	db *gorm.DB
+}
+
+func NewDeckRepository(db *gorm.DB) repository.DeckRepository {
+	return &deckRepository{db: db}
+}
+
+func (r *deckRepository) Create(ctx context.Context, deck *entity.Deck) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Create(deck).Error
+}
+
+func (r *deckRepository) Update(ctx context.Context, deck *entity.Deck) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Save(deck).Error
+}
+
+func (r *deckRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	return dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Deck{}).Error
+}
+
+func (r *deckRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Deck, error) {
+	var deck entity.Deck
+	err := dbFor(ctx, r.db).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB {
+			return db.Order("card_name")
+		}).
+		Where("user_id = ?", userID).
+		First(&deck, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+func (r *deckRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Deck, error) {
+	var decks []entity.Deck
+	err := dbFor(ctx, r.db).
+		Preload("Entries").
+		Where("user_id = ?", userID).
+		Order("name").
+		Find(&decks).Error
+	if err != nil {
+		return nil, err
+	}
+	return decks, nil
+}
+
+func (r *deckRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	db := dbFor(ctx, r.db)
+	if err := db.Where("user_id = ?", userID).Delete(&entity.DeckEntry{}).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("user_id = ?", userID).Delete(&entity.Deck{}).Error
+}
+
+func (r *deckRepository) CreateEntry(ctx context.Context, entry *entity.DeckEntry) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Create(entry).Error
+}
+
+func (r *deckRepository) DeleteEntry(ctx context.Context, id uint, deckID uint, userID uint) error {
+	return dbFor(ctx, r.db).
+		Where("id = ? AND deck_id = ? AND user_id = ?", id, deckID, userID).
+		Delete(&entity.DeckEntry{}).Error
+}
+
+func (r *deckRepository) FindEntriesByCardNames(ctx context.Context, userID uint, names []string) ([]entity.DeckEntry, error) {
+	var entries []entity.DeckEntry
+	if len(names) == 0 {
+		return entries, nil
+	}
+
+	err := dbFor(ctx, r.db).
+		InnerJoins("Deck").
+		Where("deck_entries.user_id = ? AND deck_entries.card_name IN ?", userID, names).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loanRepository struct {
+	db *gorm.DB
+}
+
+func NewLoanRepository(db *gorm.DB) repository.LoanRepository {
+	return &loanRepository{db: db}
+}
+
+// Create locks the card row while it checks the copies still available, so
+// a loan and a trade of the same copies cannot both go through. Borrowed
+// copies have no card row to check.
+func (r *loanRepository) Create(ctx context.Context, loan *entity.Loan) error {
+	if loan.IsBorrowed() {
+		return dbFor(ctx, r.db).Create(loan).Error
+	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var card entity.Card
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", loan.UserID).
+			First(&card, loan.CardID).Error
+		if err != nil {
+			return err
+		}
+
+		lent, err := lentQuantity(tx, card.ID)
+		if err != nil {
+			return err
+		}
+		if card.Quantity-lent < loan.Quantity {
+			return repository.ErrLoanUnavailable
+		}
+		return tx.Create(loan).Error
+	})
+}
+
+// lentQuantity returns the copies of a card on outstanding loans.
+func lentQuantity(tx *gorm.DB, cardID uint) (int, error) {
+	var lent int
+	err := tx.Model(&entity.Loan{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("card_id = ? AND returned_at IS NULL", cardID).
+		Scan(&lent).Error
+	return lent, err
+}
+
+// checkLent locks a card row for the rest of the transaction and fails with
+// ErrCopiesLent if quantity copies would not cover its outstanding loans.
+// Loans lock the same row, so none can be created until the change commits.
+func checkLent(tx *gorm.DB, cardID uint, quantity int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&entity.Card{}, cardID).Error; err != nil {
+		return err
+	}
+	lent, err := lentQuantity(tx, cardID)
+	if err != nil {
+		return err
+	}
+	if quantity < lent {
+		return repository.ErrCopiesLent
+	}
+	return nil
+}
+
+func (r *loanRepository) Update(ctx context.Context, loan *entity.Loan) error {
+	return dbFor(ctx, r.db).Save(loan).Error
+}
+
+func (r *loanRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	result := dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Loan{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *loanRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Loan, error) {
+	var loan entity.Loan
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&loan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func (r *loanRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Loan, error) {
+	var loans []entity.Loan
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("lent_at DESC, id DESC").Find(&loans).Error
+	if err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+func (r *loanRepository) FindOutstanding(ctx context.Context, userID uint) ([]entity.Loan, error) {
+	var loans []entity.Loan
+	err := dbFor(ctx, r.db).
+		Where("user_id = ? AND returned_at IS NULL", userID).
+		Order("due_at IS NULL, due_at, lent_at, id").
+		Find(&loans).Error
+	if err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+func (r *loanRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.Loan{}).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type locationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) repository.LocationRepository {
+	return &locationRepository{db: db}
+}
+
+func (r *locationRepository) Create(ctx context.Context, location *entity.Location) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Create(location).Error
+}
+
+func (r *locationRepository) Update(ctx context.Context, location *entity.Location) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Save(location).Error
+}
+
+func (r *locationRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	return dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Location{}).Error
+}
+
+func (r *locationRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Location, error) {
+	var location entity.Location
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&location, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (r *locationRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Location, error) {
+	var locations []entity.Location
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("name").Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (r *locationRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	db := dbFor(ctx, r.db)
+	// Detach children first so the parent foreign key never blocks the delete
+	if err := db.Unscoped().Model(&entity.Location{}).Where("user_id = ?", userID).Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("user_id = ?", userID).Delete(&entity.Location{}).Error
+}
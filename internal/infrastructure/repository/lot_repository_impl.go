@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// lotOrder lists lots oldest first; lots without a date count as the oldest.
+const lotOrder = "acquired_at IS NULL DESC, acquired_at, id"
+
+type lotRepository struct {
+	db *gorm.DB
+}
+
+func NewLotRepository(db *gorm.DB) repository.LotRepository {
+	return &lotRepository{db: db}
+}
+
+func (r *lotRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.PurchaseLot, error) {
+	var lots []entity.PurchaseLot
+	err := dbFor(ctx, r.db).
+		Where("card_id = ? AND user_id = ?", cardID, userID).
+		Order(lotOrder).
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (r *lotRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.PurchaseLot, error) {
+	var lots []entity.PurchaseLot
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order(lotOrder).Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+func (r *lotRepository) SaveLots(ctx context.Context, card *entity.Card, lots []entity.PurchaseLot) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, card); err != nil {
+			return err
+		}
+		return saveLots(tx, lots)
+	})
+}
+
+func (r *lotRepository) RecordSale(ctx context.Context, card *entity.Card, sold *entity.Card, lots []entity.PurchaseLot, sale *entity.CardSale) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// A stack sold as a whole keeps no copies to cover its loans
+		held := card.Quantity
+		if sold == nil {
+			held = 0
+		}
+		if err := checkLent(tx, card.ID, held); err != nil {
+			return err
+		}
+		if err := updateVersioned(tx, card); err != nil {
+			return err
+		}
+
+		sale.SoldCardID = card.ID
+		if sold != nil {
+			if err := tx.Create(sold).Error; err != nil {
+				return err
+			}
+			sale.SoldCardID = sold.ID
+		}
+
+		if err := saveLots(tx, lots); err != nil {
+			return err
+		}
+		return tx.Create(sale).Error
+	})
+}
+
+// saveLots inserts new lots and writes the remaining copies of existing ones.
+func saveLots(tx *gorm.DB, lots []entity.PurchaseLot) error {
+	for i := range lots {
+		if err := tx.Save(&lots[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveLots hands quantity held copies of the from stack over to the to stack,
+// oldest lots first. A lot that moves as a whole is re-pointed; otherwise it
+// is split so both stacks keep the original cost and date.
+func moveLots(tx *gorm.DB, from, to uint, quantity int) error {
+	var lots []entity.PurchaseLot
+	if err := tx.Where("card_id = ? AND remaining > 0", from).Order(lotOrder).Find(&lots).Error; err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		if quantity == 0 {
+			break
+		}
+		if lot.Remaining == lot.Quantity && lot.Remaining <= quantity {
+			if err := tx.Model(&lot).Update("card_id", to).Error; err != nil {
+				return err
+			}
+			quantity -= lot.Remaining
+			continue
+		}
+
+		moved := lot.Remaining
+		if moved > quantity {
+			moved = quantity
+		}
+		err := tx.Model(&lot).Updates(map[string]interface{}{
+			"quantity":  lot.Quantity - moved,
+			"remaining": lot.Remaining - moved,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		part := lot
+		part.ID = 0
+		part.CardID = to
+		part.Quantity = moved
+		part.Remaining = moved
+		if err := tx.Create(&part).Error; err != nil {
+			return err
+		}
+		quantity -= moved
+	}
+	return nil
+}
+
+// consumeLots takes quantity held copies of a stack out of its lots when they
+// leave the collection other than by a sale. Like the lot reconciliation of
+// the use case, the oldest copies go first. Copies no lot covers need no
+// change.
+func consumeLots(tx *gorm.DB, cardID uint, quantity int) error {
+	var lots []entity.PurchaseLot
+	if err := tx.Where("card_id = ? AND remaining > 0", cardID).Order(lotOrder).Find(&lots).Error; err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		if quantity == 0 {
+			break
+		}
+		taken := lot.Remaining
+		if taken > quantity {
+			taken = quantity
+		}
+		if err := tx.Model(&lot).Update("remaining", lot.Remaining-taken).Error; err != nil {
+			return err
+		}
+		quantity -= taken
+	}
+	return nil
+}
+
+// priceFromLots sets the buying price of card to the average held cost of
+// its lots. Cards without held lots keep their price.
+func priceFromLots(tx *gorm.DB, card *entity.Card) error {
+	var totals struct {
+		Copies int
+		Cost   float64
+	}
+	err := tx.Model(&entity.PurchaseLot{}).
+		Select("SUM(remaining) AS copies, SUM(remaining * held_unit_cost) AS cost").
+		Where("card_id = ? AND remaining > 0", card.ID).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+	if totals.Copies > 0 {
+		card.BuyingPrice = math.Round(totals.Cost/float64(totals.Copies)*100) / 100
+	}
+	return nil
+}
+
+func (r *lotRepository) FindSalesByUserID(ctx context.Context, userID uint) ([]entity.CardSale, error) {
+	var sales []entity.CardSale
+	err := dbFor(ctx, r.db).
+		Preload("Allocations").
+		Where("user_id = ?", userID).
+		Order("sold_at, id").
+		Find(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+func (r *lotRepository) FindSalesByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardSale, error) {
+	var sales []entity.CardSale
+	err := dbFor(ctx, r.db).
+		Preload("Allocations").
+		Where("(card_id = ? OR sold_card_id = ?) AND user_id = ?", cardID, cardID, userID).
+		Order("sold_at, id").
+		Find(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+func (r *lotRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		sales := tx.Model(&entity.CardSale{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("sale_id IN (?)", sales).Delete(&entity.LotAllocation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.CardSale{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entity.PurchaseLot{}).Error
+	})
+}
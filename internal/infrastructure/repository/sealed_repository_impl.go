@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type sealedProductRepository struct {
+	db *gorm.DB
+}
+
+func NewSealedProductRepository(db *gorm.DB) repository.SealedProductRepository {
+	return &sealedProductRepository{db: db}
+}
+
+func (r *sealedProductRepository) Create(ctx context.Context, product *entity.SealedProduct) error {
+	return dbFor(ctx, r.db).Create(product).Error
+}
+
+func (r *sealedProductRepository) Update(ctx context.Context, product *entity.SealedProduct) error {
+	return dbFor(ctx, r.db).Save(product).Error
+}
+
+func (r *sealedProductRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	result := dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.SealedProduct{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *sealedProductRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.SealedProduct, error) {
+	var product entity.SealedProduct
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *sealedProductRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.SealedProduct, error) {
+	var products []entity.SealedProduct
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *sealedProductRepository) Open(ctx context.Context, product *entity.SealedProduct, cards []entity.Card) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Decrement in the database so two concurrent opens cannot take the
+		// same unit.
+		result := tx.Model(&entity.SealedProduct{}).
+			Where("id = ? AND user_id = ? AND quantity > 0", product.ID, product.UserID).
+			Updates(map[string]interface{}{
+				"quantity": gorm.Expr("quantity - 1"),
+				"opened":   gorm.Expr("opened + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNothingToOpen
+		}
+
+		for i := range cards {
+			if err := tx.Create(&cards[i]).Error; err != nil {
+				return err
+			}
+		}
+		return tx.First(product, product.ID).Error
+	})
+}
+
+func (r *sealedProductRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.SealedProduct{}).Error
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) repository.TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) Create(ctx context.Context, tag *entity.Tag) error {
+	return dbFor(ctx, r.db).Create(tag).Error
+}
+
+func (r *tagRepository) Update(ctx context.Context, tag *entity.Tag) error {
+	return dbFor(ctx, r.db).Save(tag).Error
+}
+
+func (r *tagRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Tag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("tag_id = ?", id).Delete(&entity.CardTag{}).Error
+	})
+}
+
+func (r *tagRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Tag, error) {
+	var tag entity.Tag
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&tag, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) FindCardTags(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CardTag, error) {
+	var cardTags []entity.CardTag
+	query := dbFor(ctx, r.db).
+		Joins("JOIN tags ON tags.id = card_tags.tag_id").
+		Where("tags.user_id = ?", userID)
+	if cardIDs != nil {
+		query = query.Where("card_tags.card_id IN ?", cardIDs)
+	}
+	if err := query.Order("card_tags.card_id, tags.name").Find(&cardTags).Error; err != nil {
+		return nil, err
+	}
+	return cardTags, nil
+}
+
+func (r *tagRepository) SetCardTags(ctx context.Context, cardID uint, tagIDs []uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("card_id = ?", cardID).Delete(&entity.CardTag{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		cardTags := make([]entity.CardTag, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			cardTags = append(cardTags, entity.CardTag{CardID: cardID, TagID: tagID})
+		}
+		return tx.Create(&cardTags).Error
+	})
+}
+
+func (r *tagRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		tags := tx.Model(&entity.Tag{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("tag_id IN (?)", tags).Delete(&entity.CardTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entity.Tag{}).Error
+	})
+}
+
+type customFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomFieldRepository(db *gorm.DB) repository.CustomFieldRepository {
+	return &customFieldRepository{db: db}
+}
+
+func (r *customFieldRepository) Create(ctx context.Context, field *entity.CustomField) error {
+	return dbFor(ctx, r.db).Create(field).Error
+}
+
+func (r *customFieldRepository) Update(ctx context.Context, field *entity.CustomField) error {
+	return dbFor(ctx, r.db).Save(field).Error
+}
+
+func (r *customFieldRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&entity.CustomField{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("field_id = ?", id).Delete(&entity.CustomFieldValue{}).Error
+	})
+}
+
+func (r *customFieldRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.CustomField, error) {
+	var field entity.CustomField
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&field, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *customFieldRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.CustomField, error) {
+	var fields []entity.CustomField
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("name").Find(&fields).Error
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (r *customFieldRepository) FindValues(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CustomFieldValue, error) {
+	var values []entity.CustomFieldValue
+	query := dbFor(ctx, r.db).
+		Joins("JOIN custom_fields ON custom_fields.id = custom_field_values.field_id").
+		Where("custom_fields.user_id = ?", userID)
+	if cardIDs != nil {
+		query = query.Where("custom_field_values.card_id IN ?", cardIDs)
+	}
+	if err := query.Order("custom_field_values.card_id, custom_fields.name").Find(&values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (r *customFieldRepository) SetCardValues(ctx context.Context, cardID uint, values []entity.CustomFieldValue) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("card_id = ?", cardID).Delete(&entity.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		for i := range values {
+			values[i].ID = 0
+			values[i].CardID = cardID
+		}
+		return tx.Create(&values).Error
+	})
+}
+
+func (r *customFieldRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		fields := tx.Model(&entity.CustomField{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("field_id IN (?)", fields).Delete(&entity.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entity.CustomField{}).Error
+	})
+}
+
+// copyTagsAndFields gives the card to the tags and custom field values of
+// the card from that it does not have yet.
+func copyTagsAndFields(tx *gorm.DB, from, to uint) error {
+	var cardTags []entity.CardTag
+	existingTags := tx.Model(&entity.CardTag{}).Select("tag_id").Where("card_id = ?", to)
+	if err := tx.Where("card_id = ? AND tag_id NOT IN (?)", from, existingTags).Find(&cardTags).Error; err != nil {
+		return err
+	}
+	for i := range cardTags {
+		cardTags[i].ID = 0
+		cardTags[i].CardID = to
+	}
+	if len(cardTags) > 0 {
+		if err := tx.Create(&cardTags).Error; err != nil {
+			return err
+		}
+	}
+
+	var values []entity.CustomFieldValue
+	existingFields := tx.Model(&entity.CustomFieldValue{}).Select("field_id").Where("card_id = ?", to)
+	if err := tx.Where("card_id = ? AND field_id NOT IN (?)", from, existingFields).Find(&values).Error; err != nil {
+		return err
+	}
+	for i := range values {
+		values[i].ID = 0
+		values[i].CardID = to
+	}
+	if len(values) > 0 {
+		return tx.Create(&values).Error
+	}
+	return nil
+}
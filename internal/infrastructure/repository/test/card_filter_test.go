@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestCardRepository_FindByUserIDSorts(t *testing.T) {
+	tests := []struct {
+		filter domainrepo.CardFilter
+		order  string
+	}{
+		{domainrepo.CardFilter{}, "ORDER BY created_at DESC, id DESC"},
+		{domainrepo.CardFilter{Sort: domainrepo.SortByName, Ascending: true}, "ORDER BY card_name ASC, id ASC"},
+		{domainrepo.CardFilter{Sort: domainrepo.SortBySet}, "ORDER BY set_code DESC, collector_number DESC, id DESC"},
+		{domainrepo.CardFilter{Sort: domainrepo.SortByBought, Ascending: true}, "ORDER BY bought_date IS NULL, bought_date ASC, id ASC"},
+		{domainrepo.CardFilter{Sort: "card_name; DROP TABLE cards"}, "ORDER BY created_at DESC, id DESC"},
+	}
+
+	for _, tt := range tests {
+		db, queries := newRecordingDB(t)
+		if _, _, err := repository.NewCardRepository(db).FindByUserID(context.Background(), 1, 1, 20, tt.filter); err != nil {
+			t.Fatalf("FindByUserID failed: %v", err)
+		}
+		list := (*queries)[len(*queries)-1].sql
+		if !strings.Contains(list, tt.order) {
+			t.Errorf("Sort %q: expected %q in %s", tt.filter.Sort, tt.order, list)
+		}
+	}
+}
+
+func TestCardRepository_FindByUserIDFilters(t *testing.T) {
+	db, queries := newRecordingDB(t)
+	sold := false
+	minPrice, maxPrice := 10.0, 100.0
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	filter := domainrepo.CardFilter{
+		Language:   "ja",
+		Color:      domainrepo.ColorMulticolor,
+		Rarity:     "rare",
+		Sold:       &sold,
+		MinPrice:   &minPrice,
+		MaxPrice:   &maxPrice,
+		BoughtFrom: &from,
+		BoughtTo:   &to,
+	}
+	if _, _, err := repository.NewCardRepository(db).FindByUserID(context.Background(), 1, 1, 20, filter); err != nil {
+		t.Fatalf("FindByUserID failed: %v", err)
+	}
+
+	count := (*queries)[0]
+	for _, condition := range []string{"LOWER(language) IN (?,?)", "LENGTH(colors) > 1", "rarity = ?", "sell_date IS NULL", "buying_price >= ?", "buying_price <= ?", "bought_date >= ?", "bought_date < ?"} {
+		if !strings.Contains(count.sql, condition) {
+			t.Errorf("Expected %q in %s", condition, count.sql)
+		}
+	}
+
+	// The upper date bound includes the whole last day.
+	if last := count.vars[len(count.vars)-1]; last != to.AddDate(0, 0, 1) {
+		t.Errorf("Expected exclusive bound of %v, got %v", to.AddDate(0, 0, 1), last)
+	}
+}
+
+func TestCardRepository_FindPageByUserIDUsesKeyset(t *testing.T) {
+	db, queries := newRecordingDB(t)
+	cursor := &domainrepo.CardCursor{ID: 42}
+	filter := domainrepo.CardFilter{Sort: domainrepo.SortByBought}
+
+	if _, err := repository.NewCardRepository(db).FindPageByUserID(context.Background(), 1, 21, filter, cursor); err != nil {
+		t.Fatalf("FindPageByUserID failed: %v", err)
+	}
+	if len(*queries) != 1 {
+		t.Fatalf("Expected a single query without a count, got %d", len(*queries))
+	}
+
+	// The cursor card has no bought date, so only later undated cards follow.
+	list := (*queries)[0].sql
+	for _, part := range []string{"((1 = 0) OR (bought_date IS NULL AND id < ?))", "ORDER BY bought_date IS NULL, bought_date DESC, id DESC", "LIMIT 21"} {
+		if !strings.Contains(list, part) {
+			t.Errorf("Expected %q in %s", part, list)
+		}
+	}
+	if strings.Contains(list, "OFFSET") {
+		t.Errorf("Expected no OFFSET in %s", list)
+	}
+
+	cursor.Backward = true
+	*queries = nil
+	repository.NewCardRepository(db).FindPageByUserID(context.Background(), 1, 21, filter, cursor)
+	if list := (*queries)[0].sql; !strings.Contains(list, "ORDER BY bought_date IS NULL DESC, bought_date ASC, id ASC") {
+		t.Errorf("Expected reversed order for a backward cursor, got %s", list)
+	}
+}
@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestCardImageRepository_CreateFillsMissingSourceURL(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	imageRepo := repository.NewCardImageRepository(db)
+
+	uploaded := &entity.CardImage{Hash: "abc", ContentType: "image/png"}
+	if err := imageRepo.Create(ctx, uploaded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The same file downloaded later records where it came from
+	downloaded := &entity.CardImage{Hash: "abc", SourceURL: "https://example.com/bolt.png", ContentType: "image/png"}
+	if err := imageRepo.Create(ctx, downloaded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if downloaded.ID != uploaded.ID {
+		t.Errorf("Expected the stored image to be reused, got ID %d", downloaded.ID)
+	}
+	found, err := imageRepo.FindBySourceURL(ctx, "https://example.com/bolt.png")
+	if err != nil || found.ID != uploaded.ID {
+		t.Fatalf("Expected the image by its source URL, got %+v (%v)", found, err)
+	}
+
+	// A known source URL is not replaced
+	other := &entity.CardImage{Hash: "abc", SourceURL: "https://example.com/other.png"}
+	imageRepo.Create(ctx, other)
+	if other.SourceURL != "https://example.com/bolt.png" {
+		t.Errorf("Expected the first source URL to be kept, got %q", other.SourceURL)
+	}
+}
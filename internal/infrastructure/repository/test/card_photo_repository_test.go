@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestCardRepository_DeleteRemovesPhotoRows(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	photoRepo := repository.NewCardPhotoRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+
+	var cards []*entity.Card
+	for _, name := range []string{"Black Lotus", "Mox Pearl", "Mox Sapphire"} {
+		card := &entity.Card{UserID: 1, CardName: name, Quantity: 1, Version: 1}
+		cardRepo.Create(ctx, card)
+		photoRepo.Create(ctx, &entity.CardPhoto{UserID: 1, CardID: card.ID, Side: entity.PhotoSideFront})
+		cards = append(cards, card)
+	}
+	loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: cards[2].ID, CardName: cards[2].CardName, Borrower: "Sam", Quantity: 1, LentAt: time.Now()})
+
+	photos, err := cardRepo.Delete(ctx, cards[0].ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(photos) != 1 || photos[0].CardID != cards[0].ID {
+		t.Errorf("Expected the Black Lotus photo to be returned, got %+v", photos)
+	}
+
+	batch, err := cardRepo.UpdateBatch(ctx, 1, nil, []entity.Card{*cards[1], *cards[2]})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(batch.Photos) != 1 || batch.Photos[0].CardID != cards[1].ID {
+		t.Errorf("Expected the Mox Pearl photo to be returned, got %+v", batch.Photos)
+	}
+	if !errors.Is(batch.Skipped[cards[2].ID], domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected the lent Mox Sapphire to be skipped, got %v", batch.Skipped)
+	}
+
+	// The skipped card keeps its photo
+	remaining, _ := photoRepo.FindByUserID(ctx, 1)
+	if len(remaining) != 1 || remaining[0].CardID != cards[2].ID {
+		t.Errorf("Expected only the Mox Sapphire photo to remain, got %+v", remaining)
+	}
+}
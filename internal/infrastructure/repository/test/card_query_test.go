@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+	"gorm.io/gorm"
+)
+
+type recordedQuery struct {
+	sql  string
+	vars []interface{}
+}
+
+// newRecordingDB returns a dry-run database that records the statements it
+// would have sent instead of running them. Dry runs keep the built SQL on the
+// statement, so it is reset after recording to let a query builder be reused
+// the way it is against a real database.
+func newRecordingDB(t *testing.T) (*gorm.DB, *[]recordedQuery) {
+	db := newBlockingDB(t)
+	var queries []recordedQuery
+	err := db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		queries = append(queries, recordedQuery{sql: tx.Statement.SQL.String(), vars: tx.Statement.Vars})
+		tx.Statement.SQL.Reset()
+		tx.Statement.Vars = nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to register callback: %v", err)
+	}
+	return db.Session(&gorm.Session{DryRun: true}), &queries
+}
+
+func TestCardRepository_FindByUserIDBindsQueryValues(t *testing.T) {
+	db, queries := newRecordingDB(t)
+	cardRepo := repository.NewCardRepository(db)
+
+	search, err := cardquery.Parse(`name:"50%_off!" or (set:"x' OR 1=1 --" -lang:ja) qty>=4`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, _, err := cardRepo.FindByUserID(context.Background(), 1, 1, 20, domainrepo.CardFilter{Query: search}); err != nil {
+		t.Fatalf("FindByUserID failed: %v", err)
+	}
+	if len(*queries) == 0 {
+		t.Fatal("Expected queries to be recorded")
+	}
+
+	count := (*queries)[0]
+	for _, value := range []string{"50%", "OR 1=1", "ja", "japanese"} {
+		if strings.Contains(count.sql, value) {
+			t.Errorf("Expected %q to be bound, found it in SQL: %s", value, count.sql)
+		}
+	}
+
+	want := []interface{}{uint(1), "%50!%!_off!!%", "x' or 1=1 --", "ja", "japanese", float64(4)}
+	if len(count.vars) != len(want) {
+		t.Fatalf("Expected vars %v, got %v", want, count.vars)
+	}
+	for i := range want {
+		if count.vars[i] != want[i] {
+			t.Errorf("Expected var %d to be %#v, got %#v", i, want[i], count.vars[i])
+		}
+	}
+}
+
+func TestCardRepository_FindByUserIDSearchesTagsAndFields(t *testing.T) {
+	db, queries := newRecordingDB(t)
+	cardRepo := repository.NewCardRepository(db)
+
+	search, err := cardquery.Parse(`tag:"For Cube" -cf.grade=9`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	// Custom fields are resolved by the caller before the query runs
+	grade := cardquery.Terms(search)[1]
+	grade.FieldID, grade.Kind, grade.Number = 7, "number", 9
+
+	if _, _, err := cardRepo.FindByUserID(context.Background(), 1, 1, 20, domainrepo.CardFilter{Query: search}); err != nil {
+		t.Fatalf("FindByUserID failed: %v", err)
+	}
+
+	count := (*queries)[0]
+	for _, condition := range []string{"EXISTS (SELECT 1 FROM card_tags", "NOT EXISTS (SELECT 1 FROM custom_field_values", "custom_field_values.number = ?"} {
+		if !strings.Contains(count.sql, condition) {
+			t.Errorf("Expected %q in %s", condition, count.sql)
+		}
+	}
+
+	want := []interface{}{uint(1), "for cube", uint(7), float64(9)}
+	if len(count.vars) != len(want) {
+		t.Fatalf("Expected vars %v, got %v", want, count.vars)
+	}
+	for i := range want {
+		if count.vars[i] != want[i] {
+			t.Errorf("Expected var %d to be %#v, got %#v", i, want[i], count.vars[i])
+		}
+	}
+}
+
+func TestCardRepository_FindByUserIDSearchesMetadata(t *testing.T) {
+	tests := []struct {
+		search    string
+		condition string
+		vars      []interface{}
+	}{
+		{"c:rg", "(rarity <> '' AND colors LIKE ? AND colors LIKE ?)", []interface{}{"%R%", "%G%"}},
+		{"c:c", "(rarity <> '' AND colors = ?)", []interface{}{""}},
+		{"id:wu", "(rarity <> '' AND color_identity NOT LIKE ? AND color_identity NOT LIKE ? AND color_identity NOT LIKE ?)", []interface{}{"%B%", "%R%", "%G%"}},
+		{"c>w", "(rarity <> '' AND colors <> ? AND colors LIKE ?)", []interface{}{"W", "%W%"}},
+		{"-c:m", "NOT (rarity <> '' AND LENGTH(colors) > 1)", nil},
+		{"t:goblin", "(type_line LIKE ? ESCAPE '!')", []interface{}{"%goblin%"}},
+		{"mv<=2", "(rarity <> '' AND mana_value <= ?)", []interface{}{float64(2)}},
+		{"r>=mythic", "(rarity IN (?,?,?))", []interface{}{"mythic", "special", "bonus"}},
+	}
+
+	for _, tt := range tests {
+		db, queries := newRecordingDB(t)
+		search, err := cardquery.Parse(tt.search)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.search, err)
+		}
+		if _, _, err := repository.NewCardRepository(db).FindByUserID(context.Background(), 1, 1, 20, domainrepo.CardFilter{Query: search}); err != nil {
+			t.Fatalf("FindByUserID failed: %v", err)
+		}
+
+		count := (*queries)[0]
+		if !strings.Contains(count.sql, tt.condition) {
+			t.Errorf("%s: expected %q in %s", tt.search, tt.condition, count.sql)
+		}
+		want := append([]interface{}{uint(1)}, tt.vars...)
+		if !reflect.DeepEqual(count.vars, want) {
+			t.Errorf("%s: expected vars %v, got %v", tt.search, want, count.vars)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/storage"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// blockingDriver is a database/sql driver whose queries never finish on their
+// own: they only return once the query context is done, which lets the tests
+// observe whether cancellation actually reaches the database layer.
+type blockingDriver struct{}
+
+func (blockingDriver) Open(name string) (driver.Conn, error) {
+	return blockingConn{}, nil
+}
+
+type blockingConn struct{}
+
+const blockingLimit = 5 * time.Second
+
+var errNotCancelled = errors.New("query was not cancelled")
+
+func (blockingConn) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(blockingLimit):
+		return errNotCancelled
+	}
+}
+
+func (c blockingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return nil, c.wait(ctx)
+}
+
+func (c blockingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, c.wait(ctx)
+}
+
+func (blockingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (blockingConn) Close() error {
+	return nil
+}
+
+func (blockingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func init() {
+	sql.Register("blocking", blockingDriver{})
+}
+
+func newBlockingDB(t *testing.T) *gorm.DB {
+	sqlDB, err := sql.Open("blocking", "")
+	if err != nil {
+		t.Fatalf("Failed to open blocking driver: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open gorm: %v", err)
+	}
+	return db
+}
+
+func TestCardRepository_CancelledContextAbortsQuery(t *testing.T) {
+	cardRepo := repository.NewCardRepository(newBlockingDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := cardRepo.FindByID(ctx, 1, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= blockingLimit {
+		t.Errorf("Expected query to abort promptly, took %v", elapsed)
+	}
+}
+
+func TestUserRepository_CancelledContextAbortsQuery(t *testing.T) {
+	userRepo := repository.NewUserRepository(newBlockingDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := userRepo.FindByUsername(ctx, "testuser"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCardUseCase_RequestTimeoutReachesDatabase(t *testing.T) {
+	db := newBlockingDB(t)
+	cardUseCase := usecase.NewCardUseCase(repository.NewCardRepository(db), repository.NewAuditRepository(db), repository.NewCatalogRepository(db), storage.NewLocalBlobStore(t.TempDir()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	search, err := cardquery.Parse("bolt")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	start := time.Now()
+	_, _, err = cardUseCase.ListCards(ctx, 1, 1, 20, domainrepo.CardFilter{Query: search})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= blockingLimit {
+		t.Errorf("Expected query to abort at the deadline, took %v", elapsed)
+	}
+}
@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestCardRepository_KeepsLentCopies(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	lotRepo := repository.NewLotRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+
+	card := &entity.Card{UserID: 1, CardName: "Brainstorm", Quantity: 4, Version: 1}
+	cardRepo.Create(ctx, card)
+	other := &entity.Card{UserID: 1, CardName: "Ponder", Quantity: 1, Version: 1}
+	cardRepo.Create(ctx, other)
+	if err := loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: card.ID, CardName: card.CardName, Borrower: "Sam", Quantity: 3, LentAt: time.Now()}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	card.Quantity = 2
+	if err := cardRepo.Update(ctx, card); !errors.Is(err, domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent lowering the quantity below 3, got %v", err)
+	}
+	card.Quantity = 4
+	if _, err := cardRepo.Delete(ctx, card.ID, 1); !errors.Is(err, domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent deleting a lent card, got %v", err)
+	}
+
+	lower := *card
+	lower.Quantity = 1
+	batch, err := cardRepo.UpdateBatch(ctx, 1, []entity.Card{lower}, []entity.Card{*other})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(batch.Skipped) != 1 || !errors.Is(batch.Skipped[card.ID], domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected only the lent card to be skipped, got %v", batch.Skipped)
+	}
+	batch, err = cardRepo.UpdateBatch(ctx, 1, nil, []entity.Card{*card})
+	if err != nil || !errors.Is(batch.Skipped[card.ID], domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected the lent card to be skipped, got %+v (%v)", batch, err)
+	}
+
+	// Selling the whole stack would sell the lent copies with it
+	soldAt := time.Now()
+	card.SellDate = &soldAt
+	sale := &entity.CardSale{UserID: 1, CardID: card.ID, Quantity: 4, UnitPrice: 5, SoldAt: soldAt}
+	if err := lotRepo.RecordSale(ctx, card, nil, nil, sale); !errors.Is(err, domainrepo.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent selling the lent copies, got %v", err)
+	}
+
+	stored, err := cardRepo.FindByID(ctx, card.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected the lent card to be kept, got %v", err)
+	}
+	if stored.Quantity != 4 || stored.SellDate != nil || stored.Version != 1 {
+		t.Errorf("Expected the lent card to be unchanged, got %+v", stored)
+	}
+	if _, err := cardRepo.FindByID(ctx, other.ID, 1); err == nil {
+		t.Error("Expected the card without loans to be deleted")
+	}
+}
+
+func TestLoanRepository_BorrowedNeedsNoCard(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	loanRepo := repository.NewLoanRepository(db)
+
+	borrowed := &entity.Loan{UserID: 1, Direction: entity.LoanBorrowed, CardName: "Force of Will", Borrower: "Kim", Quantity: 2, LentAt: time.Now()}
+	if err := loanRepo.Create(ctx, borrowed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lent := &entity.Loan{UserID: 1, CardName: "Force of Will", Borrower: "Kim", Quantity: 1, LentAt: time.Now()}
+	if err := loanRepo.Create(ctx, lent); err == nil {
+		t.Error("Expected error lending copies of a card row that does not exist, got nil")
+	}
+
+	// Loans stored before borrowing existed read as lent
+	db.Create(&entity.Loan{UserID: 1, CardID: 1, CardName: "Sol Ring", Borrower: "Sam", Quantity: 1, LentAt: time.Now()})
+	loans, _ := loanRepo.FindOutstanding(ctx, 1)
+	if len(loans) != 2 || !loans[0].IsBorrowed() && !loans[1].IsBorrowed() {
+		t.Fatalf("Expected the borrowed loan among 2 outstanding, got %+v", loans)
+	}
+	for _, loan := range loans {
+		if loan.CardName == "Sol Ring" && loan.Direction != entity.LoanLent {
+			t.Errorf("Expected an old loan to default to lent, got %q", loan.Direction)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+func TestLocationUseCase_MoveCardsIsAllOrNothing(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	locationUseCase := usecase.NewLocationUseCase(repository.NewLocationRepository(db), cardRepo, auditRepo, repository.NewTransactor(db))
+
+	box, err := locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, Kind: entity.LocationKindBox, Name: "Box 1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Lightning Bolt", Quantity: 4, Version: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Counterspell", Quantity: 2, Version: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 2, CardName: "Opt", Quantity: 1, Version: 1})
+
+	// The third card belongs to another user, so the first two stay put
+	_, err = locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{1, 2, 3}, LocationID: &box.ID, Quantity: 1})
+	if err == nil {
+		t.Fatal("Expected error for another user's card, got nil")
+	}
+
+	cards, _ := cardRepo.FindAllByUserID(ctx, 1)
+	if len(cards) != 2 {
+		t.Fatalf("Expected the split to be rolled back, got %d cards", len(cards))
+	}
+	for _, card := range cards {
+		if card.LocationID != nil || card.Version != 1 {
+			t.Errorf("Expected %s to be unchanged, got location %v version %d", card.CardName, card.LocationID, card.Version)
+		}
+	}
+	if events, _ := auditRepo.FindAllByUserID(ctx, 1); len(events) != 0 {
+		t.Errorf("Expected no history for the rolled back move, got %d events", len(events))
+	}
+
+	moved, err := locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{1, 2}, LocationID: &box.ID})
+	if err != nil || moved != 2 {
+		t.Fatalf("Expected 2 cards moved, got %d (%v)", moved, err)
+	}
+}
+
+func TestLocationUseCase_SplitKeepsLentCopies(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	locationUseCase := usecase.NewLocationUseCase(repository.NewLocationRepository(db), cardRepo, repository.NewAuditRepository(db), repository.NewTransactor(db))
+
+	box, _ := locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, Kind: entity.LocationKindBox, Name: "Box 1"})
+	card := &entity.Card{UserID: 1, CardName: "Lightning Bolt", Quantity: 4, Version: 1}
+	cardRepo.Create(ctx, card)
+	loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: card.ID, CardName: card.CardName, Borrower: "Sam", Quantity: 3, LentAt: time.Now()})
+
+	// The loan stays with the source row, which must keep 3 copies
+	_, err := locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{card.ID}, LocationID: &box.ID, Quantity: 2})
+	if !errors.Is(err, domainrepo.ErrCopiesLent) {
+		t.Fatalf("Expected ErrCopiesLent, got %v", err)
+	}
+	if cards, _ := cardRepo.FindAllByUserID(ctx, 1); len(cards) != 1 || cards[0].Quantity != 4 {
+		t.Errorf("Expected the card to stay whole, got %+v", cards)
+	}
+
+	if _, err := locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{card.ID}, LocationID: &box.ID, Quantity: 1}); err != nil {
+		t.Errorf("Expected the unlent copy to move, got %v", err)
+	}
+}
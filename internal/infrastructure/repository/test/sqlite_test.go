@@ -0,0 +1,26 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/database"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteDB opens a migrated SQLite database for tests that need real
+// transactions and row data rather than recorded SQL.
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(database.Models()...); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db
+}
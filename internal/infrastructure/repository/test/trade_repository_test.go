@@ -0,0 +1,105 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	domainrepo "github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestTradeRepository_AcceptChecksLoansAndConsumesLots(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+
+	card := &entity.Card{UserID: 1, CardName: "Force of Will", Quantity: 3, ForTrade: 3, BuyingPrice: 20, Version: 1}
+	cardRepo.Create(ctx, card)
+	older := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&entity.PurchaseLot{UserID: 1, CardID: card.ID, Quantity: 2, Remaining: 2, UnitCost: 10, HeldUnitCost: 10, AcquiredAt: &older})
+	db.Create(&entity.PurchaseLot{UserID: 1, CardID: card.ID, Quantity: 1, Remaining: 1, UnitCost: 40, HeldUnitCost: 40, AcquiredAt: &newer})
+
+	if err := loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: card.ID, CardName: card.CardName, Borrower: "Sam", Quantity: 1, LentAt: newer}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: card.ID, CardName: card.CardName, Borrower: "Kim", Quantity: 3, LentAt: newer}); !errors.Is(err, domainrepo.ErrLoanUnavailable) {
+		t.Errorf("Expected ErrLoanUnavailable for more copies than held, got %v", err)
+	}
+
+	propose := func(quantity int) *entity.TradeProposal {
+		proposal := &entity.TradeProposal{ProposerID: 1, RecipientID: 2, Status: entity.TradeStatusPending,
+			Lines: []entity.TradeLine{{FromUserID: 1, CardID: card.ID, CardName: card.CardName, Quantity: quantity, UnitValue: 30}}}
+		if err := tradeRepo.Create(ctx, proposal); err != nil {
+			t.Fatalf("Failed to create proposal: %v", err)
+		}
+		return proposal
+	}
+	transfers := func(proposal *entity.TradeProposal) []domainrepo.CardTransfer {
+		return []domainrepo.CardTransfer{{Line: proposal.Lines[0], ToUserID: 2}}
+	}
+
+	// One of the three copies is lent out
+	all := propose(3)
+	if _, err := tradeRepo.Accept(ctx, all.ID, transfers(all), newer); !errors.Is(err, domainrepo.ErrTradeUnavailable) {
+		t.Fatalf("Expected ErrTradeUnavailable for lent copies, got %v", err)
+	}
+	if found, _ := tradeRepo.FindByID(ctx, all.ID, 1); found.Status != entity.TradeStatusPending {
+		t.Errorf("Expected proposal to stay pending, got %s", found.Status)
+	}
+
+	two := propose(2)
+	results, err := tradeRepo.Accept(ctx, two.ID, transfers(two), newer)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The two oldest copies leave the sender's lots and cost basis
+	var lots []entity.PurchaseLot
+	db.Order("id").Find(&lots)
+	if lots[0].Remaining != 0 || lots[1].Remaining != 1 {
+		t.Errorf("Expected lots to hold 0 and 1 copies, got %d and %d", lots[0].Remaining, lots[1].Remaining)
+	}
+	source, _ := cardRepo.FindByID(ctx, card.ID, 1)
+	if source.Quantity != 1 || source.BuyingPrice != 40 {
+		t.Errorf("Expected 1 copy left at 40, got %d at %.2f", source.Quantity, source.BuyingPrice)
+	}
+	if created := results[0].Created; created.UserID != 2 || created.Quantity != 2 || created.BuyingPrice != 30 {
+		t.Errorf("Expected bob to get 2 copies at 30, got %+v", created)
+	}
+}
+
+func TestTradeRepository_AcceptMovesPhotosOfUsedUpRows(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
+	photoRepo := repository.NewCardPhotoRepository(db)
+
+	card := &entity.Card{UserID: 1, CardName: "Black Lotus", Quantity: 1, ForTrade: 1, Version: 1}
+	cardRepo.Create(ctx, card)
+	photoRepo.Create(ctx, &entity.CardPhoto{UserID: 1, CardID: card.ID, Side: entity.PhotoSideFront})
+
+	proposal := &entity.TradeProposal{ProposerID: 1, RecipientID: 2, Status: entity.TradeStatusPending,
+		Lines: []entity.TradeLine{{FromUserID: 1, CardID: card.ID, CardName: card.CardName, Quantity: 1, UnitValue: 9000}}}
+	tradeRepo.Create(ctx, proposal)
+
+	results, err := tradeRepo.Accept(ctx, proposal.ID, []domainrepo.CardTransfer{{Line: proposal.Lines[0], ToUserID: 2}}, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The result names the photo as it was, so its files can be moved
+	if photos := results[0].Photos; len(photos) != 1 || photos[0].UserID != 1 {
+		t.Fatalf("Expected the moved photo as it was before, got %+v", photos)
+	}
+	photos, _ := photoRepo.FindByCardID(ctx, results[0].Created.ID, 2)
+	if len(photos) != 1 {
+		t.Errorf("Expected the photo on the received row, got %+v", photos)
+	}
+}
@@ -0,0 +1,95 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestTransactor_RollsBackRepositoryCalls(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	cardRepo := repository.NewCardRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Sol Ring", Quantity: 1, Version: 1})
+	auditRepo.Create(ctx, &entity.AuditEvent{UserID: 1, CardID: 1, Action: entity.AuditActionCreate, Source: entity.AuditSourceWeb})
+
+	errFailed := errors.New("failed")
+	err := repository.NewTransactor(db).Transaction(ctx, func(ctx context.Context) error {
+		if err := cardRepo.DeleteByUserID(ctx, 1); err != nil {
+			return err
+		}
+		if err := auditRepo.DeleteByUserID(ctx, 1); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected the function's error, got %v", err)
+	}
+
+	if cards, _ := cardRepo.FindAllByUserID(ctx, 1); len(cards) != 1 {
+		t.Errorf("Expected card delete to be rolled back, got %d cards", len(cards))
+	}
+	if events, _ := auditRepo.FindAllByUserID(ctx, 1); len(events) != 1 {
+		t.Errorf("Expected history delete to be rolled back, got %d events", len(events))
+	}
+}
+
+func TestTradeRepository_DetachUserKeepsOtherPartyHistory(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
+
+	userRepo.Create(ctx, &entity.User{Username: "alice", Password: "hash"})
+	userRepo.Create(ctx, &entity.User{Username: "bob", Password: "hash"})
+	accepted := &entity.TradeProposal{ProposerID: 1, RecipientID: 2, Status: entity.TradeStatusAccepted, Message: "Deal?",
+		Lines: []entity.TradeLine{{FromUserID: 1, CardID: 1, CardName: "Sol Ring", Quantity: 1, UnitValue: 80}}}
+	pending := &entity.TradeProposal{ProposerID: 2, RecipientID: 1, Status: entity.TradeStatusPending, Message: "Want my Opt?"}
+	tradeRepo.Create(ctx, accepted)
+	tradeRepo.Create(ctx, pending)
+
+	now := time.Now()
+	err := repository.NewTransactor(db).Transaction(ctx, func(ctx context.Context) error {
+		if err := tradeRepo.DetachUser(ctx, 1, now); err != nil {
+			return err
+		}
+		return userRepo.Anonymize(ctx, 1, now)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := userRepo.FindByUsername(ctx, "alice"); err == nil {
+		t.Error("Expected the purged username to be gone")
+	}
+	if users, _ := userRepo.FindAll(ctx); len(users) != 1 || users[0].Username != "bob" {
+		t.Errorf("Expected only bob to be listed, got %+v", users)
+	}
+
+	trades, err := tradeRepo.FindByUserID(ctx, 2)
+	if err != nil || len(trades) != 2 {
+		t.Fatalf("Expected bob to keep 2 trades, got %d (%v)", len(trades), err)
+	}
+	for _, trade := range trades {
+		switch trade.ID {
+		case accepted.ID:
+			if trade.Status != entity.TradeStatusAccepted || trade.Message != "" || len(trade.Lines) != 1 {
+				t.Errorf("Expected accepted trade kept with its lines and no message, got %+v", trade)
+			}
+			if trade.Proposer.Username != entity.DeletedUsername(1) {
+				t.Errorf("Expected purged proposer to be shown as %s, got %q", entity.DeletedUsername(1), trade.Proposer.Username)
+			}
+		case pending.ID:
+			if trade.Status != entity.TradeStatusCancelled || trade.ResolvedAt == nil {
+				t.Errorf("Expected pending trade to be cancelled, got %s", trade.Status)
+			}
+		}
+	}
+}
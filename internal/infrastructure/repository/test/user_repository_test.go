@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/repository"
+)
+
+func TestUserRepository_SettersWriteOneColumn(t *testing.T) {
+	db := newSQLiteDB(t)
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+
+	userRepo.Create(ctx, &entity.User{Username: "alice", Password: "hash", Currency: "USD", CostMethod: entity.CostMethodFIFO})
+
+	// Each request writes its own setting after reading the same user
+	deleteAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := userRepo.SetDeleteAfter(ctx, 1, &deleteAfter); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := userRepo.SetCardListView(ctx, 1, "sort=name"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := userRepo.SetCurrency(ctx, 1, "EUR"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	user, err := userRepo.FindByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.DeleteAfter == nil || !user.DeleteAfter.Equal(deleteAfter) {
+		t.Errorf("Expected delete after %v, got %v", deleteAfter, user.DeleteAfter)
+	}
+	if user.CardListView != "sort=name" || user.Currency != "EUR" {
+		t.Errorf("Expected view and currency to be kept, got %q and %q", user.CardListView, user.Currency)
+	}
+	if user.Username != "alice" || user.Password != "hash" || user.CostMethod != entity.CostMethodFIFO {
+		t.Errorf("Expected other columns to be untouched, got %+v", user)
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tradeRepository struct {
+	db *gorm.DB
+}
+
+func NewTradeRepository(db *gorm.DB) repository.TradeRepository {
+	return &tradeRepository{db: db}
+}
+
+func (r *tradeRepository) Create(ctx context.Context, proposal *entity.TradeProposal) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if proposal.ParentID != nil {
+			if err := resolvePending(tx, *proposal.ParentID, entity.TradeStatusCountered, time.Now()); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Proposer", "Recipient", "Parent").Create(proposal).Error
+	})
+}
+
+func (r *tradeRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.TradeProposal, error) {
+	var proposal entity.TradeProposal
+	err := dbFor(ctx, r.db).
+		Preload("Lines").
+		Preload("Proposer", unscoped).
+		Preload("Recipient", unscoped).
+		Where("proposer_id = ? OR recipient_id = ?", userID, userID).
+		First(&proposal, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+func (r *tradeRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.TradeProposal, error) {
+	var proposals []entity.TradeProposal
+	err := dbFor(ctx, r.db).
+		Preload("Lines").
+		Preload("Proposer", unscoped).
+		Preload("Recipient", unscoped).
+		Where("proposer_id = ? OR recipient_id = ?", userID, userID).
+		Order("created_at DESC, id DESC").
+		Find(&proposals).Error
+	if err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// unscoped lets preloads include the anonymized rows of purged users, so their
+// past proposals still name a counterparty.
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+func (r *tradeRepository) Resolve(ctx context.Context, id uint, status entity.TradeStatus, at time.Time) error {
+	return resolvePending(dbFor(ctx, r.db), id, status, at)
+}
+
+// resolvePending moves a proposal out of the pending state, failing with
+// ErrProposalNotPending if another request got there first.
+func resolvePending(db *gorm.DB, id uint, status entity.TradeStatus, at time.Time) error {
+	result := db.Model(&entity.TradeProposal{}).
+		Where("id = ? AND status = ?", id, entity.TradeStatusPending).
+		Updates(map[string]interface{}{"status": status, "resolved_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrProposalNotPending
+	}
+	return nil
+}
+
+func (r *tradeRepository) Accept(ctx context.Context, id uint, transfers []repository.CardTransfer, at time.Time) ([]repository.CardTransferResult, error) {
+	var results []repository.CardTransferResult
+
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := resolvePending(tx, id, entity.TradeStatusAccepted, at); err != nil {
+			return err
+		}
+
+		for _, transfer := range transfers {
+			result, err := transferCards(tx, transfer, at)
+			if err != nil {
+				return err
+			}
+			results = append(results, *result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// transferCards takes the line's copies out of the source row, locking it for
+// the rest of the transaction, and gives them to the receiving user as a new
+// row valued at the line's unit value. Lent copies cannot be traded. When the
+// source row is used up, its photos move to the new row.
+func transferCards(tx *gorm.DB, transfer repository.CardTransfer, at time.Time) (*repository.CardTransferResult, error) {
+	line := transfer.Line
+
+	var source entity.Card
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", line.FromUserID).
+		First(&source, line.CardID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrTradeUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Loans lock the same row, so none can be created until this commits
+	lent, err := lentQuantity(tx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	if source.ForTrade < line.Quantity || source.Quantity-lent < line.Quantity {
+		return nil, repository.ErrTradeUnavailable
+	}
+
+	result := &repository.CardTransferResult{Before: source}
+
+	// The traded copies leave the sender's cost basis
+	if err := consumeLots(tx, source.ID, line.Quantity); err != nil {
+		return nil, err
+	}
+
+	source.Quantity -= line.Quantity
+	source.ForTrade -= line.Quantity
+	if source.Quantity == 0 {
+		err = tx.Delete(&source).Error
+	} else if err = priceFromLots(tx, &source); err == nil {
+		err = updateVersioned(tx, &source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.After = source
+
+	boughtDate := at
+	result.Created = entity.Card{
+		UserID:          transfer.ToUserID,
+		CardName:        source.CardName,
+		CardImageURL:    source.CardImageURL,
+		ImageHash:       source.ImageHash,
+		ImageSourceURL:  source.ImageSourceURL,
+		SetCode:         source.SetCode,
+		CollectorNumber: source.CollectorNumber,
+		Language:        source.Language,
+		Foil:            source.Foil,
+		Quantity:        line.Quantity,
+		BuyingPrice:     line.UnitValue,
+		BoughtDate:      &boughtDate,
+		Version:         1,
+		CardMetadata:    source.CardMetadata,
+	}
+	if err := tx.Create(&result.Created).Error; err != nil {
+		return nil, err
+	}
+
+	if source.Quantity == 0 {
+		if err := tx.Where("card_id = ?", source.ID).Order("id").Find(&result.Photos).Error; err != nil {
+			return nil, err
+		}
+		if len(result.Photos) > 0 {
+			err := tx.Model(&entity.CardPhoto{}).
+				Where("card_id = ?", source.ID).
+				Updates(map[string]interface{}{"card_id": result.Created.ID, "user_id": transfer.ToUserID}).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// DetachUser leaves the proposals of a purged user in place for the other
+// party: pending ones are cancelled and the messages the user wrote are
+// cleared.
+func (r *tradeRepository) DetachUser(ctx context.Context, userID uint, at time.Time) error {
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.TradeProposal{}).
+			Where("(proposer_id = ? OR recipient_id = ?) AND status = ?", userID, userID, entity.TradeStatusPending).
+			Updates(map[string]interface{}{"status": entity.TradeStatusCancelled, "resolved_at": at}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.TradeProposal{}).Where("proposer_id = ?", userID).Update("message", "").Error
+	})
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
 	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
 	"gorm.io/gorm"
@@ -14,24 +17,74 @@ func NewUserRepository(db *gorm.DB) repository.UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *entity.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
+	return dbFor(ctx, r.db).Create(user).Error
 }
 
-func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := dbFor(ctx, r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByID(id uint) (*entity.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 	var user entity.User
-	err := r.db.First(&user, id).Error
+	err := dbFor(ctx, r.db).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (r *userRepository) FindAll(ctx context.Context) ([]entity.User, error) {
+	var users []entity.User
+	err := dbFor(ctx, r.db).Order("username").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) SetDeleteAfter(ctx context.Context, id uint, deleteAfter *time.Time) error {
+	return r.setColumn(ctx, id, "delete_after", deleteAfter)
+}
+
+func (r *userRepository) SetCardListView(ctx context.Context, id uint, view string) error {
+	return r.setColumn(ctx, id, "card_list_view", view)
+}
+
+func (r *userRepository) SetCostMethod(ctx context.Context, id uint, method entity.CostMethod) error {
+	return r.setColumn(ctx, id, "cost_method", method)
+}
+
+func (r *userRepository) SetCurrency(ctx context.Context, id uint, currency string) error {
+	return r.setColumn(ctx, id, "currency", currency)
+}
+
+func (r *userRepository) setColumn(ctx context.Context, id uint, column string, value interface{}) error {
+	return dbFor(ctx, r.db).Model(&entity.User{}).Where("id = ?", id).Update(column, value).Error
+}
+
+func (r *userRepository) Anonymize(ctx context.Context, id uint, at time.Time) error {
+	return dbFor(ctx, r.db).Unscoped().Model(&entity.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"username":       entity.DeletedUsername(id),
+		"password":       "",
+		"delete_after":   nil,
+		"card_list_view": "",
+		"deleted_at":     at,
+	}).Error
+}
+
+func (r *userRepository) FindDueForDeletion(ctx context.Context, before time.Time) ([]entity.User, error) {
+	var users []entity.User
+	err := dbFor(ctx, r.db).
+		Where("delete_after IS NOT NULL AND delete_after <= ?", before).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
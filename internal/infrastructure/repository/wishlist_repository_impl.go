@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) repository.WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) Create(ctx context.Context, item *entity.WishlistItem) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Create(item).Error
+}
+
+func (r *wishlistRepository) Update(ctx context.Context, item *entity.WishlistItem) error {
+	return dbFor(ctx, r.db).Omit(clause.Associations).Save(item).Error
+}
+
+func (r *wishlistRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	return dbFor(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.WishlistItem{}).Error
+}
+
+func (r *wishlistRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.WishlistItem, error) {
+	var item entity.WishlistItem
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).First(&item, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *wishlistRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.WishlistItem, error) {
+	var items []entity.WishlistItem
+	err := dbFor(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("priority DESC, card_name").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *wishlistRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFor(ctx, r.db).Unscoped().Where("user_id = ?", userID).Delete(&entity.WishlistItem{}).Error
+}
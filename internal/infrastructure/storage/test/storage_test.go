@@ -0,0 +1,71 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/infrastructure/storage"
+)
+
+func readBlob(t *testing.T, store repository.BlobStore, key string) string {
+	body, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Expected blob %s, got %v", key, err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	return string(data)
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocalBlobStore(t.TempDir())
+
+	if err := store.Put(ctx, "cards/ab/abcd/small", strings.NewReader("first")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := readBlob(t, store, "cards/ab/abcd/small"); got != "first" {
+		t.Errorf("Expected first, got %q", got)
+	}
+
+	store.Put(ctx, "cards/ab/abcd/small", strings.NewReader("second"))
+	if got := readBlob(t, store, "cards/ab/abcd/small"); got != "second" {
+		t.Errorf("Expected the blob to be replaced, got %q", got)
+	}
+
+	if err := store.Delete(ctx, "cards/ab/abcd/small"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "cards/ab/abcd/small"); !errors.Is(err, repository.ErrBlobNotFound) {
+		t.Errorf("Expected ErrBlobNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "cards/ab/abcd/small"); err != nil {
+		t.Errorf("Expected deleting a missing blob to succeed, got %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "cards/../../outside", "cards//small", "cards\\small"} {
+		if err := store.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, repository.ErrInvalidBlobKey) {
+			t.Errorf("Expected key %q to be rejected, got %v", key, err)
+		}
+	}
+}
+
+func TestNewPublicHTTPClient_RejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	client := storage.NewPublicHTTPClient(5 * time.Second)
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("Expected a request to a loopback address to fail")
+	}
+}
@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DeletionGracePeriod is how long a deletion request can still be cancelled
+// before the account and its data are purged.
+const DeletionGracePeriod = 14 * 24 * time.Hour
+
+type AccountUseCase struct {
+	userRepo     repository.UserRepository
+	cardRepo     repository.CardRepository
+	auditRepo    repository.AuditRepository
+	deckRepo     repository.DeckRepository
+	locationRepo repository.LocationRepository
+	wishlistRepo repository.WishlistRepository
+	tradeRepo    repository.TradeRepository
+	lotRepo      repository.LotRepository
+	tagRepo      repository.TagRepository
+	fieldRepo    repository.CustomFieldRepository
+	loanRepo     repository.LoanRepository
+	sealedRepo   repository.SealedProductRepository
+	photoRepo    repository.CardPhotoRepository
+	blobStore    repository.BlobStore
+	transactor   repository.Transactor
+}
+
+func NewAccountUseCase(userRepo repository.UserRepository, cardRepo repository.CardRepository, auditRepo repository.AuditRepository, deckRepo repository.DeckRepository, locationRepo repository.LocationRepository, wishlistRepo repository.WishlistRepository, tradeRepo repository.TradeRepository, lotRepo repository.LotRepository, tagRepo repository.TagRepository, fieldRepo repository.CustomFieldRepository, loanRepo repository.LoanRepository, sealedRepo repository.SealedProductRepository, photoRepo repository.CardPhotoRepository, blobStore repository.BlobStore, transactor repository.Transactor) *AccountUseCase {
+	return &AccountUseCase{
+		userRepo:     userRepo,
+		cardRepo:     cardRepo,
+		auditRepo:    auditRepo,
+		deckRepo:     deckRepo,
+		locationRepo: locationRepo,
+		wishlistRepo: wishlistRepo,
+		tradeRepo:    tradeRepo,
+		lotRepo:      lotRepo,
+		tagRepo:      tagRepo,
+		fieldRepo:    fieldRepo,
+		loanRepo:     loanRepo,
+		sealedRepo:   sealedRepo,
+		photoRepo:    photoRepo,
+		blobStore:    blobStore,
+		transactor:   transactor,
+	}
+}
+
+// AccountProfile is the exported view of a user; the password hash is never
+// included.
+type AccountProfile struct {
+	ID          uint       `json:"id"`
+	Username    string     `json:"username"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeleteAfter *time.Time `json:"delete_after,omitempty"`
+}
+
+func (uc *AccountUseCase) GetAccount(ctx context.Context, userID uint) (*entity.User, error) {
+	return uc.userRepo.FindByID(ctx, userID)
+}
+
+// WriteExport writes a zip archive with everything stored for the user.
+func (uc *AccountUseCase) WriteExport(ctx context.Context, userID uint, w io.Writer) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	history, err := uc.auditRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	decks, err := uc.deckRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	locations, err := uc.locationRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := uc.wishlistRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	trades, err := uc.tradeRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	lots, err := uc.lotRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	sales, err := uc.lotRepo.FindSalesByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tags, err := uc.tagRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	cardTags, err := uc.tagRepo.FindCardTags(ctx, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	fields, err := uc.fieldRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	fieldValues, err := uc.fieldRepo.FindValues(ctx, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	loans, err := uc.loanRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := uc.sealedRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	photos, err := uc.photoRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	profile := AccountProfile{
+		ID:          user.ID,
+		Username:    user.Username,
+		CreatedAt:   user.CreatedAt,
+		DeleteAfter: user.DeleteAfter,
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"cards.json", cards},
+		{"history.json", history},
+		{"decks.json", decks},
+		{"locations.json", locations},
+		{"wishlist.json", wishlist},
+		{"trades.json", trades},
+		{"lots.json", lots},
+		{"sales.json", sales},
+		{"tags.json", tags},
+		{"card_tags.json", cardTags},
+		{"custom_fields.json", fields},
+		{"custom_field_values.json", fieldValues},
+		{"loans.json", loans},
+		{"sealed_products.json", sealed},
+		{"photos.json", photos},
+	}
+	for _, file := range files {
+		if err := writeJSONFile(archive, file.name, file.data); err != nil {
+			return err
+		}
+	}
+
+	for i := range photos {
+		if err := uc.writePhotoFile(ctx, archive, &photos[i]); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// writePhotoFile adds the original file of a photo to the archive as
+// photos/<id>.<format>. A photo whose file is missing is only listed in
+// photos.json.
+func (uc *AccountUseCase) writePhotoFile(ctx context.Context, archive *zip.Writer, photo *entity.CardPhoto) error {
+	body, err := uc.blobStore.Get(ctx, photoBlobKey(photo, entity.ImageSizeOriginal))
+	if errors.Is(err, repository.ErrBlobNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read photo %d: %w", photo.ID, err)
+	}
+	defer body.Close()
+
+	f, err := archive.Create(fmt.Sprintf("photos/%d.%s", photo.ID, strings.TrimPrefix(photo.ContentType, "image/")))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, body)
+	return err
+}
+
+func writeJSONFile(archive *zip.Writer, name string, data interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// RequestDeletion schedules the account for deletion after the grace period.
+// The user must confirm with their username and current password.
+func (uc *AccountUseCase) RequestDeletion(ctx context.Context, userID uint, username, password string) (*time.Time, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if username != user.Username {
+		return nil, errors.New("username does not match")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, errors.New("incorrect password")
+	}
+
+	deleteAfter := time.Now().Add(DeletionGracePeriod)
+	if err := uc.userRepo.SetDeleteAfter(ctx, userID, &deleteAfter); err != nil {
+		return nil, err
+	}
+
+	return &deleteAfter, nil
+}
+
+func (uc *AccountUseCase) CancelDeletion(ctx context.Context, userID uint) error {
+	if _, err := uc.userRepo.FindByID(ctx, userID); err != nil {
+		return err
+	}
+	return uc.userRepo.SetDeleteAfter(ctx, userID, nil)
+}
+
+// SaveCardListView remembers the card list sort and filters of the user. The
+// view is only written when it changed, and only the view column is written.
+func (uc *AccountUseCase) SaveCardListView(ctx context.Context, userID uint, view string) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CardListView == view {
+		return nil
+	}
+
+	return uc.userRepo.SetCardListView(ctx, userID, view)
+}
+
+// SetCostMethod sets how future sales of the user pick the purchase lots
+// they consume. Sales already recorded keep their cost basis.
+func (uc *AccountUseCase) SetCostMethod(ctx context.Context, userID uint, method entity.CostMethod) error {
+	if !validCostMethod(method) {
+		return errors.New("unknown cost method")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CostMethod == method {
+		return nil
+	}
+
+	return uc.userRepo.SetCostMethod(ctx, userID, method)
+}
+
+// SetCurrency sets the currency the user records prices in. Stored amounts
+// are not converted.
+func (uc *AccountUseCase) SetCurrency(ctx context.Context, userID uint, currency string) error {
+	if !validCurrency(currency) {
+		return errors.New("unsupported currency")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Currency == currency {
+		return nil
+	}
+
+	return uc.userRepo.SetCurrency(ctx, userID, currency)
+}
+
+func validCurrency(currency string) bool {
+	for _, c := range entity.Currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeDueAccounts permanently deletes every account whose grace period has
+// ended, together with all of its data.
+func (uc *AccountUseCase) PurgeDueAccounts(ctx context.Context, now time.Time) (int, error) {
+	users, err := uc.userRepo.FindDueForDeletion(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		if err := uc.purgeAccount(ctx, user.ID, now); err != nil {
+			return purged, fmt.Errorf("failed to purge user %d: %w", user.ID, err)
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purgeAccount deletes the rows of an account in one transaction, so a
+// failure leaves the account whole and due for the next run. The user row is
+// anonymized rather than deleted because other users' trades refer to it.
+// Photo files are removed once the rows are gone.
+func (uc *AccountUseCase) purgeAccount(ctx context.Context, userID uint, now time.Time) error {
+	photos, err := uc.photoRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := uc.lotRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.tagRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.fieldRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.loanRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.sealedRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.photoRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.cardRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.auditRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.deckRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.wishlistRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.tradeRepo.DetachUser(ctx, userID, now); err != nil {
+			return err
+		}
+		// Locations go after the cards that reference them
+		if err := uc.locationRepo.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		return uc.userRepo.Anonymize(ctx, userID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	return deletePhotoFiles(ctx, uc.blobStore, photos)
+}
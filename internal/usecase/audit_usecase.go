@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type AuditUseCase struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditUseCase(auditRepo repository.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{auditRepo: auditRepo}
+}
+
+// CardHistory returns every recorded event for a card, newest first. It keeps
+// working after the card itself has been deleted.
+func (uc *AuditUseCase) CardHistory(ctx context.Context, cardID uint, userID uint) ([]entity.AuditEvent, error) {
+	return uc.auditRepo.FindByCardID(ctx, cardID, userID)
+}
+
+// ActivityFeed returns the collection-wide event feed for a user, newest first.
+func (uc *AuditUseCase) ActivityFeed(ctx context.Context, userID uint, page, pageSize int) ([]entity.AuditEvent, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	return uc.auditRepo.FindByUserID(ctx, userID, page, pageSize)
+}
+
+// DiffCards lists the audited fields that differ between two card states.
+// A nil card is treated as empty, so creations and deletions show every
+// populated field.
+func DiffCards(before, after *entity.Card) []entity.AuditChange {
+	var changes []entity.AuditChange
+	for _, field := range auditedFields {
+		var beforeValue, afterValue string
+		if before != nil {
+			beforeValue = field.value(before)
+		}
+		if after != nil {
+			afterValue = field.value(after)
+		}
+		if beforeValue != afterValue {
+			changes = append(changes, entity.AuditChange{
+				Field:  field.name,
+				Before: beforeValue,
+				After:  afterValue,
+			})
+		}
+	}
+	return changes
+}
+
+// auditedFields lists the card fields tracked by the audit log, in display
+// order.
+var auditedFields = []struct {
+	name  string
+	value func(card *entity.Card) string
+}{
+	{"card_name", func(c *entity.Card) string { return c.CardName }},
+	{"card_image_url", func(c *entity.Card) string { return c.CardImageURL }},
+	{"set_code", func(c *entity.Card) string { return c.SetCode }},
+	{"collector_number", func(c *entity.Card) string { return c.CollectorNumber }},
+	{"language", func(c *entity.Card) string { return c.Language }},
+	{"foil", func(c *entity.Card) string { return fmt.Sprintf("%t", c.Foil) }},
+	{"quantity", func(c *entity.Card) string { return fmt.Sprintf("%d", c.Quantity) }},
+	{"for_trade", func(c *entity.Card) string { return fmt.Sprintf("%d", c.ForTrade) }},
+	{"buying_price", func(c *entity.Card) string { return fmt.Sprintf("%.2f", c.BuyingPrice) }},
+	{"bought_date", func(c *entity.Card) string { return dateString(c.BoughtDate) }},
+	{"sell_date", func(c *entity.Card) string { return dateString(c.SellDate) }},
+	{"location_id", func(c *entity.Card) string { return idString(c.LocationID) }},
+}
+
+func dateString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func idString(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *id)
+}
+
+// recordCardEvent appends an audit event for a card change. Failures are
+// logged rather than returned so that a broken audit table never blocks the
+// collection change itself.
+func recordCardEvent(ctx context.Context, auditRepo repository.AuditRepository, action entity.AuditAction, source entity.AuditSource, actorID uint, before, after *entity.Card) {
+	if auditRepo == nil {
+		return
+	}
+
+	card := after
+	if card == nil {
+		card = before
+	}
+
+	changes := DiffCards(before, after)
+	if action == entity.AuditActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	if source == "" {
+		source = entity.AuditSourceWeb
+	}
+
+	event := &entity.AuditEvent{
+		UserID:   card.UserID,
+		ActorID:  actorID,
+		CardID:   card.ID,
+		CardName: card.CardName,
+		Action:   action,
+		Source:   source,
+		Changes:  changes,
+	}
+
+	// The card change has already been written, so the event must be stored
+	// even if the request is cancelled in the meantime.
+	if err := auditRepo.Create(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to record audit event for card %d: %v", card.ID, err)
+	}
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 
 	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
@@ -16,9 +17,13 @@ func NewAuthUseCase(userRepo repository.UserRepository) *AuthUseCase {
 	return &AuthUseCase{userRepo: userRepo}
 }
 
-func (uc *AuthUseCase) Register(username, password string) error {
+func (uc *AuthUseCase) Register(ctx context.Context, username, password string) error {
+	if entity.IsReservedUsername(username) {
+		return errors.New("username is not available")
+	}
+
 	// Check if user already exists
-	_, err := uc.userRepo.FindByUsername(username)
+	_, err := uc.userRepo.FindByUsername(ctx, username)
 	if err == nil {
 		return errors.New("username already exists")
 	}
@@ -34,11 +39,11 @@ func (uc *AuthUseCase) Register(username, password string) error {
 		Password: string(hashedPassword),
 	}
 
-	return uc.userRepo.Create(user)
+	return uc.userRepo.Create(ctx, user)
 }
 
-func (uc *AuthUseCase) Login(username, password string) (*entity.User, error) {
-	user, err := uc.userRepo.FindByUsername(username)
+func (uc *AuthUseCase) Login(ctx context.Context, username, password string) (*entity.User, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, errors.New("invalid username or password")
 	}
@@ -51,6 +56,6 @@ func (uc *AuthUseCase) Login(username, password string) (*entity.User, error) {
 	return user, nil
 }
 
-func (uc *AuthUseCase) GetUserByID(id uint) (*entity.User, error) {
-	return uc.userRepo.FindByID(id)
+func (uc *AuthUseCase) GetUserByID(ctx context.Context, id uint) (*entity.User, error) {
+	return uc.userRepo.FindByID(ctx, id)
 }
@@ -1,18 +1,32 @@
 package usecase
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
 	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
 	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
 )
 
 type CardUseCase struct {
-	cardRepo repository.CardRepository
+	cardRepo    repository.CardRepository
+	auditRepo   repository.AuditRepository
+	catalogRepo repository.CatalogRepository
+	blobStore   repository.BlobStore
 }
 
-func NewCardUseCase(cardRepo repository.CardRepository) *CardUseCase {
-	return &CardUseCase{cardRepo: cardRepo}
+func NewCardUseCase(cardRepo repository.CardRepository, auditRepo repository.AuditRepository, catalogRepo repository.CatalogRepository, blobStore repository.BlobStore) *CardUseCase {
+	return &CardUseCase{cardRepo: cardRepo, auditRepo: auditRepo, catalogRepo: catalogRepo, blobStore: blobStore}
 }
 
 type CreateCardInput struct {
@@ -22,72 +36,370 @@ type CreateCardInput struct {
 	SetCode         string
 	CollectorNumber string
 	Language        string
+	Foil            bool
 	Quantity        int
+	ForTrade        int
 	BuyingPrice     float64
 	BoughtDate      *time.Time
 	SellDate        *time.Time
+	Source          entity.AuditSource
 }
 
 type UpdateCardInput struct {
 	ID              uint
 	UserID          uint
+	Version         uint
 	CardName        string
 	CardImageURL    string
 	SetCode         string
 	CollectorNumber string
 	Language        string
+	Foil            bool
 	Quantity        int
+	ForTrade        int
 	BuyingPrice     float64
 	BoughtDate      *time.Time
 	SellDate        *time.Time
+	Source          entity.AuditSource
 }
 
-func (uc *CardUseCase) CreateCard(input CreateCardInput) error {
+// CardConflictError is returned by UpdateCard when the card was changed after
+// the caller loaded it. Current holds the card as it is stored now.
+type CardConflictError struct {
+	Current *entity.Card
+}
+
+func (e *CardConflictError) Error() string {
+	return "card was modified by someone else"
+}
+
+func (uc *CardUseCase) CreateCard(ctx context.Context, input CreateCardInput) error {
+	language, err := normalizeLanguage(input.Language)
+	if err != nil {
+		return err
+	}
+
 	card := &entity.Card{
 		UserID:          input.UserID,
 		CardName:        input.CardName,
 		CardImageURL:    input.CardImageURL,
 		SetCode:         input.SetCode,
 		CollectorNumber: input.CollectorNumber,
-		Language:        input.Language,
+		Language:        language,
+		Foil:            input.Foil,
 		Quantity:        input.Quantity,
+		ForTrade:        clampForTrade(input.ForTrade, input.Quantity),
 		BuyingPrice:     input.BuyingPrice,
 		BoughtDate:      input.BoughtDate,
 		SellDate:        input.SellDate,
+		Version:         1,
+	}
+	if err := fillMetadata(ctx, uc.catalogRepo, card); err != nil {
+		return err
+	}
+
+	if err := uc.cardRepo.Create(ctx, card); err != nil {
+		return err
 	}
 
-	return uc.cardRepo.Create(card)
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionCreate, input.Source, input.UserID, nil, card)
+	return nil
 }
 
-func (uc *CardUseCase) UpdateCard(input UpdateCardInput) error {
+func (uc *CardUseCase) UpdateCard(ctx context.Context, input UpdateCardInput) error {
+	language, err := normalizeLanguage(input.Language)
+	if err != nil {
+		return err
+	}
+
 	// First check if card belongs to user
-	card, err := uc.cardRepo.FindByID(input.ID, input.UserID)
+	card, err := uc.cardRepo.FindByID(ctx, input.ID, input.UserID)
 	if err != nil {
 		return err
 	}
+	if card.Version != input.Version {
+		return &CardConflictError{Current: card}
+	}
+	before := *card
 
 	card.CardName = input.CardName
 	card.CardImageURL = input.CardImageURL
 	card.SetCode = input.SetCode
 	card.CollectorNumber = input.CollectorNumber
-	card.Language = input.Language
+	card.Language = language
+	card.Foil = input.Foil
 	card.Quantity = input.Quantity
+	card.ForTrade = clampForTrade(input.ForTrade, input.Quantity)
 	card.BuyingPrice = input.BuyingPrice
 	card.BoughtDate = input.BoughtDate
 	card.SellDate = input.SellDate
+	if err := fillMetadata(ctx, uc.catalogRepo, card); err != nil {
+		return err
+	}
+
+	if err := uc.cardRepo.Update(ctx, card); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return uc.conflictError(ctx, input.ID, input.UserID)
+		}
+		return err
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, input.Source, input.UserID, &before, card)
+	return nil
+}
+
+// normalizeLanguage returns the code of a language given by its code, name
+// or an alias. The language is optional, so an empty value stays empty.
+func normalizeLanguage(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	language, ok := entity.FindLanguage(value)
+	if !ok {
+		return "", fmt.Errorf("unknown language %q", value)
+	}
+	return language.Code, nil
+}
+
+// fillMetadata sets the metadata of each card to that of its catalog
+// printing, or clears it if the printing is not in the catalog.
+func fillMetadata(ctx context.Context, catalogRepo repository.CatalogRepository, cards ...*entity.Card) error {
+	lookup := make([]entity.Card, len(cards))
+	for i, card := range cards {
+		lookup[i] = *card
+	}
+	printings, err := catalogRepo.FindPrintings(ctx, lookup)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]entity.CardMetadata, len(printings))
+	for _, printing := range printings {
+		metadata[printingKey(printing.SetCode, printing.CollectorNumber)] = printing.Metadata()
+	}
+	for _, card := range cards {
+		card.CardMetadata = metadata[printingKey(card.SetCode, card.CollectorNumber)]
+	}
+	return nil
+}
+
+// clampForTrade keeps the number of copies offered for trade between zero and
+// the number of copies owned.
+func clampForTrade(forTrade, quantity int) int {
+	if forTrade < 0 {
+		return 0
+	}
+	if forTrade > quantity {
+		return quantity
+	}
+	return forTrade
+}
+
+func (uc *CardUseCase) conflictError(ctx context.Context, id uint, userID uint) error {
+	current, err := uc.cardRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return &CardConflictError{Current: current}
+}
+
+func (uc *CardUseCase) DeleteCard(ctx context.Context, id uint, userID uint, source entity.AuditSource) error {
+	card, err := uc.cardRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	photos, err := uc.cardRepo.Delete(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionDelete, source, userID, card, nil)
+
+	// The photo rows went with the card; their files follow now that the
+	// delete is committed.
+	if err := deletePhotoFiles(ctx, uc.blobStore, photos); err != nil {
+		return fmt.Errorf("card deleted, but removing its photos failed: %w", err)
+	}
+	return nil
+}
+
+// BulkAction is a change applied to every selected card by BulkEdit. Moving
+// cards is not one of them; LocationUseCase.MoveCards does that for the
+// collection list and the edit page alike.
+type BulkAction string
+
+const (
+	BulkSetLanguage    BulkAction = "language"
+	BulkMarkSold       BulkAction = "sold"
+	BulkAdjustQuantity BulkAction = "quantity"
+	BulkDelete         BulkAction = "delete"
+)
+
+// maxBulkCards bounds how many cards one bulk edit may touch.
+const maxBulkCards = 500
+
+// BulkEditInput describes a bulk edit. Only the field belonging to Action is
+// used: Language, SellDate or QuantityDelta, which may be negative.
+type BulkEditInput struct {
+	UserID        uint
+	CardIDs       []uint
+	Action        BulkAction
+	Language      string
+	SellDate      *time.Time
+	QuantityDelta int
+	Source        entity.AuditSource
+}
+
+// BulkFailure is a selected card the bulk edit left unchanged, with why.
+type BulkFailure struct {
+	CardID   uint
+	CardName string
+	Reason   string
+}
+
+// BulkEditResult reports how many cards a bulk edit changed and which it
+// could not.
+type BulkEditResult struct {
+	Applied  int
+	Failures []BulkFailure
+}
+
+// BulkEdit applies one action to the selected cards of a user and saves them
+// in a single transaction. Cards that are not the user's, that the action
+// does not apply to, or that changed in the meantime are reported as
+// failures; the other cards are still saved.
+func (uc *CardUseCase) BulkEdit(ctx context.Context, input BulkEditInput) (*BulkEditResult, error) {
+	if err := validateBulkEdit(input); err != nil {
+		return nil, err
+	}
+
+	result := &BulkEditResult{}
+	var updated, deleted, before []entity.Card
+	seen := make(map[uint]bool, len(input.CardIDs))
+	for _, id := range input.CardIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		card, err := uc.cardRepo.FindByID(ctx, id, input.UserID)
+		if err != nil {
+			result.Failures = append(result.Failures, BulkFailure{CardID: id, Reason: "card not found"})
+			continue
+		}
+		if input.Action == BulkDelete {
+			deleted = append(deleted, *card)
+			continue
+		}
+
+		original := *card
+		if reason := applyBulkAction(card, input); reason != "" {
+			result.Failures = append(result.Failures, BulkFailure{CardID: id, CardName: card.CardName, Reason: reason})
+			continue
+		}
+		updated = append(updated, *card)
+		before = append(before, original)
+	}
+
+	batch, err := uc.cardRepo.UpdateBatch(ctx, input.UserID, updated, deleted)
+	if err != nil {
+		return nil, err
+	}
+	skipped := batch.Skipped
+
+	for i := range updated {
+		if err, ok := skipped[updated[i].ID]; ok {
+			result.Failures = append(result.Failures, BulkFailure{CardID: updated[i].ID, CardName: updated[i].CardName, Reason: skipReason(err)})
+			continue
+		}
+		result.Applied++
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, input.Source, input.UserID, &before[i], &updated[i])
+	}
+	for i := range deleted {
+		if err, ok := skipped[deleted[i].ID]; ok {
+			result.Failures = append(result.Failures, BulkFailure{CardID: deleted[i].ID, CardName: deleted[i].CardName, Reason: skipReason(err)})
+			continue
+		}
+		result.Applied++
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionDelete, input.Source, input.UserID, &deleted[i], nil)
+	}
+
+	if err := deletePhotoFiles(ctx, uc.blobStore, batch.Photos); err != nil {
+		return nil, fmt.Errorf("cards deleted, but removing their photos failed: %w", err)
+	}
+	return result, nil
+}
+
+// skipReason describes why UpdateBatch skipped a card.
+func skipReason(err error) string {
+	if errors.Is(err, repository.ErrCopiesLent) {
+		return "copies are lent out"
+	}
+	return "changed by someone else"
+}
+
+func validateBulkEdit(input BulkEditInput) error {
+	if len(input.CardIDs) == 0 {
+		return errors.New("no cards selected")
+	}
+	if len(input.CardIDs) > maxBulkCards {
+		return fmt.Errorf("select at most %d cards at once", maxBulkCards)
+	}
 
-	return uc.cardRepo.Update(card)
+	switch input.Action {
+	case BulkDelete:
+	case BulkSetLanguage:
+		if strings.TrimSpace(input.Language) == "" {
+			return errors.New("language is required")
+		}
+		if _, ok := entity.FindLanguage(input.Language); !ok {
+			return fmt.Errorf("unknown language %q", strings.TrimSpace(input.Language))
+		}
+	case BulkMarkSold:
+		if input.SellDate == nil {
+			return errors.New("sell date is required")
+		}
+	case BulkAdjustQuantity:
+		if input.QuantityDelta == 0 {
+			return errors.New("quantity change cannot be zero")
+		}
+	default:
+		return errors.New("unknown bulk action")
+	}
+	return nil
 }
 
-func (uc *CardUseCase) DeleteCard(id uint, userID uint) error {
-	return uc.cardRepo.Delete(id, userID)
+// applyBulkAction changes card according to the input, or returns why the
+// action does not apply to it.
+func applyBulkAction(card *entity.Card, input BulkEditInput) string {
+	switch input.Action {
+	case BulkSetLanguage:
+		language, _ := entity.FindLanguage(input.Language)
+		card.Language = language.Code
+	case BulkMarkSold:
+		if card.SellDate != nil {
+			return "already sold"
+		}
+		card.SellDate = input.SellDate
+		card.ForTrade = 0
+	case BulkAdjustQuantity:
+		quantity := card.Quantity + input.QuantityDelta
+		if quantity < 1 {
+			return "quantity would drop below 1"
+		}
+		card.Quantity = quantity
+		card.ForTrade = clampForTrade(card.ForTrade, quantity)
+	}
+	return ""
 }
 
-func (uc *CardUseCase) GetCard(id uint, userID uint) (*entity.Card, error) {
-	return uc.cardRepo.FindByID(id, userID)
+func (uc *CardUseCase) GetCard(ctx context.Context, id uint, userID uint) (*entity.Card, error) {
+	return uc.cardRepo.FindByID(ctx, id, userID)
 }
 
-func (uc *CardUseCase) ListCards(userID uint, page, pageSize int, search string) ([]entity.Card, int64, error) {
+func (uc *CardUseCase) ListCards(ctx context.Context, userID uint, page, pageSize int, filter repository.CardFilter) ([]entity.Card, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -95,5 +407,144 @@ func (uc *CardUseCase) ListCards(userID uint, page, pageSize int, search string)
 		pageSize = 20
 	}
 
-	return uc.cardRepo.FindByUserID(userID, page, pageSize, search)
+	return uc.cardRepo.FindByUserID(ctx, userID, page, pageSize, filter)
+}
+
+// ErrInvalidCursor is returned for a page cursor that cannot be decoded or
+// was made for a listing with a different sort or filter.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// CardPage is one page of a cursor-paginated card listing. Next and Prev are
+// opaque cursors for the neighbouring pages, empty when there is none. Total
+// is the number of matching cards, or -1 if it was not counted.
+type CardPage struct {
+	Cards []entity.Card
+	Next  string
+	Prev  string
+	Total int64
+}
+
+// cardPageToken is what an opaque page cursor encodes. Filter is the
+// filterHash of the listing the cursor belongs to.
+type cardPageToken struct {
+	Filter   string                `json:"filter"`
+	Position repository.CardCursor `json:"pos"`
+}
+
+// ListCardPage lists the page of cards a cursor from an earlier CardPage
+// points at; an empty cursor lists the first page. When count is set the
+// matching cards are counted.
+func (uc *CardUseCase) ListCardPage(ctx context.Context, userID uint, pageSize int, filter repository.CardFilter, cursor string, count bool) (*CardPage, error) {
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	if filter.Sort == "" {
+		filter.Sort = repository.SortByCreated
+	}
+
+	page := &CardPage{Total: -1}
+	hash := filterHash(filter)
+	var position *repository.CardCursor
+	if cursor != "" {
+		token, err := decodeCardPageToken(cursor)
+		if err != nil || token.Filter != hash {
+			return nil, ErrInvalidCursor
+		}
+		position = &token.Position
+	}
+	if count {
+		total, err := uc.cardRepo.CountByUserID(ctx, userID, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = total
+	}
+
+	// One extra card tells whether there is a page beyond this one.
+	cards, err := uc.cardRepo.FindPageByUserID(ctx, userID, pageSize+1, filter, position)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := position != nil && position.Backward
+	more := len(cards) > pageSize
+	if more && backward {
+		cards = cards[1:]
+	} else if more {
+		cards = cards[:pageSize]
+	}
+	page.Cards = cards
+	if len(cards) == 0 {
+		return page, nil
+	}
+
+	token := cardPageToken{Filter: hash}
+	// A page reached going forward has one before it, and one reached going
+	// backward has one after it; the extra card answers the other direction.
+	hasPrev := (position != nil && !backward) || (backward && more)
+	hasNext := backward || more
+	if hasPrev {
+		token.Position = repository.NewCardCursor(cards[0], true)
+		page.Prev = encodeCardPageToken(token)
+	}
+	if hasNext {
+		token.Position = repository.NewCardCursor(cards[len(cards)-1], false)
+		page.Next = encodeCardPageToken(token)
+	}
+	return page, nil
+}
+
+// filterHash identifies the cards a filter matches and their order, so a
+// cursor only continues the listing it was made for.
+func filterHash(filter repository.CardFilter) string {
+	query := filter.Query
+	filter.Query = nil
+	filter.LocationIDs = slices.Clone(filter.LocationIDs)
+	slices.Sort(filter.LocationIDs)
+
+	h := sha256.New()
+	json.NewEncoder(h).Encode(filter)
+	writeQuery(h, query)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// writeQuery writes a query in a form that tells every node type apart.
+func writeQuery(w io.Writer, expr cardquery.Expr) {
+	switch e := expr.(type) {
+	case *cardquery.And:
+		io.WriteString(w, "and(")
+		writeQuery(w, e.Left)
+		io.WriteString(w, ",")
+		writeQuery(w, e.Right)
+		io.WriteString(w, ")")
+	case *cardquery.Or:
+		io.WriteString(w, "or(")
+		writeQuery(w, e.Left)
+		io.WriteString(w, ",")
+		writeQuery(w, e.Right)
+		io.WriteString(w, ")")
+	case *cardquery.Not:
+		io.WriteString(w, "not(")
+		writeQuery(w, e.Expr)
+		io.WriteString(w, ")")
+	case *cardquery.Term:
+		json.NewEncoder(w).Encode(e)
+	}
+}
+
+func encodeCardPageToken(token cardPageToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCardPageToken(cursor string) (*cardPageToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var token cardPageToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
 }
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type DeckUseCase struct {
+	deckRepo repository.DeckRepository
+	cardRepo repository.CardRepository
+	loanRepo repository.LoanRepository
+}
+
+func NewDeckUseCase(deckRepo repository.DeckRepository, cardRepo repository.CardRepository, loanRepo repository.LoanRepository) *DeckUseCase {
+	return &DeckUseCase{deckRepo: deckRepo, cardRepo: cardRepo, loanRepo: loanRepo}
+}
+
+type DeckInput struct {
+	ID          uint
+	UserID      uint
+	Name        string
+	Format      string
+	Description string
+}
+
+type AddDeckEntryInput struct {
+	DeckID          uint
+	UserID          uint
+	CardName        string
+	SetCode         string
+	CollectorNumber string
+	Quantity        int
+	Zone            entity.DeckZone
+}
+
+// DeckCommitment records how many copies of a card another deck uses.
+type DeckCommitment struct {
+	DeckID   uint
+	DeckName string
+	Quantity int
+}
+
+// DeckCardStatus compares what a deck needs of one card with what the user
+// owns, what their other decks already use and what is lent out.
+type DeckCardStatus struct {
+	CardName    string
+	Needed      int
+	Owned       int
+	Committed   int
+	Commitments []DeckCommitment
+	Lent        int
+	// Available is the number of owned copies neither used by other decks
+	// nor lent out.
+	Available int
+	// Missing is how many copies must be acquired to build this deck without
+	// taking cards out of other decks.
+	Missing int
+}
+
+type DeckOwnershipReport struct {
+	Deck         *entity.Deck
+	Cards        []DeckCardStatus
+	TotalNeeded  int
+	TotalMissing int
+}
+
+func validateDeckInput(input DeckInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("deck name is required")
+	}
+	if input.Format == "" {
+		return nil
+	}
+	for _, format := range entity.DeckFormats {
+		if input.Format == format {
+			return nil
+		}
+	}
+	return errors.New("unknown deck format")
+}
+
+func (uc *DeckUseCase) CreateDeck(ctx context.Context, input DeckInput) (*entity.Deck, error) {
+	if err := validateDeckInput(input); err != nil {
+		return nil, err
+	}
+
+	deck := &entity.Deck{
+		UserID:      input.UserID,
+		Name:        strings.TrimSpace(input.Name),
+		Format:      input.Format,
+		Description: input.Description,
+	}
+
+	if err := uc.deckRepo.Create(ctx, deck); err != nil {
+		return nil, err
+	}
+	return deck, nil
+}
+
+func (uc *DeckUseCase) UpdateDeck(ctx context.Context, input DeckInput) error {
+	if err := validateDeckInput(input); err != nil {
+		return err
+	}
+
+	deck, err := uc.deckRepo.FindByID(ctx, input.ID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	deck.Name = strings.TrimSpace(input.Name)
+	deck.Format = input.Format
+	deck.Description = input.Description
+
+	return uc.deckRepo.Update(ctx, deck)
+}
+
+func (uc *DeckUseCase) DeleteDeck(ctx context.Context, id uint, userID uint) error {
+	return uc.deckRepo.Delete(ctx, id, userID)
+}
+
+func (uc *DeckUseCase) GetDeck(ctx context.Context, id uint, userID uint) (*entity.Deck, error) {
+	return uc.deckRepo.FindByID(ctx, id, userID)
+}
+
+func (uc *DeckUseCase) ListDecks(ctx context.Context, userID uint) ([]entity.Deck, error) {
+	return uc.deckRepo.FindByUserID(ctx, userID)
+}
+
+func (uc *DeckUseCase) AddEntry(ctx context.Context, input AddDeckEntryInput) error {
+	if strings.TrimSpace(input.CardName) == "" {
+		return errors.New("card name is required")
+	}
+	if input.Quantity < 1 {
+		return errors.New("quantity must be at least 1")
+	}
+	if !validDeckZone(input.Zone) {
+		return errors.New("unknown deck zone")
+	}
+
+	// Make sure the deck belongs to the user
+	if _, err := uc.deckRepo.FindByID(ctx, input.DeckID, input.UserID); err != nil {
+		return err
+	}
+
+	entry := &entity.DeckEntry{
+		DeckID:          input.DeckID,
+		UserID:          input.UserID,
+		CardName:        strings.TrimSpace(input.CardName),
+		SetCode:         input.SetCode,
+		CollectorNumber: input.CollectorNumber,
+		Quantity:        input.Quantity,
+		Zone:            input.Zone,
+	}
+
+	return uc.deckRepo.CreateEntry(ctx, entry)
+}
+
+func validDeckZone(zone entity.DeckZone) bool {
+	for _, z := range entity.DeckZones {
+		if zone == z {
+			return true
+		}
+	}
+	return false
+}
+
+func (uc *DeckUseCase) RemoveEntry(ctx context.Context, entryID uint, deckID uint, userID uint) error {
+	return uc.deckRepo.DeleteEntry(ctx, entryID, deckID, userID)
+}
+
+// OwnershipReport works out, for every card in the deck, how many copies the
+// user owns, how many of those are already used by their other decks, and
+// how many are still missing. Cards are matched by name, case-insensitively,
+// regardless of printing.
+func (uc *DeckUseCase) OwnershipReport(ctx context.Context, deckID uint, userID uint) (*DeckOwnershipReport, error) {
+	deck, err := uc.deckRepo.FindByID(ctx, deckID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	needed := make(map[string]*DeckCardStatus)
+	var names []string
+	for _, entry := range deck.Entries {
+		key := strings.ToLower(entry.CardName)
+		status, ok := needed[key]
+		if !ok {
+			status = &DeckCardStatus{CardName: entry.CardName}
+			needed[key] = status
+			names = append(names, entry.CardName)
+		}
+		status.Needed += entry.Quantity
+	}
+
+	owned, err := uc.cardRepo.OwnedQuantities(ctx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range owned {
+		if status, ok := needed[strings.ToLower(o.CardName)]; ok {
+			status.Owned += o.Quantity
+		}
+	}
+
+	entries, err := uc.deckRepo.FindEntriesByCardNames(ctx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.DeckID == deck.ID {
+			continue
+		}
+		status, ok := needed[strings.ToLower(entry.CardName)]
+		if !ok {
+			continue
+		}
+		status.Committed += entry.Quantity
+		status.Commitments = addCommitment(status.Commitments, entry)
+	}
+
+	loans, err := uc.loanRepo.FindOutstanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, loan := range loans {
+		if loan.IsBorrowed() {
+			continue
+		}
+		if status, ok := needed[strings.ToLower(loan.CardName)]; ok {
+			status.Lent += loan.Quantity
+		}
+	}
+
+	report := &DeckOwnershipReport{Deck: deck}
+	for _, status := range needed {
+		status.Available = status.Owned - status.Committed - status.Lent
+		if status.Available < 0 {
+			status.Available = 0
+		}
+		if status.Needed > status.Available {
+			status.Missing = status.Needed - status.Available
+		}
+
+		report.TotalNeeded += status.Needed
+		report.TotalMissing += status.Missing
+		report.Cards = append(report.Cards, *status)
+	}
+
+	// Missing cards first, then alphabetically
+	sort.Slice(report.Cards, func(i, j int) bool {
+		a, b := report.Cards[i], report.Cards[j]
+		if (a.Missing > 0) != (b.Missing > 0) {
+			return a.Missing > 0
+		}
+		return strings.ToLower(a.CardName) < strings.ToLower(b.CardName)
+	})
+
+	return report, nil
+}
+
+func addCommitment(commitments []DeckCommitment, entry entity.DeckEntry) []DeckCommitment {
+	for i := range commitments {
+		if commitments[i].DeckID == entry.DeckID {
+			commitments[i].Quantity += entry.Quantity
+			return commitments
+		}
+	}
+
+	name := ""
+	if entry.Deck != nil {
+		name = entry.Deck.Name
+	}
+	return append(commitments, DeckCommitment{
+		DeckID:   entry.DeckID,
+		DeckName: name,
+		Quantity: entry.Quantity,
+	})
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type DuplicateUseCase struct {
+	cardRepo  repository.CardRepository
+	auditRepo repository.AuditRepository
+}
+
+func NewDuplicateUseCase(cardRepo repository.CardRepository, auditRepo repository.AuditRepository) *DuplicateUseCase {
+	return &DuplicateUseCase{cardRepo: cardRepo, auditRepo: auditRepo}
+}
+
+// DuplicateGroup is a set of unsold card rows for the same printing,
+// language and finish in the same location. Merged previews the single row
+// they would be merged into; Cards are ordered by ID, the first being the row
+// that is kept.
+type DuplicateGroup struct {
+	Cards  []entity.Card
+	Merged entity.Card
+}
+
+// IDs returns the IDs of the rows in the group.
+func (g DuplicateGroup) IDs() []uint {
+	ids := make([]uint, len(g.Cards))
+	for i, card := range g.Cards {
+		ids[i] = card.ID
+	}
+	return ids
+}
+
+// FindDuplicates groups the user's unsold cards that only differ in
+// quantity, price, dates and image, sorted by card name. Sold rows are left
+// alone since they record individual sales.
+func (uc *DuplicateUseCase) FindDuplicates(ctx context.Context, userID uint) ([]DuplicateGroup, error) {
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[duplicateKey][]entity.Card)
+	var keys []duplicateKey
+	for _, card := range cards {
+		if card.SellDate != nil {
+			continue
+		}
+		key := newDuplicateKey(&card)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], card)
+	}
+
+	var duplicates []DuplicateGroup
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+		duplicates = append(duplicates, DuplicateGroup{Cards: group, Merged: mergeCards(group)})
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return strings.ToLower(duplicates[i].Merged.CardName) < strings.ToLower(duplicates[j].Merged.CardName)
+	})
+	return duplicates, nil
+}
+
+// MergeCards merges the given rows of one duplicate group into the row with
+// the lowest ID and returns it. Quantities and copies for trade are added up
+// and the buying price becomes the average weighted by quantity, so the
+// total cost basis is unchanged.
+func (uc *DuplicateUseCase) MergeCards(ctx context.Context, userID uint, ids []uint) (*entity.Card, error) {
+	if len(ids) < 2 {
+		return nil, errors.New("select at least two cards to merge")
+	}
+
+	cards := make([]entity.Card, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		card, err := uc.cardRepo.FindByID(ctx, id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if card.SellDate != nil {
+			return nil, errors.New("sold cards cannot be merged")
+		}
+		if len(cards) > 0 && newDuplicateKey(card) != newDuplicateKey(&cards[0]) {
+			return nil, errors.New("only cards of the same printing, language, finish and location can be merged")
+		}
+		cards = append(cards, *card)
+	}
+	if len(cards) < 2 {
+		return nil, errors.New("select at least two cards to merge")
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+
+	before := cards[0]
+	target := mergeCards(cards)
+	if err := uc.cardRepo.Merge(ctx, &target, cards[1:]); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, errors.New("the cards were changed while merging; review the duplicates again")
+		}
+		return nil, err
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, entity.AuditSourceWeb, userID, &before, &target)
+	for i := range cards[1:] {
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionDelete, entity.AuditSourceWeb, userID, &cards[i+1], nil)
+	}
+	return &target, nil
+}
+
+// MergeAll merges every duplicate group and returns the number of groups
+// merged. Each group is merged in its own transaction; the first failure
+// stops the run.
+func (uc *DuplicateUseCase) MergeAll(ctx context.Context, userID uint) (int, error) {
+	groups, err := uc.FindDuplicates(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, group := range groups {
+		if _, err := uc.MergeCards(ctx, userID, group.IDs()); err != nil {
+			return i, err
+		}
+	}
+	return len(groups), nil
+}
+
+type duplicateKey struct {
+	name, set, number, language string
+	foil                        bool
+	location                    uint
+}
+
+func newDuplicateKey(card *entity.Card) duplicateKey {
+	key := duplicateKey{
+		name:     normalizeKey(card.CardName),
+		set:      normalizeKey(card.SetCode),
+		number:   normalizeKey(card.CollectorNumber),
+		language: normalizeKey(card.Language),
+		foil:     card.Foil,
+	}
+	if card.LocationID != nil {
+		key.location = *card.LocationID
+	}
+	return key
+}
+
+func normalizeKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// mergeCards returns the first card with the copies of all cards added to
+// it. The earliest bought date is kept, and the first image if the kept row
+// has none.
+func mergeCards(cards []entity.Card) entity.Card {
+	merged := cards[0]
+	merged.Quantity = 0
+	merged.ForTrade = 0
+
+	var cost float64
+	for _, card := range cards {
+		merged.Quantity += card.Quantity
+		merged.ForTrade += card.ForTrade
+		cost += card.BuyingPrice * float64(card.Quantity)
+
+		if card.BoughtDate != nil && (merged.BoughtDate == nil || card.BoughtDate.Before(*merged.BoughtDate)) {
+			merged.BoughtDate = card.BoughtDate
+		}
+		if merged.CardImageURL == "" {
+			merged.CardImageURL = card.CardImageURL
+		}
+		if merged.ImageHash == "" {
+			merged.ImageHash, merged.ImageSourceURL = card.ImageHash, card.ImageSourceURL
+		}
+	}
+
+	if merged.ForTrade > merged.Quantity {
+		merged.ForTrade = merged.Quantity
+	}
+	if merged.Quantity > 0 {
+		merged.BuyingPrice = math.Round(cost/float64(merged.Quantity)*100) / 100
+	}
+	return merged
+}
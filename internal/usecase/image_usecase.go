@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+const (
+	// maxImageBytes bounds downloaded and uploaded image files.
+	maxImageBytes = 10 << 20
+	// maxImagePixels bounds the decoded size, so a small file cannot expand
+	// into an enormous bitmap.
+	maxImagePixels   = 24_000_000
+	thumbnailQuality = 85
+)
+
+// ErrImageTooLarge is returned for image files over the size limit.
+var ErrImageTooLarge = fmt.Errorf("image files can be at most %d MB", maxImageBytes>>20)
+
+// ErrUnsupportedImage is returned for files that are not JPEG, PNG or GIF
+// images.
+var ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are supported")
+
+// ErrNoCardImage is returned when a card has no cached image yet.
+var ErrNoCardImage = errors.New("card has no cached image")
+
+type ImageUseCase struct {
+	imageRepo   repository.CardImageRepository
+	cardRepo    repository.CardRepository
+	catalogRepo repository.CatalogRepository
+	blobStore   repository.BlobStore
+	client      *http.Client
+}
+
+// NewImageUseCase returns the image use case. client downloads card images
+// from their URLs.
+func NewImageUseCase(imageRepo repository.CardImageRepository, cardRepo repository.CardRepository, catalogRepo repository.CatalogRepository, blobStore repository.BlobStore, client *http.Client) *ImageUseCase {
+	return &ImageUseCase{imageRepo: imageRepo, cardRepo: cardRepo, catalogRepo: catalogRepo, blobStore: blobStore, client: client}
+}
+
+// ImageBlob is one rendition of a card image or photo, opened for reading.
+// The caller closes Body. ETag identifies the content, which never changes
+// for a given ETag.
+type ImageBlob struct {
+	Body        io.ReadCloser
+	ContentType string
+	ETag        string
+}
+
+// imageBlobKey returns the blob store key of one rendition of an image.
+func imageBlobKey(hash string, size entity.ImageSize) string {
+	return "cards/" + hash[:2] + "/" + hash + "/" + string(size)
+}
+
+// CachePending fetches the images of up to limit cards whose image URL
+// changed since it was last fetched, and returns how many cards now show a
+// cached image. A URL that was downloaded before is not downloaded again.
+// Cards whose download fails keep their previous image and are not retried
+// until their URL changes.
+func (uc *ImageUseCase) CachePending(ctx context.Context, limit int) (int, error) {
+	cards, err := uc.imageRepo.FindPendingCards(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	cached := 0
+	for _, card := range cards {
+		hash := card.ImageHash
+		stored, err := uc.imageRepo.FindBySourceURL(ctx, card.CardImageURL)
+		if err != nil {
+			stored, err = uc.download(ctx, card.CardImageURL)
+		}
+		if err != nil {
+			log.Printf("Failed to cache image of card %d from %s: %v", card.ID, card.CardImageURL, err)
+		} else {
+			hash = stored.Hash
+			cached++
+		}
+
+		if err := uc.imageRepo.SetCardImage(ctx, card.ID, card.CardImageURL, hash); err != nil {
+			return cached, err
+		}
+	}
+	return cached, nil
+}
+
+func (uc *ImageUseCase) download(ctx context.Context, rawURL string) (*entity.CardImage, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("image URL must be an http or https address")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+	req.Header.Set("User-Agent", "mtg-collection-tracker")
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return uc.store(ctx, resp.Body, rawURL)
+}
+
+// UploadCardImage stores an uploaded file as the image of a card. The card
+// keeps showing it until its image URL is changed.
+func (uc *ImageUseCase) UploadCardImage(ctx context.Context, userID uint, cardID uint, r io.Reader) error {
+	card, err := uc.cardRepo.FindByID(ctx, cardID, userID)
+	if err != nil {
+		return err
+	}
+
+	stored, err := uc.store(ctx, r, "")
+	if err != nil {
+		return err
+	}
+	return uc.imageRepo.SetCardImage(ctx, card.ID, card.CardImageURL, stored.Hash)
+}
+
+// imageFile is a validated image file read into memory.
+type imageFile struct {
+	data        []byte
+	config      image.Config
+	contentType string
+}
+
+// readImageFile reads an image file and checks that it is a JPEG, PNG or GIF
+// image within the size limits.
+func readImageFile(r io.Reader) (*imageFile, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png" && format != "gif") {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("images can be at most %d megapixels", maxImagePixels/1_000_000)
+	}
+
+	return &imageFile{data: data, config: config, contentType: "image/" + format}, nil
+}
+
+func (f *imageFile) decode() (image.Image, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(f.data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return decoded, nil
+}
+
+// putThumbnail stores decoded scaled down to width as a JPEG under key.
+func putThumbnail(ctx context.Context, blobStore repository.BlobStore, key string, decoded image.Image, width int) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleToWidth(decoded, width), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return err
+	}
+	return blobStore.Put(ctx, key, &buf)
+}
+
+// store validates an image file, writes it and its thumbnails to the blob
+// store and records it. A file that is already stored is only looked up.
+func (uc *ImageUseCase) store(ctx context.Context, r io.Reader, sourceURL string) (*entity.CardImage, error) {
+	file, err := readImageFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(file.data)
+	hash := hex.EncodeToString(sum[:])
+	if existing, err := uc.imageRepo.FindByHash(ctx, hash); err == nil {
+		return existing, nil
+	}
+
+	decoded, err := file.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	// Thumbnails first, so a recorded image always has every rendition
+	for size, width := range entity.ThumbnailWidths {
+		if err := putThumbnail(ctx, uc.blobStore, imageBlobKey(hash, size), decoded, width); err != nil {
+			return nil, err
+		}
+	}
+	if err := uc.blobStore.Put(ctx, imageBlobKey(hash, entity.ImageSizeOriginal), bytes.NewReader(file.data)); err != nil {
+		return nil, err
+	}
+
+	stored := &entity.CardImage{
+		Hash:        hash,
+		SourceURL:   sourceURL,
+		ContentType: file.contentType,
+		Width:       file.config.Width,
+		Height:      file.config.Height,
+		Size:        int64(len(file.data)),
+	}
+	if err := uc.imageRepo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// OpenCardImage opens one rendition of a card's cached image.
+func (uc *ImageUseCase) OpenCardImage(ctx context.Context, userID uint, cardID uint, size entity.ImageSize) (*ImageBlob, error) {
+	if err := checkImageSize(size); err != nil {
+		return nil, err
+	}
+
+	card, err := uc.cardRepo.FindByID(ctx, cardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if card.ImageHash == "" {
+		return nil, ErrNoCardImage
+	}
+	return uc.open(ctx, card.ImageHash, size)
+}
+
+// OpenTranslatedImage opens one rendition of the catalog image of a card's
+// printing in the card's language. The image is downloaded and cached the
+// first time it is asked for.
+func (uc *ImageUseCase) OpenTranslatedImage(ctx context.Context, userID uint, cardID uint, size entity.ImageSize) (*ImageBlob, error) {
+	if err := checkImageSize(size); err != nil {
+		return nil, err
+	}
+
+	card, err := uc.cardRepo.FindByID(ctx, cardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := uc.catalogRepo.FindTranslations(ctx, []entity.Card{*card})
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) == 0 || translations[0].ImageURL == "" {
+		return nil, ErrNoCardImage
+	}
+
+	imageURL := translations[0].ImageURL
+	stored, err := uc.imageRepo.FindBySourceURL(ctx, imageURL)
+	if err != nil {
+		stored, err = uc.download(ctx, imageURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uc.open(ctx, stored.Hash, size)
+}
+
+func checkImageSize(size entity.ImageSize) error {
+	if _, ok := entity.ThumbnailWidths[size]; !ok && size != entity.ImageSizeOriginal {
+		return fmt.Errorf("unknown image size %q", size)
+	}
+	return nil
+}
+
+// open opens one rendition of a stored image.
+func (uc *ImageUseCase) open(ctx context.Context, hash string, size entity.ImageSize) (*ImageBlob, error) {
+	contentType := "image/jpeg"
+	if size == entity.ImageSizeOriginal {
+		stored, err := uc.imageRepo.FindByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		contentType = stored.ContentType
+	}
+
+	body, err := uc.blobStore.Get(ctx, imageBlobKey(hash, size))
+	if err != nil {
+		return nil, err
+	}
+	return &ImageBlob{Body: body, ContentType: contentType, ETag: hash + "-" + string(size)}, nil
+}
+
+// scaleToWidth shrinks src to the given width, keeping its aspect ratio, by
+// averaging the source pixels under each target pixel. Transparent areas are
+// flattened onto white, as JPEG has no alpha channel. Images that are
+// already narrow enough keep their size.
+func scaleToWidth(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	flat := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+	if srcW <= width {
+		return flat
+	}
+
+	height := max(srcH*width/srcW, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max((y+1)*srcH/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max((x+1)*srcW/width, x0+1)
+
+			var sum [3]int
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += int(row[sx*4])
+					sum[1] += int(row[sx*4+1])
+					sum[2] += int(row[sx*4+2])
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(sum[0] / n)
+			dst.Pix[i+1] = uint8(sum[1] / n)
+			dst.Pix[i+2] = uint8(sum[2] / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
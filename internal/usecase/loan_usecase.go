@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+// maxBorrowerLength matches the size of the borrower column.
+const maxBorrowerLength = 100
+
+type LoanUseCase struct {
+	loanRepo repository.LoanRepository
+	cardRepo repository.CardRepository
+}
+
+func NewLoanUseCase(loanRepo repository.LoanRepository, cardRepo repository.CardRepository) *LoanUseCase {
+	return &LoanUseCase{loanRepo: loanRepo, cardRepo: cardRepo}
+}
+
+type LendInput struct {
+	UserID   uint
+	CardID   uint
+	Borrower string
+	Quantity int
+	LentAt   time.Time
+	DueAt    *time.Time
+	Notes    string
+}
+
+// Lend records copies of a card lent to someone. Only copies that are not
+// already out on another loan can be lent.
+func (uc *LoanUseCase) Lend(ctx context.Context, input LendInput) (*entity.Loan, error) {
+	borrower, err := loanParty(input.Borrower, "borrower")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoanTerms(input.Quantity, input.LentAt, input.DueAt, "lent"); err != nil {
+		return nil, err
+	}
+
+	card, err := uc.cardRepo.FindByID(ctx, input.CardID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if card.SellDate != nil {
+		return nil, errors.New("sold cards cannot be lent")
+	}
+
+	lent, err := uc.LentQuantities(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if available := card.Quantity - lent[card.ID]; input.Quantity > available {
+		return nil, fmt.Errorf("only %d copies of %s are available to lend", max(available, 0), card.CardName)
+	}
+
+	loan := &entity.Loan{
+		UserID:          input.UserID,
+		Direction:       entity.LoanLent,
+		CardID:          card.ID,
+		CardName:        card.CardName,
+		SetCode:         card.SetCode,
+		CollectorNumber: card.CollectorNumber,
+		Borrower:        borrower,
+		Quantity:        input.Quantity,
+		LentAt:          input.LentAt,
+		DueAt:           input.DueAt,
+		Notes:           strings.TrimSpace(input.Notes),
+	}
+	if err := uc.loanRepo.Create(ctx, loan); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+type BorrowInput struct {
+	UserID          uint
+	CardName        string
+	SetCode         string
+	CollectorNumber string
+	Lender          string
+	Quantity        int
+	BorrowedAt      time.Time
+	DueAt           *time.Time
+	Notes           string
+}
+
+// Borrow records copies of a card borrowed from someone. They are not added
+// to the collection, so the card is given by name rather than by a card row.
+func (uc *LoanUseCase) Borrow(ctx context.Context, input BorrowInput) (*entity.Loan, error) {
+	cardName := strings.TrimSpace(input.CardName)
+	if cardName == "" {
+		return nil, errors.New("card name is required")
+	}
+	lender, err := loanParty(input.Lender, "lender")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoanTerms(input.Quantity, input.BorrowedAt, input.DueAt, "borrowed"); err != nil {
+		return nil, err
+	}
+
+	loan := &entity.Loan{
+		UserID:          input.UserID,
+		Direction:       entity.LoanBorrowed,
+		CardName:        cardName,
+		SetCode:         strings.TrimSpace(input.SetCode),
+		CollectorNumber: strings.TrimSpace(input.CollectorNumber),
+		Borrower:        lender,
+		Quantity:        input.Quantity,
+		LentAt:          input.BorrowedAt,
+		DueAt:           input.DueAt,
+		Notes:           strings.TrimSpace(input.Notes),
+	}
+	if err := uc.loanRepo.Create(ctx, loan); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// loanParty trims the name of the friend on the other side of a loan, who
+// is named role in errors.
+func loanParty(name, role string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s is required", role)
+	}
+	if len(name) > maxBorrowerLength {
+		return "", fmt.Errorf("%s must be at most %d characters", role, maxBorrowerLength)
+	}
+	return name, nil
+}
+
+// checkLoanTerms validates the copies and dates of a loan made on the date
+// at; verb is "lent" or "borrowed".
+func checkLoanTerms(quantity int, at time.Time, dueAt *time.Time, verb string) error {
+	if quantity < 1 {
+		return errors.New("quantity must be at least 1")
+	}
+	if at.IsZero() {
+		return fmt.Errorf("%s date is required", verb)
+	}
+	if dueAt != nil && dueAt.Before(at) {
+		return fmt.Errorf("expected return cannot be before the %s date", verb)
+	}
+	return nil
+}
+
+// MarkReturned closes an outstanding loan. Lent copies become available
+// again; borrowed ones have been given back.
+func (uc *LoanUseCase) MarkReturned(ctx context.Context, id uint, userID uint, returnedAt time.Time) error {
+	loan, err := uc.loanRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !loan.IsOutstanding() {
+		return errors.New("the loan has already been returned")
+	}
+	if returnedAt.IsZero() {
+		return errors.New("return date is required")
+	}
+	if returnedAt.Before(loan.LentAt) {
+		if loan.IsBorrowed() {
+			return errors.New("return date cannot be before the borrowed date")
+		}
+		return errors.New("return date cannot be before the lent date")
+	}
+
+	loan.ReturnedAt = &returnedAt
+	return uc.loanRepo.Update(ctx, loan)
+}
+
+func (uc *LoanUseCase) DeleteLoan(ctx context.Context, id uint, userID uint) error {
+	return uc.loanRepo.Delete(ctx, id, userID)
+}
+
+// Loans returns the outstanding loans of a user, oldest due date first, and
+// the returned ones, most recently lent first.
+func (uc *LoanUseCase) Loans(ctx context.Context, userID uint) ([]entity.Loan, []entity.Loan, error) {
+	outstanding, err := uc.loanRepo.FindOutstanding(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	loans, err := uc.loanRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var returned []entity.Loan
+	for _, loan := range loans {
+		if !loan.IsOutstanding() {
+			returned = append(returned, loan)
+		}
+	}
+	return outstanding, returned, nil
+}
+
+// CardLoans returns the outstanding loans of one card.
+func (uc *LoanUseCase) CardLoans(ctx context.Context, cardID uint, userID uint) ([]entity.Loan, error) {
+	outstanding, err := uc.loanRepo.FindOutstanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var loans []entity.Loan
+	for _, loan := range outstanding {
+		if loan.CardID == cardID {
+			loans = append(loans, loan)
+		}
+	}
+	return loans, nil
+}
+
+// Overdue returns the outstanding loans that are past their expected return
+// date at now, most overdue first.
+func (uc *LoanUseCase) Overdue(ctx context.Context, userID uint, now time.Time) ([]entity.Loan, error) {
+	outstanding, err := uc.loanRepo.FindOutstanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var overdue []entity.Loan
+	for _, loan := range outstanding {
+		if loan.IsOverdue(now) {
+			overdue = append(overdue, loan)
+		}
+	}
+	return overdue, nil
+}
+
+// LentQuantities returns the number of copies lent out per card ID.
+func (uc *LoanUseCase) LentQuantities(ctx context.Context, userID uint) (map[uint]int, error) {
+	return lentQuantities(ctx, uc.loanRepo, userID)
+}
+
+func lentQuantities(ctx context.Context, loanRepo repository.LoanRepository, userID uint) (map[uint]int, error) {
+	outstanding, err := loanRepo.FindOutstanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lent := make(map[uint]int)
+	for _, loan := range outstanding {
+		if !loan.IsBorrowed() {
+			lent[loan.CardID] += loan.Quantity
+		}
+	}
+	return lent, nil
+}
@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type LocationUseCase struct {
+	locationRepo repository.LocationRepository
+	cardRepo     repository.CardRepository
+	auditRepo    repository.AuditRepository
+	transactor   repository.Transactor
+}
+
+func NewLocationUseCase(locationRepo repository.LocationRepository, cardRepo repository.CardRepository, auditRepo repository.AuditRepository, transactor repository.Transactor) *LocationUseCase {
+	return &LocationUseCase{locationRepo: locationRepo, cardRepo: cardRepo, auditRepo: auditRepo, transactor: transactor}
+}
+
+type CreateLocationInput struct {
+	UserID   uint
+	ParentID *uint
+	Kind     entity.LocationKind
+	Name     string
+}
+
+// MoveCardsInput moves cards to a location. A nil LocationID takes the cards
+// out of any location. When Quantity is set and smaller than a card's
+// quantity, only that many copies are split off and moved.
+type MoveCardsInput struct {
+	UserID     uint
+	CardIDs    []uint
+	LocationID *uint
+	Quantity   int
+	Source     entity.AuditSource
+}
+
+// LocationNode is a location together with its place in the hierarchy.
+type LocationNode struct {
+	entity.Location
+	// Path is the full name of the location, e.g. "Shelf A > Box 2".
+	Path string
+	// Depth is the nesting level, 0 for top-level locations.
+	Depth    int
+	Children int
+}
+
+func (uc *LocationUseCase) CreateLocation(ctx context.Context, input CreateLocationInput) (*entity.Location, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("location name is required")
+	}
+	if input.Kind.Rank() < 0 {
+		return nil, errors.New("unknown location kind")
+	}
+
+	if input.ParentID != nil {
+		parent, err := uc.locationRepo.FindByID(ctx, *input.ParentID, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Kind.Rank() >= input.Kind.Rank() {
+			return nil, errors.New("a " + string(input.Kind) + " cannot be placed inside a " + string(parent.Kind))
+		}
+	}
+
+	location := &entity.Location{
+		UserID:   input.UserID,
+		ParentID: input.ParentID,
+		Kind:     input.Kind,
+		Name:     name,
+	}
+
+	if err := uc.locationRepo.Create(ctx, location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (uc *LocationUseCase) RenameLocation(ctx context.Context, id uint, userID uint, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("location name is required")
+	}
+
+	location, err := uc.locationRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	location.Name = name
+	return uc.locationRepo.Update(ctx, location)
+}
+
+// DeleteLocation removes an empty location. Locations that still hold cards
+// or other locations are kept, so nothing is silently unassigned.
+func (uc *LocationUseCase) DeleteLocation(ctx context.Context, id uint, userID uint) error {
+	nodes, err := uc.ListLocations(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var node *LocationNode
+	for i := range nodes {
+		if nodes[i].ID == id {
+			node = &nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		return errors.New("location not found")
+	}
+	if node.Children > 0 {
+		return errors.New("location still contains other locations")
+	}
+
+	_, cards, err := uc.cardRepo.FindByUserID(ctx, userID, 1, 1, repository.CardFilter{LocationIDs: []uint{id}})
+	if err != nil {
+		return err
+	}
+	if cards > 0 {
+		return errors.New("location still contains cards")
+	}
+
+	return uc.locationRepo.Delete(ctx, id, userID)
+}
+
+// ListLocations returns all of a user's locations in tree order: every
+// location is followed by its children, siblings sorted by name.
+func (uc *LocationUseCase) ListLocations(ctx context.Context, userID uint) ([]LocationNode, error) {
+	locations, err := uc.locationRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]entity.Location)
+	var roots []entity.Location
+	known := make(map[uint]bool, len(locations))
+	for _, location := range locations {
+		known[location.ID] = true
+	}
+	for _, location := range locations {
+		if location.ParentID == nil || !known[*location.ParentID] {
+			roots = append(roots, location)
+		} else {
+			children[*location.ParentID] = append(children[*location.ParentID], location)
+		}
+	}
+
+	var nodes []LocationNode
+	var walk func(locations []entity.Location, path string, depth int)
+	walk = func(locations []entity.Location, path string, depth int) {
+		sort.SliceStable(locations, func(i, j int) bool {
+			return strings.ToLower(locations[i].Name) < strings.ToLower(locations[j].Name)
+		})
+		for _, location := range locations {
+			nodePath := location.Name
+			if path != "" {
+				nodePath = path + " > " + location.Name
+			}
+			nodes = append(nodes, LocationNode{
+				Location: location,
+				Path:     nodePath,
+				Depth:    depth,
+				Children: len(children[location.ID]),
+			})
+			walk(children[location.ID], nodePath, depth+1)
+		}
+	}
+	walk(roots, "", 0)
+
+	return nodes, nil
+}
+
+// Subtree returns the ID of a location and of every location nested inside
+// it, for filtering cards by location.
+func (uc *LocationUseCase) Subtree(ctx context.Context, id uint, userID uint) ([]uint, error) {
+	locations, err := uc.locationRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]uint)
+	found := false
+	for _, location := range locations {
+		if location.ID == id {
+			found = true
+		}
+		if location.ParentID != nil {
+			children[*location.ParentID] = append(children[*location.ParentID], location.ID)
+		}
+	}
+	if !found {
+		return nil, errors.New("location not found")
+	}
+
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// MoveCards assigns the selected cards to a location and returns how many
+// card rows were moved or split. All cards are moved in one transaction, so
+// on error none of them has moved.
+func (uc *LocationUseCase) MoveCards(ctx context.Context, input MoveCardsInput) (int, error) {
+	if len(input.CardIDs) == 0 {
+		return 0, errors.New("no cards selected")
+	}
+	if len(input.CardIDs) > maxBulkCards {
+		return 0, fmt.Errorf("select at most %d cards at once", maxBulkCards)
+	}
+	if input.Quantity < 0 {
+		return 0, errors.New("quantity cannot be negative")
+	}
+	if input.LocationID != nil {
+		if _, err := uc.locationRepo.FindByID(ctx, *input.LocationID, input.UserID); err != nil {
+			return 0, err
+		}
+	}
+
+	moved := 0
+	err := uc.transactor.Transaction(ctx, func(ctx context.Context) error {
+		moved = 0
+		for _, id := range input.CardIDs {
+			card, err := uc.cardRepo.FindByID(ctx, id, input.UserID)
+			if err != nil {
+				return err
+			}
+			if sameLocation(card.LocationID, input.LocationID) {
+				continue
+			}
+
+			if input.Quantity > 0 && input.Quantity < card.Quantity {
+				err = uc.splitCard(ctx, card, input)
+			} else {
+				before := *card
+				card.LocationID = input.LocationID
+				err = uc.cardRepo.Update(ctx, card)
+				if err == nil {
+					recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, input.Source, input.UserID, &before, card)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			moved++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+// splitCard moves input.Quantity copies of card into a new row at the target
+// location, leaving the rest where they are.
+func (uc *LocationUseCase) splitCard(ctx context.Context, card *entity.Card, input MoveCardsInput) error {
+	before := *card
+
+	// The new row keeps the original CreatedAt, so both halves stay next to
+	// each other in the collection list.
+	part := *card
+	part.ID = 0
+	part.Quantity = input.Quantity
+	part.LocationID = input.LocationID
+	part.ForTrade = 0
+	part.Version = 1
+	part.UpdatedAt = time.Time{}
+
+	card.Quantity -= input.Quantity
+	card.ForTrade = clampForTrade(card.ForTrade, card.Quantity)
+
+	if err := uc.cardRepo.Split(ctx, card, &part); err != nil {
+		return err
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, input.Source, input.UserID, &before, card)
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionCreate, input.Source, input.UserID, nil, &part)
+	return nil
+}
+
+func sameLocation(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
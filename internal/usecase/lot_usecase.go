@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+const (
+	// openingLotSource marks the lot created for the copies a card already
+	// had when its first lot was recorded.
+	openingLotSource = "opening balance"
+	// adjustmentLotSource marks a lot created because the card quantity was
+	// raised outside of the lot tracking.
+	adjustmentLotSource = "adjustment"
+)
+
+type LotUseCase struct {
+	lotRepo   repository.LotRepository
+	cardRepo  repository.CardRepository
+	userRepo  repository.UserRepository
+	auditRepo repository.AuditRepository
+}
+
+func NewLotUseCase(lotRepo repository.LotRepository, cardRepo repository.CardRepository, userRepo repository.UserRepository, auditRepo repository.AuditRepository) *LotUseCase {
+	return &LotUseCase{lotRepo: lotRepo, cardRepo: cardRepo, userRepo: userRepo, auditRepo: auditRepo}
+}
+
+type AddLotInput struct {
+	UserID     uint
+	CardID     uint
+	Quantity   int
+	UnitCost   float64
+	AcquiredAt *time.Time
+	Source     string
+}
+
+type RecordSaleInput struct {
+	UserID    uint
+	CardID    uint
+	Quantity  int
+	UnitPrice float64
+	SoldAt    time.Time
+}
+
+// RealizedGains lists the sales of a user with their totals.
+type RealizedGains struct {
+	Sales     []entity.CardSale
+	Proceeds  float64
+	CostBasis float64
+	Gain      float64
+}
+
+// Lots returns the purchase lots of a card, oldest first.
+func (uc *LotUseCase) Lots(ctx context.Context, cardID uint, userID uint) ([]entity.PurchaseLot, error) {
+	if _, err := uc.cardRepo.FindByID(ctx, cardID, userID); err != nil {
+		return nil, err
+	}
+	return uc.lotRepo.FindByCardID(ctx, cardID, userID)
+}
+
+// CardSales returns the sales made out of a card, oldest first.
+func (uc *LotUseCase) CardSales(ctx context.Context, cardID uint, userID uint) ([]entity.CardSale, error) {
+	return uc.lotRepo.FindSalesByCardID(ctx, cardID, userID)
+}
+
+// AddLot records copies bought for an existing card stack. The first lot of a
+// card also records the copies it already had as an opening lot, so the
+// stack stays fully covered. The card's quantity grows by the lot and its
+// buying price becomes the average cost of the copies held.
+func (uc *LotUseCase) AddLot(ctx context.Context, input AddLotInput) error {
+	if input.Quantity < 1 {
+		return errors.New("quantity must be at least 1")
+	}
+	if input.UnitCost < 0 {
+		return errors.New("unit cost cannot be negative")
+	}
+
+	card, err := uc.cardRepo.FindByID(ctx, input.CardID, input.UserID)
+	if err != nil {
+		return err
+	}
+	if card.SellDate != nil {
+		return errors.New("lots cannot be added to a sold card")
+	}
+	before := *card
+
+	lots, err := uc.lotRepo.FindByCardID(ctx, card.ID, input.UserID)
+	if err != nil {
+		return err
+	}
+	lots = reconcileLots(card, lots)
+	lots = append(lots, entity.PurchaseLot{
+		UserID:       input.UserID,
+		CardID:       card.ID,
+		Quantity:     input.Quantity,
+		Remaining:    input.Quantity,
+		UnitCost:     input.UnitCost,
+		HeldUnitCost: input.UnitCost,
+		AcquiredAt:   input.AcquiredAt,
+		Source:       input.Source,
+	})
+	sortLots(lots)
+
+	card.Quantity += input.Quantity
+	applyLots(card, lots)
+
+	if err := uc.lotRepo.SaveLots(ctx, card, lots); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return errors.New("the card was changed while adding the lot; try again")
+		}
+		return err
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, entity.AuditSourceWeb, input.UserID, &before, card)
+	return nil
+}
+
+// RecordSale sells copies of a card. The copies are taken from the purchase
+// lots according to the user's cost method, which fixes the cost basis of
+// the sale. Selling the whole stack marks the card as sold; selling part of
+// it moves the sold copies to a new, sold row.
+func (uc *LotUseCase) RecordSale(ctx context.Context, input RecordSaleInput) (*entity.CardSale, error) {
+	if input.Quantity < 1 {
+		return nil, errors.New("quantity must be at least 1")
+	}
+	if input.UnitPrice < 0 {
+		return nil, errors.New("sale price cannot be negative")
+	}
+	if input.SoldAt.IsZero() {
+		return nil, errors.New("sale date is required")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	method := user.CostMethod
+	if method == "" {
+		method = entity.CostMethodFIFO
+	}
+
+	card, err := uc.cardRepo.FindByID(ctx, input.CardID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if card.SellDate != nil {
+		return nil, errors.New("card is already sold")
+	}
+	if input.Quantity > card.Quantity {
+		return nil, errors.New("cannot sell more copies than the card has")
+	}
+	before := *card
+
+	lots, err := uc.lotRepo.FindByCardID(ctx, card.ID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	lots = reconcileLots(card, lots)
+	if hasNewLots(lots) {
+		// Allocations refer to lots by ID, so lots made up for untracked
+		// copies are stored first.
+		if err := uc.lotRepo.SaveLots(ctx, card, lots); err != nil {
+			return nil, uc.saleError(err)
+		}
+		before = *card
+	}
+
+	allocations := allocateLots(lots, input.Quantity, method)
+	sale := &entity.CardSale{
+		UserID:          input.UserID,
+		CardID:          card.ID,
+		CardName:        card.CardName,
+		SetCode:         card.SetCode,
+		CollectorNumber: card.CollectorNumber,
+		Quantity:        input.Quantity,
+		UnitPrice:       input.UnitPrice,
+		SoldAt:          input.SoldAt,
+		Method:          method,
+		Allocations:     allocations,
+	}
+	unitCost := roundCents(sale.CostBasis() / float64(input.Quantity))
+	soldAt := input.SoldAt
+
+	var sold *entity.Card
+	if input.Quantity == card.Quantity {
+		card.SellDate = &soldAt
+		card.ForTrade = 0
+		card.BuyingPrice = unitCost
+	} else {
+		part := *card
+		part.ID = 0
+		part.Quantity = input.Quantity
+		part.ForTrade = 0
+		part.LocationID = nil
+		part.BuyingPrice = unitCost
+		part.SellDate = &soldAt
+		part.Version = 1
+		part.UpdatedAt = time.Time{}
+		sold = &part
+
+		card.Quantity -= input.Quantity
+		card.ForTrade = clampForTrade(card.ForTrade, card.Quantity)
+		applyLots(card, lots)
+	}
+
+	if err := uc.lotRepo.RecordSale(ctx, card, sold, lots, sale); err != nil {
+		return nil, uc.saleError(err)
+	}
+
+	recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, entity.AuditSourceWeb, input.UserID, &before, card)
+	if sold != nil {
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionCreate, entity.AuditSourceWeb, input.UserID, nil, sold)
+	}
+	return sale, nil
+}
+
+func (uc *LotUseCase) saleError(err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return errors.New("the card was changed while recording the sale; try again")
+	}
+	return err
+}
+
+// RealizedGains returns every sale of the user, oldest first, with the total
+// proceeds, cost basis and gain.
+func (uc *LotUseCase) RealizedGains(ctx context.Context, userID uint) (*RealizedGains, error) {
+	sales, err := uc.lotRepo.FindSalesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	gains := &RealizedGains{Sales: sales}
+	for _, sale := range sales {
+		gains.Proceeds += sale.Proceeds()
+		gains.CostBasis += sale.CostBasis()
+	}
+	gains.Gain = gains.Proceeds - gains.CostBasis
+	return gains, nil
+}
+
+// reconcileLots makes the remaining copies of the lots match the card
+// quantity, which may have been edited, moved or traded since. Missing copies
+// are added as a lot at the card's buying price; surplus copies are taken
+// from the oldest lots. A card without lots gets a single opening lot.
+func reconcileLots(card *entity.Card, lots []entity.PurchaseLot) []entity.PurchaseLot {
+	held := 0
+	for _, lot := range lots {
+		held += lot.Remaining
+	}
+
+	if held < card.Quantity {
+		source := adjustmentLotSource
+		if len(lots) == 0 {
+			source = openingLotSource
+		}
+		lots = append(lots, entity.PurchaseLot{
+			UserID:       card.UserID,
+			CardID:       card.ID,
+			Quantity:     card.Quantity - held,
+			Remaining:    card.Quantity - held,
+			UnitCost:     card.BuyingPrice,
+			HeldUnitCost: card.BuyingPrice,
+			AcquiredAt:   card.BoughtDate,
+			Source:       source,
+		})
+	}
+	sortLots(lots)
+
+	for i := 0; held > card.Quantity && i < len(lots); i++ {
+		taken := lots[i].Remaining
+		if taken > held-card.Quantity {
+			taken = held - card.Quantity
+		}
+		lots[i].Remaining -= taken
+		held -= taken
+	}
+	return lots
+}
+
+// sortLots orders lots oldest first like the repository does, with lots that
+// are not stored yet after stored lots of the same date.
+func sortLots(lots []entity.PurchaseLot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].AcquiredAt, lots[j].AcquiredAt
+		if (a == nil) != (b == nil) {
+			return a == nil
+		}
+		if a != nil && !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		if (lots[i].ID == 0) != (lots[j].ID == 0) {
+			return lots[j].ID == 0
+		}
+		return lots[i].ID < lots[j].ID
+	})
+}
+
+func hasNewLots(lots []entity.PurchaseLot) bool {
+	for _, lot := range lots {
+		if lot.ID == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// allocateLots takes quantity copies out of lots, which are ordered oldest
+// first, and returns what was taken from each lot. FIFO takes the oldest
+// copies and LIFO the newest, each at the lot's held cost. Average cost takes
+// the oldest copies, each at the average cost of all copies held, and
+// revalues the copies left over to that average so the pool keeps its cost.
+func allocateLots(lots []entity.PurchaseLot, quantity int, method entity.CostMethod) []entity.LotAllocation {
+	order := make([]int, 0, len(lots))
+	for i := range lots {
+		order = append(order, i)
+	}
+	if method == entity.CostMethodLIFO {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+
+	var average float64
+	if method == entity.CostMethodAverage {
+		held := 0
+		var cost float64
+		for _, lot := range lots {
+			held += lot.Remaining
+			cost += lot.HeldUnitCost * float64(lot.Remaining)
+		}
+		if held > 0 {
+			average = math.Round(cost/float64(held)*10000) / 10000
+		}
+	}
+
+	var allocations []entity.LotAllocation
+	for _, i := range order {
+		if quantity == 0 {
+			break
+		}
+		lot := &lots[i]
+		if lot.Remaining == 0 {
+			continue
+		}
+
+		taken := lot.Remaining
+		if taken > quantity {
+			taken = quantity
+		}
+		lot.Remaining -= taken
+		quantity -= taken
+
+		unitCost := lot.HeldUnitCost
+		if method == entity.CostMethodAverage {
+			unitCost = average
+		}
+		allocations = append(allocations, entity.LotAllocation{
+			LotID:      lot.ID,
+			Quantity:   taken,
+			UnitCost:   unitCost,
+			AcquiredAt: lot.AcquiredAt,
+		})
+	}
+
+	if method == entity.CostMethodAverage {
+		for i := range lots {
+			if lots[i].Remaining > 0 {
+				lots[i].HeldUnitCost = average
+			}
+		}
+	}
+	return allocations
+}
+
+// applyLots sets the buying price of a card to the average held cost of the
+// copies still in its lots, and the bought date to the oldest of them.
+func applyLots(card *entity.Card, lots []entity.PurchaseLot) {
+	held := 0
+	var cost float64
+	var bought *time.Time
+	for _, lot := range lots {
+		if lot.Remaining == 0 {
+			continue
+		}
+		held += lot.Remaining
+		cost += lot.HeldUnitCost * float64(lot.Remaining)
+		if lot.AcquiredAt != nil && (bought == nil || lot.AcquiredAt.Before(*bought)) {
+			bought = lot.AcquiredAt
+		}
+	}
+
+	if held > 0 {
+		card.BuyingPrice = roundCents(cost / float64(held))
+	}
+	if bought != nil {
+		card.BoughtDate = bought
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// validCostMethod reports whether method is one of entity.CostMethods.
+func validCostMethod(method entity.CostMethod) bool {
+	for _, m := range entity.CostMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
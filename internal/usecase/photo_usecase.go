@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+// maxPhotosPerCard bounds how many photos one card can have.
+const maxPhotosPerCard = 20
+
+type PhotoUseCase struct {
+	photoRepo repository.CardPhotoRepository
+	cardRepo  repository.CardRepository
+	blobStore repository.BlobStore
+}
+
+func NewPhotoUseCase(photoRepo repository.CardPhotoRepository, cardRepo repository.CardRepository, blobStore repository.BlobStore) *PhotoUseCase {
+	return &PhotoUseCase{photoRepo: photoRepo, cardRepo: cardRepo, blobStore: blobStore}
+}
+
+type UploadPhotoInput struct {
+	UserID  uint
+	CardID  uint
+	Side    entity.PhotoSide
+	Caption string
+	File    io.Reader
+}
+
+// photoBlobKey returns the blob store key of one rendition of a photo. Only
+// the original and the normal size thumbnail are stored.
+func photoBlobKey(photo *entity.CardPhoto, size entity.ImageSize) string {
+	return fmt.Sprintf("photos/%d/%d/%s", photo.UserID, photo.ID, size)
+}
+
+// UploadPhoto adds a photo to a card. The row is created first to name the
+// files; if storing them fails, the row and any stored file are removed
+// again.
+func (uc *PhotoUseCase) UploadPhoto(ctx context.Context, input UploadPhotoInput) (*entity.CardPhoto, error) {
+	if !input.Side.Valid() {
+		return nil, errors.New("unknown photo side")
+	}
+	caption := strings.TrimSpace(input.Caption)
+	if len(caption) > 255 {
+		return nil, errors.New("caption must be at most 255 characters")
+	}
+
+	if _, err := uc.cardRepo.FindByID(ctx, input.CardID, input.UserID); err != nil {
+		return nil, err
+	}
+	count, err := uc.photoRepo.CountByCardID(ctx, input.CardID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPhotosPerCard {
+		return nil, fmt.Errorf("a card can have at most %d photos", maxPhotosPerCard)
+	}
+
+	file, err := readImageFile(input.File)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := file.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	photo := &entity.CardPhoto{
+		UserID:      input.UserID,
+		CardID:      input.CardID,
+		Side:        input.Side,
+		Caption:     caption,
+		ContentType: file.contentType,
+		Width:       file.config.Width,
+		Height:      file.config.Height,
+		Size:        int64(len(file.data)),
+	}
+	if err := uc.photoRepo.Create(ctx, photo); err != nil {
+		return nil, err
+	}
+
+	err = uc.blobStore.Put(ctx, photoBlobKey(photo, entity.ImageSizeOriginal), bytes.NewReader(file.data))
+	if err == nil {
+		err = putThumbnail(ctx, uc.blobStore, photoBlobKey(photo, entity.ImageSizeNormal), decoded, entity.ThumbnailWidths[entity.ImageSizeNormal])
+	}
+	if err != nil {
+		if cleanupErr := uc.removePhoto(ctx, photo); cleanupErr != nil {
+			return nil, errors.Join(err, cleanupErr)
+		}
+		return nil, err
+	}
+	return photo, nil
+}
+
+// CardPhotos returns the photos of a card in upload order.
+func (uc *PhotoUseCase) CardPhotos(ctx context.Context, cardID uint, userID uint) ([]entity.CardPhoto, error) {
+	return uc.photoRepo.FindByCardID(ctx, cardID, userID)
+}
+
+func (uc *PhotoUseCase) GetPhoto(ctx context.Context, id uint, userID uint) (*entity.CardPhoto, error) {
+	return uc.photoRepo.FindByID(ctx, id, userID)
+}
+
+// DeletePhoto removes a photo together with its files.
+func (uc *PhotoUseCase) DeletePhoto(ctx context.Context, id uint, userID uint) error {
+	photo, err := uc.photoRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return uc.removePhoto(ctx, photo)
+}
+
+// removePhoto deletes the files of a photo and then its row, so a failure
+// never leaves a row pointing at missing files.
+func (uc *PhotoUseCase) removePhoto(ctx context.Context, photo *entity.CardPhoto) error {
+	if err := deletePhotoFiles(ctx, uc.blobStore, []entity.CardPhoto{*photo}); err != nil {
+		return err
+	}
+	return uc.photoRepo.Delete(ctx, photo.ID, photo.UserID)
+}
+
+// OpenPhoto opens the original or the normal size thumbnail of a photo.
+func (uc *PhotoUseCase) OpenPhoto(ctx context.Context, id uint, userID uint, size entity.ImageSize) (*ImageBlob, error) {
+	if size != entity.ImageSizeOriginal && size != entity.ImageSizeNormal {
+		return nil, fmt.Errorf("unknown photo size %q", size)
+	}
+
+	photo, err := uc.photoRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := photo.ContentType
+	if size != entity.ImageSizeOriginal {
+		contentType = "image/jpeg"
+	}
+
+	body, err := uc.blobStore.Get(ctx, photoBlobKey(photo, size))
+	if err != nil {
+		return nil, err
+	}
+	return &ImageBlob{Body: body, ContentType: contentType, ETag: fmt.Sprintf("photo-%d-%s", photo.ID, size)}, nil
+}
+
+// photoSizes are the renditions stored for every photo.
+var photoSizes = []entity.ImageSize{entity.ImageSizeNormal, entity.ImageSizeOriginal}
+
+// deletePhotoFiles removes every stored rendition of photos.
+func deletePhotoFiles(ctx context.Context, blobStore repository.BlobStore, photos []entity.CardPhoto) error {
+	for i := range photos {
+		for _, size := range photoSizes {
+			if err := blobStore.Delete(ctx, photoBlobKey(&photos[i], size)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// movePhotoFiles moves the files of photos that changed owner, given as they
+// were before, to the keys of the new owner. Each file is copied before the
+// old one is removed.
+func movePhotoFiles(ctx context.Context, blobStore repository.BlobStore, photos []entity.CardPhoto, toUserID uint) error {
+	for i := range photos {
+		moved := photos[i]
+		moved.UserID = toUserID
+		for _, size := range photoSizes {
+			if err := copyBlob(ctx, blobStore, photoBlobKey(&photos[i], size), photoBlobKey(&moved, size)); err != nil {
+				return err
+			}
+		}
+	}
+	return deletePhotoFiles(ctx, blobStore, photos)
+}
+
+func copyBlob(ctx context.Context, blobStore repository.BlobStore, from, to string) error {
+	body, err := blobStore.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return blobStore.Put(ctx, to, body)
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+// maxOpenedCards bounds how many card lines opening one product may create.
+const maxOpenedCards = 500
+
+// openedCardLine matches one line of an opened card list:
+// "[qty[x]] name [(SET)] [#number] [*F*]", e.g. "2x Lightning Bolt (M10) #146".
+var openedCardLine = regexp.MustCompile(`^(?:(\d+)x?\s+)?(.+?)(?:\s+\(([A-Za-z0-9]{2,6})\))?(?:\s+#(\S+))?(\s+\*F\*)?$`)
+
+type SealedUseCase struct {
+	sealedRepo  repository.SealedProductRepository
+	auditRepo   repository.AuditRepository
+	catalogRepo repository.CatalogRepository
+}
+
+func NewSealedUseCase(sealedRepo repository.SealedProductRepository, auditRepo repository.AuditRepository, catalogRepo repository.CatalogRepository) *SealedUseCase {
+	return &SealedUseCase{sealedRepo: sealedRepo, auditRepo: auditRepo, catalogRepo: catalogRepo}
+}
+
+type SealedProductInput struct {
+	ID         uint
+	UserID     uint
+	Name       string
+	SetCode    string
+	Type       entity.SealedProductType
+	Quantity   int
+	UnitCost   float64
+	BoughtDate *time.Time
+	SellDate   *time.Time
+	Notes      string
+}
+
+// OpenProductInput opens one unit of a sealed product. Cards lists what was
+// inside, one card per line in the format described at openedCardLine; the
+// set defaults to the product's set.
+type OpenProductInput struct {
+	UserID    uint
+	ProductID uint
+	Cards     string
+	Language  string
+}
+
+// SealedInventory lists a user's sealed products with the totals of the ones
+// still held.
+type SealedInventory struct {
+	Products  []entity.SealedProduct
+	HeldUnits int
+	HeldCost  float64
+}
+
+func validateSealedInput(input SealedProductInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("product name is required")
+	}
+	if !input.Type.Valid() {
+		return errors.New("unknown product type")
+	}
+	if input.Quantity < 0 {
+		return errors.New("quantity cannot be negative")
+	}
+	if input.UnitCost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+	return nil
+}
+
+func (uc *SealedUseCase) CreateProduct(ctx context.Context, input SealedProductInput) (*entity.SealedProduct, error) {
+	if err := validateSealedInput(input); err != nil {
+		return nil, err
+	}
+	if input.Quantity < 1 {
+		return nil, errors.New("quantity must be at least 1")
+	}
+
+	product := &entity.SealedProduct{UserID: input.UserID}
+	applySealedInput(product, input)
+
+	if err := uc.sealedRepo.Create(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// UpdateProduct changes a product. The quantity may drop to zero once every
+// unit has been opened; the opened count is only changed by OpenProduct.
+func (uc *SealedUseCase) UpdateProduct(ctx context.Context, input SealedProductInput) error {
+	if err := validateSealedInput(input); err != nil {
+		return err
+	}
+
+	product, err := uc.sealedRepo.FindByID(ctx, input.ID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	applySealedInput(product, input)
+	return uc.sealedRepo.Update(ctx, product)
+}
+
+func applySealedInput(product *entity.SealedProduct, input SealedProductInput) {
+	product.Name = strings.TrimSpace(input.Name)
+	product.SetCode = strings.TrimSpace(input.SetCode)
+	product.Type = input.Type
+	product.Quantity = input.Quantity
+	product.UnitCost = input.UnitCost
+	product.BoughtDate = input.BoughtDate
+	product.SellDate = input.SellDate
+	product.Notes = strings.TrimSpace(input.Notes)
+}
+
+func (uc *SealedUseCase) DeleteProduct(ctx context.Context, id uint, userID uint) error {
+	return uc.sealedRepo.Delete(ctx, id, userID)
+}
+
+func (uc *SealedUseCase) GetProduct(ctx context.Context, id uint, userID uint) (*entity.SealedProduct, error) {
+	return uc.sealedRepo.FindByID(ctx, id, userID)
+}
+
+func (uc *SealedUseCase) ListProducts(ctx context.Context, userID uint) (*SealedInventory, error) {
+	products, err := uc.sealedRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &SealedInventory{Products: products}
+	for i := range products {
+		if products[i].SellDate == nil {
+			inventory.HeldUnits += products[i].Quantity
+			inventory.HeldCost += products[i].HeldCost()
+		}
+	}
+	return inventory, nil
+}
+
+// OpenProduct opens one unit of a sealed product and adds the cards it
+// contained to the collection. The unit's cost is spread evenly over every
+// copy opened, rounded to cents, and the cards take the product's bought
+// date.
+func (uc *SealedUseCase) OpenProduct(ctx context.Context, input OpenProductInput) ([]entity.Card, error) {
+	product, err := uc.sealedRepo.FindByID(ctx, input.ProductID, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Type.Openable() {
+		return nil, fmt.Errorf("%s products do not contain cards", strings.ToLower(product.Type.Label()))
+	}
+	if product.SellDate != nil {
+		return nil, errors.New("sold products cannot be opened")
+	}
+	if product.Quantity < 1 {
+		return nil, repository.ErrNothingToOpen
+	}
+
+	cards, err := ParseOpenedCards(input.Cards)
+	if err != nil {
+		return nil, err
+	}
+	language, err := normalizeLanguage(input.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := 0
+	for i := range cards {
+		copies += cards[i].Quantity
+	}
+	unitPrice := roundCents(product.UnitCost / float64(copies))
+
+	opened := make([]*entity.Card, len(cards))
+	for i := range cards {
+		card := &cards[i]
+		opened[i] = card
+		card.UserID = input.UserID
+		if card.SetCode == "" {
+			card.SetCode = product.SetCode
+		}
+		card.Language = language
+		card.BuyingPrice = unitPrice
+		card.BoughtDate = product.BoughtDate
+		card.Version = 1
+	}
+	if err := fillMetadata(ctx, uc.catalogRepo, opened...); err != nil {
+		return nil, err
+	}
+
+	if err := uc.sealedRepo.Open(ctx, product, cards); err != nil {
+		return nil, err
+	}
+
+	for i := range cards {
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionCreate, entity.AuditSourceWeb, input.UserID, nil, &cards[i])
+	}
+	return cards, nil
+}
+
+// ParseOpenedCards reads a list of opened cards, one per line as
+// "[qty[x]] name [(SET)] [#number] [*F*]". Blank lines are skipped.
+func ParseOpenedCards(text string) ([]entity.Card, error) {
+	var cards []entity.Card
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		match := openedCardLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: cannot read %q", lineNumber, line)
+		}
+
+		quantity := 1
+		if match[1] != "" {
+			quantity, _ = strconv.Atoi(match[1])
+		}
+		if quantity < 1 {
+			return nil, fmt.Errorf("line %d: quantity must be at least 1", lineNumber)
+		}
+
+		cards = append(cards, entity.Card{
+			CardName:        match[2],
+			SetCode:         match[3],
+			CollectorNumber: match[4],
+			Foil:            match[5] != "",
+			Quantity:        quantity,
+		})
+		if len(cards) > maxOpenedCards {
+			return nil, fmt.Errorf("at most %d card lines can be added at once", maxOpenedCards)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, errors.New("list at least one card that was inside")
+	}
+	return cards, nil
+}
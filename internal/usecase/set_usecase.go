@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type SetUseCase struct {
+	catalogRepo repository.CatalogRepository
+	cardRepo    repository.CardRepository
+}
+
+func NewSetUseCase(catalogRepo repository.CatalogRepository, cardRepo repository.CardRepository) *SetUseCase {
+	return &SetUseCase{catalogRepo: catalogRepo, cardRepo: cardRepo}
+}
+
+type RarityProgress struct {
+	Rarity entity.Rarity
+	Owned  int
+	Total  int
+}
+
+// SetProgress counts owned collector numbers against the catalog size of a
+// set. In master set mode every printed finish of a collector number counts
+// separately. Set is nil for set codes that are not in the catalog; Total is
+// zero then.
+type SetProgress struct {
+	Code     string
+	Set      *entity.CatalogSet
+	Owned    int
+	Total    int
+	Rarities []RarityProgress
+}
+
+func (p SetProgress) Name() string {
+	if p.Set == nil {
+		return strings.ToUpper(p.Code)
+	}
+	return p.Set.Name
+}
+
+// Percent returns the completion rounded down to a whole percent.
+func (p SetProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Owned * 100 / p.Total
+}
+
+func (p SetProgress) Complete() bool {
+	return p.Total > 0 && p.Owned >= p.Total
+}
+
+// SetCardStatus is one catalog printing with the copies the user owns of it.
+type SetCardStatus struct {
+	Card    entity.CatalogCard
+	Nonfoil int
+	Foil    int
+	// MissingNonfoil and MissingFoil are only set in master set mode, and
+	// only for finishes that were printed.
+	MissingNonfoil bool
+	MissingFoil    bool
+	Missing        bool
+}
+
+type SetDetail struct {
+	SetProgress
+	Cards []SetCardStatus
+	// Unknown lists owned collector numbers the catalog does not know, which
+	// usually points at a typo in the card's collector number.
+	Unknown []string
+}
+
+// ownedFinishes holds unsold copies of one collector number by finish.
+type ownedFinishes struct {
+	nonfoil int
+	foil    int
+}
+
+// ownedBySet groups the user's printings by lower-case set code and
+// collector number.
+func (uc *SetUseCase) ownedBySet(ctx context.Context, userID uint) (map[string]map[string]*ownedFinishes, error) {
+	printings, err := uc.cardRepo.OwnedPrintings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]map[string]*ownedFinishes)
+	for _, printing := range printings {
+		code := strings.ToLower(strings.TrimSpace(printing.SetCode))
+		number := strings.TrimSpace(printing.CollectorNumber)
+		if code == "" || number == "" || printing.Quantity <= 0 {
+			continue
+		}
+
+		if owned[code] == nil {
+			owned[code] = make(map[string]*ownedFinishes)
+		}
+		finishes := owned[code][number]
+		if finishes == nil {
+			finishes = &ownedFinishes{}
+			owned[code][number] = finishes
+		}
+		if printing.Foil {
+			finishes.foil += printing.Quantity
+		} else {
+			finishes.nonfoil += printing.Quantity
+		}
+	}
+	return owned, nil
+}
+
+// Overview returns the completion of every set the user owns cards from,
+// newest catalog set first. Sets missing from the catalog come last.
+func (uc *SetUseCase) Overview(ctx context.Context, userID uint, master bool) ([]SetProgress, error) {
+	owned, err := uc.ownedBySet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(owned) == 0 {
+		return nil, nil
+	}
+
+	codes := make([]string, 0, len(owned))
+	for code := range owned {
+		codes = append(codes, code)
+	}
+	cards, err := uc.catalogRepo.FindCardsBySet(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	cardsBySet := make(map[string][]entity.CatalogCard)
+	for _, card := range cards {
+		cardsBySet[card.SetCode] = append(cardsBySet[card.SetCode], card)
+	}
+
+	sets, err := uc.catalogRepo.FindSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var overview []SetProgress
+	for i := range sets {
+		code := sets[i].Code
+		if owned[code] == nil {
+			continue
+		}
+		progress, _, _ := setCompletion(cardsBySet[code], owned[code], master)
+		progress.Code = code
+		progress.Set = &sets[i]
+		overview = append(overview, progress)
+		delete(owned, code)
+	}
+
+	uncatalogued := make([]string, 0, len(owned))
+	for code := range owned {
+		uncatalogued = append(uncatalogued, code)
+	}
+	sort.Strings(uncatalogued)
+	for _, code := range uncatalogued {
+		overview = append(overview, SetProgress{Code: code, Owned: len(owned[code])})
+	}
+
+	return overview, nil
+}
+
+// Detail lists every printing of a catalog set with the copies the user
+// owns, in collector number order.
+func (uc *SetUseCase) Detail(ctx context.Context, userID uint, code string, master bool) (*SetDetail, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	set, err := uc.catalogRepo.FindSet(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := uc.catalogRepo.FindCardsBySet(ctx, []string{code})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return collectorNumberLess(cards[i].CollectorNumber, cards[j].CollectorNumber)
+	})
+
+	owned, err := uc.ownedBySet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, statuses, unknown := setCompletion(cards, owned[code], master)
+	progress.Code = code
+	progress.Set = set
+
+	return &SetDetail{SetProgress: progress, Cards: statuses, Unknown: unknown}, nil
+}
+
+// Translations looks up the catalog translation of cards in a language other
+// than English, keyed by card ID. Cards without one are left out, as are all
+// cards when no catalog is loaded.
+func (uc *SetUseCase) Translations(ctx context.Context, cards []entity.Card) (map[uint]entity.CatalogTranslation, error) {
+	translations, err := uc.catalogRepo.FindTranslations(ctx, cards)
+	if err != nil {
+		return nil, err
+	}
+
+	byPrinting := make(map[string]entity.CatalogTranslation, len(translations))
+	for _, translation := range translations {
+		byPrinting[printingKey(translation.SetCode, translation.CollectorNumber)+"|"+translation.Lang] = translation
+	}
+
+	result := make(map[uint]entity.CatalogTranslation)
+	for _, card := range cards {
+		if translation, ok := byPrinting[printingKey(card.SetCode, card.CollectorNumber)+"|"+card.Language]; ok {
+			result[card.ID] = translation
+		}
+	}
+	return result, nil
+}
+
+// setCompletion compares a set's catalog printings with the owned copies.
+// It also returns owned collector numbers that are not in the catalog.
+func setCompletion(cards []entity.CatalogCard, owned map[string]*ownedFinishes, master bool) (SetProgress, []SetCardStatus, []string) {
+	var progress SetProgress
+	rarities := make(map[entity.Rarity]*RarityProgress)
+	statuses := make([]SetCardStatus, 0, len(cards))
+	known := make(map[string]bool, len(cards))
+
+	for _, card := range cards {
+		known[card.CollectorNumber] = true
+		status := SetCardStatus{Card: card}
+		if finishes := owned[card.CollectorNumber]; finishes != nil {
+			status.Nonfoil = finishes.nonfoil
+			status.Foil = finishes.foil
+		}
+
+		total, have := 1, 0
+		if status.Nonfoil+status.Foil > 0 {
+			have = 1
+		}
+		if master {
+			total, have = 0, 0
+			// Printings without finish data are treated as nonfoil only
+			if card.Nonfoil || !card.Foil {
+				total++
+				if status.Nonfoil > 0 {
+					have++
+				} else {
+					status.MissingNonfoil = true
+				}
+			}
+			if card.Foil {
+				total++
+				if status.Foil > 0 {
+					have++
+				} else {
+					status.MissingFoil = true
+				}
+			}
+		}
+		status.Missing = have < total
+
+		rarity := rarities[card.Rarity]
+		if rarity == nil {
+			rarity = &RarityProgress{Rarity: card.Rarity}
+			rarities[card.Rarity] = rarity
+		}
+		rarity.Total += total
+		rarity.Owned += have
+		progress.Total += total
+		progress.Owned += have
+
+		statuses = append(statuses, status)
+	}
+
+	for _, rarity := range entity.Rarities {
+		if r := rarities[rarity]; r != nil {
+			progress.Rarities = append(progress.Rarities, *r)
+			delete(rarities, rarity)
+		}
+	}
+	// Rarities the catalog uses but this code does not know about
+	for _, r := range rarities {
+		progress.Rarities = append(progress.Rarities, *r)
+	}
+
+	var unknown []string
+	for number := range owned {
+		if !known[number] {
+			unknown = append(unknown, number)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return collectorNumberLess(unknown[i], unknown[j])
+	})
+
+	return progress, statuses, unknown
+}
+
+// collectorNumberLess orders collector numbers by their leading number, so
+// "2" sorts before "10" and "10a". Numbers without leading digits, such as
+// promo markers, sort after all others.
+func collectorNumberLess(a, b string) bool {
+	numberA, restA := splitCollectorNumber(a)
+	numberB, restB := splitCollectorNumber(b)
+	if numberA != numberB {
+		return numberA < numberB
+	}
+	return restA < restB
+}
+
+func splitCollectorNumber(number string) (int, string) {
+	digits := 0
+	for digits < len(number) && number[digits] >= '0' && number[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return int(^uint(0) >> 1), number
+	}
+	n, err := strconv.Atoi(number[:digits])
+	if err != nil {
+		return int(^uint(0) >> 1), number
+	}
+	return n, number[digits:]
+}
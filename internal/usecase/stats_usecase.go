@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+// topHoldingsLimit is how many of the most expensive holdings are listed.
+const topHoldingsLimit = 10
+
+type StatsUseCase struct {
+	cardRepo    repository.CardRepository
+	catalogRepo repository.CatalogRepository
+	sealedRepo  repository.SealedProductRepository
+}
+
+func NewStatsUseCase(cardRepo repository.CardRepository, catalogRepo repository.CatalogRepository, sealedRepo repository.SealedProductRepository) *StatsUseCase {
+	return &StatsUseCase{cardRepo: cardRepo, catalogRepo: catalogRepo, sealedRepo: sealedRepo}
+}
+
+// CollectionStats aggregates a user's whole collection. Copy counts and
+// distributions cover cards that are still held; spending covers every card
+// with a buying price, sold or not, and the unopened units of sealed
+// products. Opened units are counted through the cards they became. Buying
+// prices are per copy. HeldValue is the cost of the held cards and sealed
+// products together.
+type CollectionStats struct {
+	TotalCopies  int            `json:"total_copies"`
+	UniqueCards  int            `json:"unique_cards"`
+	HeldCopies   int            `json:"held_copies"`
+	SoldCopies   int            `json:"sold_copies"`
+	HeldCost     float64        `json:"held_cost"`
+	SealedUnits  int            `json:"sealed_units"`
+	SealedCost   float64        `json:"sealed_cost"`
+	HeldValue    float64        `json:"held_value"`
+	TotalSpend   float64        `json:"total_spend"`
+	UndatedSpend float64        `json:"undated_spend"`
+	SpendByMonth []MonthlySpend `json:"spend_by_month"`
+	BySet        []StatBucket   `json:"by_set"`
+	ByLanguage   []StatBucket   `json:"by_language"`
+	ByColor      []StatBucket   `json:"by_color"`
+	ByRarity     []StatBucket   `json:"by_rarity"`
+	ByType       []StatBucket   `json:"by_type"`
+	ByManaValue  []StatBucket   `json:"by_mana_value"`
+	TopHoldings  []Holding      `json:"top_holdings"`
+}
+
+// MonthlySpend is what was spent on cards and sealed products bought in one
+// month, e.g. "2024-03". Copies only counts cards.
+type MonthlySpend struct {
+	Month  string  `json:"month"`
+	Copies int     `json:"copies"`
+	Amount float64 `json:"amount"`
+}
+
+// StatBucket is one group of a distribution. Share is the percentage of
+// held copies that fall into it.
+type StatBucket struct {
+	Key    string  `json:"key"`
+	Label  string  `json:"label"`
+	Copies int     `json:"copies"`
+	Cost   float64 `json:"cost"`
+	Share  float64 `json:"share"`
+}
+
+type Holding struct {
+	CardID          uint    `json:"card_id"`
+	CardName        string  `json:"card_name"`
+	SetCode         string  `json:"set_code"`
+	CollectorNumber string  `json:"collector_number"`
+	Quantity        int     `json:"quantity"`
+	UnitPrice       float64 `json:"unit_price"`
+	Total           float64 `json:"total"`
+}
+
+// Stats computes the collection statistics. Color, rarity, type and mana
+// value come from the catalog metadata stored with each card; cards without
+// it are counted as unknown.
+func (uc *StatsUseCase) Stats(ctx context.Context, userID uint) (*CollectionStats, error) {
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	setNames, err := uc.setNames(ctx, cards)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CollectionStats{}
+	names := make(map[string]bool)
+	months := make(map[string]*MonthlySpend)
+	bySet := newBucketCounter()
+	byLanguage := newBucketCounter()
+	byColor := newBucketCounter()
+	byRarity := newBucketCounter()
+	byType := newBucketCounter()
+	byManaValue := newBucketCounter()
+	var held []entity.Card
+
+	addSpend := func(bought *time.Time, copies int, cost float64) {
+		stats.TotalSpend += cost
+		if bought == nil {
+			stats.UndatedSpend += cost
+			return
+		}
+		month := bought.Format("2006-01")
+		spend := months[month]
+		if spend == nil {
+			spend = &MonthlySpend{Month: month}
+			months[month] = spend
+		}
+		spend.Copies += copies
+		spend.Amount += cost
+	}
+
+	for _, card := range cards {
+		cost := card.BuyingPrice * float64(card.Quantity)
+		stats.TotalCopies += card.Quantity
+		addSpend(card.BoughtDate, card.Quantity, cost)
+
+		if card.SellDate != nil {
+			stats.SoldCopies += card.Quantity
+			continue
+		}
+
+		stats.HeldCopies += card.Quantity
+		stats.HeldCost += cost
+		names[strings.ToLower(strings.TrimSpace(card.CardName))] = true
+		held = append(held, card)
+
+		code := strings.ToLower(strings.TrimSpace(card.SetCode))
+		setLabel := "No set"
+		if code != "" {
+			setLabel = strings.ToUpper(code)
+			if name, ok := setNames[code]; ok {
+				setLabel = name
+			}
+		}
+		bySet.add(code, setLabel, card.Quantity, cost)
+
+		language := strings.TrimSpace(card.Language)
+		if known, ok := entity.FindLanguage(language); ok {
+			byLanguage.add(known.Code, known.Name, card.Quantity, cost)
+		} else if language == "" {
+			byLanguage.add("", "Unknown", card.Quantity, cost)
+		} else {
+			byLanguage.add(strings.ToLower(language), language, card.Quantity, cost)
+		}
+
+		if card.Known() {
+			color := colorGroup(card.Colors)
+			byColor.add(color, colorLabels[color], card.Quantity, cost)
+			byRarity.add(string(card.Rarity), rarityLabel(card.Rarity), card.Quantity, cost)
+			cardType := mainType(card.TypeLine)
+			byType.add(cardType, typeLabel(cardType), card.Quantity, cost)
+			manaValue := manaValueGroup(card.ManaValue)
+			byManaValue.add(manaValue, manaValue, card.Quantity, cost)
+		} else {
+			byColor.add("", "Unknown", card.Quantity, cost)
+			byRarity.add("", "Unknown", card.Quantity, cost)
+			byType.add("", "Unknown", card.Quantity, cost)
+			byManaValue.add("", "Unknown", card.Quantity, cost)
+		}
+	}
+
+	stats.UniqueCards = len(names)
+
+	products, err := uc.sealedRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		product := &products[i]
+		// Sealed units are not card copies, so they only add to the amount.
+		addSpend(product.BoughtDate, 0, product.UnitCost*float64(product.Quantity))
+
+		if product.SellDate == nil {
+			stats.SealedUnits += product.Quantity
+			stats.SealedCost += product.HeldCost()
+		}
+	}
+	stats.HeldValue = stats.HeldCost + stats.SealedCost
+
+	for _, spend := range months {
+		stats.SpendByMonth = append(stats.SpendByMonth, *spend)
+	}
+	sort.Slice(stats.SpendByMonth, func(i, j int) bool {
+		return stats.SpendByMonth[i].Month < stats.SpendByMonth[j].Month
+	})
+
+	stats.BySet = bySet.byCopies(stats.HeldCopies)
+	stats.ByLanguage = byLanguage.byCopies(stats.HeldCopies)
+	stats.ByColor = byColor.inOrder(stats.HeldCopies, colorGroups)
+	rarityOrder := make([]string, 0, len(entity.Rarities))
+	for _, rarity := range entity.Rarities {
+		rarityOrder = append(rarityOrder, string(rarity))
+	}
+	stats.ByRarity = byRarity.inOrder(stats.HeldCopies, rarityOrder)
+	stats.ByType = byType.inOrder(stats.HeldCopies, cardTypes)
+	stats.ByManaValue = byManaValue.inOrder(stats.HeldCopies, manaValueGroups)
+
+	sort.SliceStable(held, func(i, j int) bool {
+		return held[i].BuyingPrice > held[j].BuyingPrice
+	})
+	for _, card := range held {
+		if len(stats.TopHoldings) == topHoldingsLimit || card.BuyingPrice <= 0 {
+			break
+		}
+		stats.TopHoldings = append(stats.TopHoldings, Holding{
+			CardID:          card.ID,
+			CardName:        card.CardName,
+			SetCode:         card.SetCode,
+			CollectorNumber: card.CollectorNumber,
+			Quantity:        card.Quantity,
+			UnitPrice:       card.BuyingPrice,
+			Total:           card.BuyingPrice * float64(card.Quantity),
+		})
+	}
+
+	return stats, nil
+}
+
+// setNames returns the catalog names of the sets the cards belong to.
+func (uc *StatsUseCase) setNames(ctx context.Context, cards []entity.Card) (map[string]string, error) {
+	seen := make(map[string]bool)
+	for _, card := range cards {
+		seen[strings.ToLower(strings.TrimSpace(card.SetCode))] = true
+	}
+
+	sets, err := uc.catalogRepo.FindSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setNames := make(map[string]string)
+	for _, set := range sets {
+		if seen[set.Code] {
+			setNames[set.Code] = set.Name
+		}
+	}
+	return setNames, nil
+}
+
+func printingKey(setCode, collectorNumber string) string {
+	return strings.ToLower(strings.TrimSpace(setCode)) + "/" + strings.TrimSpace(collectorNumber)
+}
+
+// colorGroups lists the color distribution groups in display order; the
+// unknown group for cards missing from the catalog is keyed "".
+var colorGroups = []string{"W", "U", "B", "R", "G", "multicolor", "colorless", ""}
+
+var colorLabels = map[string]string{
+	"W":          "White",
+	"U":          "Blue",
+	"B":          "Black",
+	"R":          "Red",
+	"G":          "Green",
+	"multicolor": "Multicolor",
+	"colorless":  "Colorless",
+	"":           "Unknown",
+}
+
+func rarityLabel(rarity entity.Rarity) string {
+	if rarity == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(string(rarity[:1])) + string(rarity[1:])
+}
+
+// cardTypes lists the card types of the type distribution in the order
+// mainType prefers them, so an artifact creature counts as a creature.
+var cardTypes = []string{"Creature", "Planeswalker", "Battle", "Land", "Instant", "Sorcery", "Artifact", "Enchantment", "Other", ""}
+
+// mainType returns the card type a type line is grouped under. Only the
+// front face of a card with several faces counts. Printings loaded before
+// the catalog had type lines have an empty one, which stays unknown.
+func mainType(typeLine string) string {
+	if typeLine == "" {
+		return ""
+	}
+	front, _, _ := strings.Cut(typeLine, "//")
+	types, _, _ := strings.Cut(front, "—")
+	words := strings.Fields(types)
+	for _, cardType := range cardTypes {
+		for _, word := range words {
+			if word == cardType {
+				return cardType
+			}
+		}
+	}
+	return "Other"
+}
+
+func typeLabel(cardType string) string {
+	if cardType == "" {
+		return "Unknown"
+	}
+	return cardType
+}
+
+// manaValueGroups lists the mana value distribution groups in display
+// order.
+var manaValueGroups = []string{"0", "1", "2", "3", "4", "5", "6", "7+", ""}
+
+func manaValueGroup(manaValue float64) string {
+	if manaValue >= 7 {
+		return "7+"
+	}
+	return strconv.Itoa(int(manaValue))
+}
+
+func colorGroup(colors string) string {
+	switch len(colors) {
+	case 0:
+		return "colorless"
+	case 1:
+		return colors
+	default:
+		return "multicolor"
+	}
+}
+
+// bucketCounter accumulates a distribution, keeping the label of the first
+// value seen for each key.
+type bucketCounter struct {
+	buckets map[string]*StatBucket
+}
+
+func newBucketCounter() *bucketCounter {
+	return &bucketCounter{buckets: make(map[string]*StatBucket)}
+}
+
+func (b *bucketCounter) add(key, label string, copies int, cost float64) {
+	bucket := b.buckets[key]
+	if bucket == nil {
+		bucket = &StatBucket{Key: key, Label: label}
+		b.buckets[key] = bucket
+	}
+	bucket.Copies += copies
+	bucket.Cost += cost
+}
+
+// byCopies returns the buckets with the most copies first.
+func (b *bucketCounter) byCopies(total int) []StatBucket {
+	buckets := make([]StatBucket, 0, len(b.buckets))
+	for _, bucket := range b.buckets {
+		buckets = append(buckets, b.withShare(*bucket, total))
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Copies != buckets[j].Copies {
+			return buckets[i].Copies > buckets[j].Copies
+		}
+		return buckets[i].Label < buckets[j].Label
+	})
+	return buckets
+}
+
+// inOrder returns the buckets in the given key order, followed by any keys
+// the order does not mention.
+func (b *bucketCounter) inOrder(total int, order []string) []StatBucket {
+	buckets := make([]StatBucket, 0, len(b.buckets))
+	listed := make(map[string]bool, len(order))
+	for _, key := range order {
+		listed[key] = true
+		if bucket := b.buckets[key]; bucket != nil {
+			buckets = append(buckets, b.withShare(*bucket, total))
+		}
+	}
+
+	var rest []StatBucket
+	for key, bucket := range b.buckets {
+		if !listed[key] {
+			rest = append(rest, b.withShare(*bucket, total))
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i].Key < rest[j].Key })
+
+	return append(buckets, rest...)
+}
+
+func (b *bucketCounter) withShare(bucket StatBucket, total int) StatBucket {
+	if total > 0 {
+		bucket.Share = float64(bucket.Copies) * 100 / float64(total)
+	}
+	return bucket
+}
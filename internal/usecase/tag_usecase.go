@@ -0,0 +1,405 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+const (
+	maxTagNameLength   = 50
+	maxFieldNameLength = 50
+	maxFieldValue      = 255
+	maxFieldOptions    = 1000
+)
+
+var (
+	tagColorPattern  = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	fieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// TagUseCase manages the tags and custom fields users put on their cards.
+type TagUseCase struct {
+	tagRepo   repository.TagRepository
+	fieldRepo repository.CustomFieldRepository
+	cardRepo  repository.CardRepository
+}
+
+func NewTagUseCase(tagRepo repository.TagRepository, fieldRepo repository.CustomFieldRepository, cardRepo repository.CardRepository) *TagUseCase {
+	return &TagUseCase{tagRepo: tagRepo, fieldRepo: fieldRepo, cardRepo: cardRepo}
+}
+
+// TagInput creates or changes a tag. An empty Color uses
+// entity.DefaultTagColor.
+type TagInput struct {
+	UserID uint
+	Name   string
+	Color  string
+}
+
+// CustomFieldInput creates or changes a custom field. Options lists the
+// choices of an enum field, one per line. The kind of an existing field
+// cannot be changed, so it is ignored by UpdateField.
+type CustomFieldInput struct {
+	UserID  uint
+	Name    string
+	Kind    entity.CustomFieldKind
+	Options string
+}
+
+// SetCardTagsInput replaces the tags and custom field values of a card.
+// Fields maps custom field IDs to the value as entered; empty values are
+// removed.
+type SetCardTagsInput struct {
+	UserID uint
+	CardID uint
+	TagIDs []uint
+	Fields map[uint]string
+}
+
+func (uc *TagUseCase) ListTags(ctx context.Context, userID uint) ([]entity.Tag, error) {
+	return uc.tagRepo.FindByUserID(ctx, userID)
+}
+
+func (uc *TagUseCase) CreateTag(ctx context.Context, input TagInput) (*entity.Tag, error) {
+	tag := &entity.Tag{UserID: input.UserID}
+	if err := uc.applyTagInput(ctx, tag, input); err != nil {
+		return nil, err
+	}
+	if err := uc.tagRepo.Create(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (uc *TagUseCase) UpdateTag(ctx context.Context, id uint, input TagInput) error {
+	tag, err := uc.tagRepo.FindByID(ctx, id, input.UserID)
+	if err != nil {
+		return err
+	}
+	if err := uc.applyTagInput(ctx, tag, input); err != nil {
+		return err
+	}
+	return uc.tagRepo.Update(ctx, tag)
+}
+
+// DeleteTag removes a tag and takes it off every card.
+func (uc *TagUseCase) DeleteTag(ctx context.Context, id uint, userID uint) error {
+	return uc.tagRepo.Delete(ctx, id, userID)
+}
+
+func (uc *TagUseCase) applyTagInput(ctx context.Context, tag *entity.Tag, input TagInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return errors.New("tag name is required")
+	}
+	if len(name) > maxTagNameLength {
+		return fmt.Errorf("tag name must be at most %d characters", maxTagNameLength)
+	}
+
+	color := strings.ToLower(strings.TrimSpace(input.Color))
+	if color == "" {
+		color = entity.DefaultTagColor
+	}
+	if !tagColorPattern.MatchString(color) {
+		return errors.New("tag color must be a hex color such as #1e90ff")
+	}
+
+	tags, err := uc.tagRepo.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+	for _, other := range tags {
+		if other.ID != tag.ID && strings.EqualFold(other.Name, name) {
+			return errors.New("a tag with this name already exists")
+		}
+	}
+
+	tag.Name = name
+	tag.Color = color
+	return nil
+}
+
+func (uc *TagUseCase) ListFields(ctx context.Context, userID uint) ([]entity.CustomField, error) {
+	return uc.fieldRepo.FindByUserID(ctx, userID)
+}
+
+func (uc *TagUseCase) CreateField(ctx context.Context, input CustomFieldInput) (*entity.CustomField, error) {
+	if !validFieldKind(input.Kind) {
+		return nil, errors.New("unknown custom field kind")
+	}
+
+	field := &entity.CustomField{UserID: input.UserID, Kind: input.Kind}
+	if err := uc.applyFieldInput(ctx, field, input); err != nil {
+		return nil, err
+	}
+	if err := uc.fieldRepo.Create(ctx, field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// UpdateField renames a custom field or changes the choices of an enum
+// field. Values stored for a choice that was removed are kept.
+func (uc *TagUseCase) UpdateField(ctx context.Context, id uint, input CustomFieldInput) error {
+	field, err := uc.fieldRepo.FindByID(ctx, id, input.UserID)
+	if err != nil {
+		return err
+	}
+	if err := uc.applyFieldInput(ctx, field, input); err != nil {
+		return err
+	}
+	return uc.fieldRepo.Update(ctx, field)
+}
+
+// DeleteField removes a custom field together with its values.
+func (uc *TagUseCase) DeleteField(ctx context.Context, id uint, userID uint) error {
+	return uc.fieldRepo.Delete(ctx, id, userID)
+}
+
+func (uc *TagUseCase) applyFieldInput(ctx context.Context, field *entity.CustomField, input CustomFieldInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return errors.New("field name is required")
+	}
+	if len(name) > maxFieldNameLength {
+		return fmt.Errorf("field name must be at most %d characters", maxFieldNameLength)
+	}
+	if !fieldNamePattern.MatchString(name) {
+		return errors.New("field name must start with a letter and contain only letters, digits and underscores")
+	}
+
+	fields, err := uc.fieldRepo.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+	for _, other := range fields {
+		if other.ID != field.ID && strings.EqualFold(other.Name, name) {
+			return errors.New("a field with this name already exists")
+		}
+	}
+
+	field.Name = name
+	field.Options = ""
+	if field.Kind == entity.CustomFieldEnum {
+		field.Options = strings.Join(entity.CustomField{Options: input.Options}.Choices(), "\n")
+		if field.Options == "" {
+			return errors.New("a choice field needs at least one choice")
+		}
+		if len(field.Options) > maxFieldOptions {
+			return fmt.Errorf("choices must be at most %d characters in total", maxFieldOptions)
+		}
+	}
+	return nil
+}
+
+func validFieldKind(kind entity.CustomFieldKind) bool {
+	for _, k := range entity.CustomFieldKinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// CardTags returns the tags on each of the given cards, sorted by name.
+func (uc *TagUseCase) CardTags(ctx context.Context, userID uint, cardIDs []uint) (map[uint][]entity.Tag, error) {
+	result := make(map[uint][]entity.Tag)
+	if len(cardIDs) == 0 {
+		return result, nil
+	}
+
+	cardTags, err := uc.tagRepo.FindCardTags(ctx, userID, cardIDs)
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[uint][]uint)
+	for _, cardTag := range cardTags {
+		tagged[cardTag.TagID] = append(tagged[cardTag.TagID], cardTag.CardID)
+	}
+
+	// Tags are listed by name, so every card gets its tags in that order
+	tags, err := uc.tagRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		for _, cardID := range tagged[tag.ID] {
+			result[cardID] = append(result[cardID], tag)
+		}
+	}
+	return result, nil
+}
+
+// CardFieldValues returns the custom field values of a card by field ID.
+func (uc *TagUseCase) CardFieldValues(ctx context.Context, userID uint, cardID uint) (map[uint]string, error) {
+	values, err := uc.fieldRepo.FindValues(ctx, userID, []uint{cardID})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]string, len(values))
+	for _, value := range values {
+		result[value.FieldID] = value.Value
+	}
+	return result, nil
+}
+
+// SetCardTags replaces the tags and custom field values of a card of the
+// user. Nothing is saved if a tag, field or value is invalid.
+func (uc *TagUseCase) SetCardTags(ctx context.Context, input SetCardTagsInput) error {
+	if _, err := uc.cardRepo.FindByID(ctx, input.CardID, input.UserID); err != nil {
+		return err
+	}
+
+	tags, err := uc.tagRepo.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uint]bool, len(tags))
+	for _, tag := range tags {
+		owned[tag.ID] = true
+	}
+	var tagIDs []uint
+	seen := make(map[uint]bool, len(input.TagIDs))
+	for _, id := range input.TagIDs {
+		if !owned[id] {
+			return errors.New("tag not found")
+		}
+		if !seen[id] {
+			seen[id] = true
+			tagIDs = append(tagIDs, id)
+		}
+	}
+
+	fields, err := uc.fieldRepo.FindByUserID(ctx, input.UserID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]entity.CustomField, len(fields))
+	for _, field := range fields {
+		byID[field.ID] = field
+	}
+	var values []entity.CustomFieldValue
+	for fieldID, raw := range input.Fields {
+		field, ok := byID[fieldID]
+		if !ok {
+			return errors.New("custom field not found")
+		}
+		value, err := parseFieldValue(field, raw)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			values = append(values, *value)
+		}
+	}
+
+	if err := uc.tagRepo.SetCardTags(ctx, input.CardID, tagIDs); err != nil {
+		return err
+	}
+	return uc.fieldRepo.SetCardValues(ctx, input.CardID, values)
+}
+
+// parseFieldValue checks a value entered for a field and returns it in its
+// stored form, or nil for an empty value.
+func parseFieldValue(field entity.CustomField, raw string) (*entity.CustomFieldValue, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value := &entity.CustomFieldValue{FieldID: field.ID, Value: raw}
+	switch field.Kind {
+	case entity.CustomFieldNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number", field.Name)
+		}
+		value.Number = &number
+		value.Value = strconv.FormatFloat(number, 'f', -1, 64)
+	case entity.CustomFieldDate:
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a date as YYYY-MM-DD", field.Name)
+		}
+		value.Date = &date
+	case entity.CustomFieldEnum:
+		value.Value = ""
+		for _, choice := range field.Choices() {
+			if strings.EqualFold(choice, raw) {
+				value.Value = choice
+			}
+		}
+		if value.Value == "" {
+			return nil, fmt.Errorf("%s must be one of %s", field.Name, strings.Join(field.Choices(), ", "))
+		}
+	default:
+		if len(raw) > maxFieldValue {
+			return nil, fmt.Errorf("%s must be at most %d characters", field.Name, maxFieldValue)
+		}
+	}
+	return value, nil
+}
+
+// ResolveSearch looks up the custom fields named in a parsed search and
+// checks the values compared with them, so the query can be run.
+func (uc *TagUseCase) ResolveSearch(ctx context.Context, userID uint, query cardquery.Expr) error {
+	var fields []entity.CustomField
+	loaded := false
+	for _, term := range cardquery.Terms(query) {
+		if term.Field != cardquery.FieldCustom {
+			continue
+		}
+		if !loaded {
+			var err error
+			if fields, err = uc.fieldRepo.FindByUserID(ctx, userID); err != nil {
+				return err
+			}
+			loaded = true
+		}
+		if err := resolveCustomTerm(term, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveCustomTerm(term *cardquery.Term, fields []entity.CustomField) error {
+	label := string(cardquery.FieldCustom) + "." + term.Name
+	for _, field := range fields {
+		if !strings.EqualFold(field.Name, term.Name) {
+			continue
+		}
+
+		term.FieldID = field.ID
+		term.Kind = string(field.Kind)
+		switch field.Kind {
+		case entity.CustomFieldNumber:
+			number, err := strconv.ParseFloat(term.Text, 64)
+			if err != nil {
+				return fmt.Errorf("%s needs a number, got %q", label, term.Text)
+			}
+			term.Number = number
+		case entity.CustomFieldDate:
+			date, err := time.Parse("2006-01-02", term.Text)
+			if err != nil {
+				return fmt.Errorf("%s needs a date as YYYY-MM-DD, got %q", label, term.Text)
+			}
+			term.Date = date
+		default:
+			if term.Op != cardquery.OpMatch && term.Op != cardquery.OpEqual && term.Op != cardquery.OpNotEqual {
+				return fmt.Errorf("%s only supports :, = and !=", label)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown custom field %q", term.Name)
+}
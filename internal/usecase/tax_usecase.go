@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type TaxUseCase struct {
+	lotRepo  repository.LotRepository
+	cardRepo repository.CardRepository
+	userRepo repository.UserRepository
+}
+
+func NewTaxUseCase(lotRepo repository.LotRepository, cardRepo repository.CardRepository, userRepo repository.UserRepository) *TaxUseCase {
+	return &TaxUseCase{lotRepo: lotRepo, cardRepo: cardRepo, userRepo: userRepo}
+}
+
+// TaxDisposal is the part of a sale taken from one purchase lot, which is
+// what has a single acquisition date and so a single holding period. Amounts
+// are rounded to cents.
+type TaxDisposal struct {
+	SaleID          uint
+	CardID          uint
+	CardName        string
+	SetCode         string
+	CollectorNumber string
+	Quantity        int
+	AcquiredAt      *time.Time
+	SoldAt          time.Time
+	Method          entity.CostMethod
+	CostBasis       float64
+	Proceeds        float64
+	Gain            float64
+}
+
+// HoldingDays returns the number of days the copies were held, or -1 when
+// the acquisition date is unknown.
+func (d TaxDisposal) HoldingDays() int {
+	if d.AcquiredAt == nil {
+		return -1
+	}
+	return int(d.SoldAt.Sub(*d.AcquiredAt).Hours() / 24)
+}
+
+// Term returns "long" for copies held more than a year, "short" otherwise and
+// "unknown" when the acquisition date is missing. The year is a calendar
+// year, so leap days do not shift the boundary.
+func (d TaxDisposal) Term() string {
+	switch {
+	case d.AcquiredAt == nil:
+		return "unknown"
+	case d.SoldAt.After(d.AcquiredAt.AddDate(1, 0, 0)):
+		return "long"
+	default:
+		return "short"
+	}
+}
+
+// TaxReport lists every disposal of one calendar year with its totals.
+// Unrecorded lists cards marked sold in the year without a recorded sale, so
+// without proceeds; they are left out of the totals.
+type TaxReport struct {
+	Year            int
+	Currency        string
+	Disposals       []TaxDisposal
+	Unrecorded      []entity.Card
+	Proceeds        float64
+	CostBasis       float64
+	Gain            float64
+	ShortTermGain   float64
+	LongTermGain    float64
+	UnknownTermGain float64
+}
+
+// TaxYears returns the years the user sold cards in, newest first.
+func (uc *TaxUseCase) TaxYears(ctx context.Context, userID uint) ([]int, error) {
+	sales, err := uc.lotRepo.FindSalesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	for _, sale := range sales {
+		seen[sale.SoldAt.Year()] = true
+	}
+	for _, card := range cards {
+		if card.SellDate != nil {
+			seen[card.SellDate.Year()] = true
+		}
+	}
+
+	years := make([]int, 0, len(seen))
+	for year := range seen {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return years, nil
+}
+
+// Report builds the tax report of the user for a calendar year, with one
+// disposal per lot each sale consumed, ordered by sale date.
+func (uc *TaxUseCase) Report(ctx context.Context, userID uint, year int) (*TaxReport, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := uc.lotRepo.FindSalesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TaxReport{Year: year, Currency: user.Currency}
+	if report.Currency == "" {
+		report.Currency = entity.DefaultCurrency
+	}
+
+	recorded := make(map[uint]bool, len(sales))
+	for _, sale := range sales {
+		recorded[sale.SoldCardID] = true
+		if sale.SoldAt.Year() != year {
+			continue
+		}
+		for _, disposal := range saleDisposals(sale) {
+			report.add(disposal)
+		}
+	}
+
+	for _, card := range cards {
+		if card.SellDate != nil && card.SellDate.Year() == year && !recorded[card.ID] {
+			report.Unrecorded = append(report.Unrecorded, card)
+		}
+	}
+	sort.SliceStable(report.Unrecorded, func(i, j int) bool {
+		return report.Unrecorded[i].SellDate.Before(*report.Unrecorded[j].SellDate)
+	})
+
+	return report, nil
+}
+
+// saleDisposals splits a sale into one disposal per allocation.
+func saleDisposals(sale entity.CardSale) []TaxDisposal {
+	base := TaxDisposal{
+		SaleID:          sale.ID,
+		CardID:          sale.SoldCardID,
+		CardName:        sale.CardName,
+		SetCode:         sale.SetCode,
+		CollectorNumber: sale.CollectorNumber,
+		SoldAt:          sale.SoldAt,
+		Method:          sale.Method,
+	}
+
+	disposals := make([]TaxDisposal, 0, len(sale.Allocations))
+	for _, allocation := range sale.Allocations {
+		disposal := base
+		disposal.Quantity = allocation.Quantity
+		disposal.AcquiredAt = allocation.AcquiredAt
+		disposal.CostBasis = roundCents(allocation.Cost())
+		disposal.Proceeds = roundCents(sale.UnitPrice * float64(allocation.Quantity))
+		disposal.Gain = roundCents(disposal.Proceeds - disposal.CostBasis)
+		disposals = append(disposals, disposal)
+	}
+	return disposals
+}
+
+func (r *TaxReport) add(disposal TaxDisposal) {
+	r.Disposals = append(r.Disposals, disposal)
+	r.Proceeds = roundCents(r.Proceeds + disposal.Proceeds)
+	r.CostBasis = roundCents(r.CostBasis + disposal.CostBasis)
+	r.Gain = roundCents(r.Gain + disposal.Gain)
+
+	switch disposal.Term() {
+	case "long":
+		r.LongTermGain = roundCents(r.LongTermGain + disposal.Gain)
+	case "short":
+		r.ShortTermGain = roundCents(r.ShortTermGain + disposal.Gain)
+	default:
+		r.UnknownTermGain = roundCents(r.UnknownTermGain + disposal.Gain)
+	}
+}
+
+// WriteCSV writes the disposals of the report followed by a totals row.
+func (r *TaxReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"Sale ID", "Date Sold", "Card", "Set", "Collector Number", "Quantity",
+		"Date Acquired", "Holding Period (days)", "Term", "Cost Method",
+		"Cost Basis (" + r.Currency + ")", "Proceeds (" + r.Currency + ")", "Gain (" + r.Currency + ")",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, d := range r.Disposals {
+		acquired, holding := "", ""
+		if d.AcquiredAt != nil {
+			acquired = d.AcquiredAt.Format("2006-01-02")
+			holding = strconv.Itoa(d.HoldingDays())
+		}
+		record := []string{
+			strconv.FormatUint(uint64(d.SaleID), 10), d.SoldAt.Format("2006-01-02"),
+			csvText(d.CardName), csvText(d.SetCode), csvText(d.CollectorNumber), strconv.Itoa(d.Quantity),
+			acquired, holding, d.Term(), string(d.Method),
+			money(d.CostBasis), money(d.Proceeds), money(d.Gain),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	totals := make([]string, len(header))
+	totals[0] = fmt.Sprintf("Total %d", r.Year)
+	totals[10], totals[11], totals[12] = money(r.CostBasis), money(r.Proceeds), money(r.Gain)
+	if err := writer.Write(totals); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText keeps spreadsheets from reading user-entered text as a formula.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func money(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,229 @@
+package usecase_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mockTransactor runs the function directly; the mock repositories have no
+// transaction to join.
+type mockTransactor struct{}
+
+func (mockTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type accountFixture struct {
+	userRepo       *mockUserRepository
+	cardRepo       *mockCardRepository
+	tradeRepo      *mockTradeRepository
+	auditRepo      *mockAuditRepository
+	photoRepo      *mockCardPhotoRepository
+	blobStore      *mockBlobStore
+	accountUseCase *usecase.AccountUseCase
+}
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	f := &accountFixture{
+		userRepo:  newMockUserRepository(),
+		cardRepo:  newMockCardRepository(),
+		auditRepo: &mockAuditRepository{},
+		photoRepo: newMockCardPhotoRepository(),
+		blobStore: newMockBlobStore(),
+	}
+	f.tradeRepo = newMockTradeRepository(f.cardRepo)
+	f.accountUseCase = usecase.NewAccountUseCase(f.userRepo, f.cardRepo, f.auditRepo, newMockDeckRepository(), newMockLocationRepository(), newMockWishlistRepository(), f.tradeRepo, newMockLotRepository(f.cardRepo), newMockTagRepository(), newMockCustomFieldRepository(), newMockLoanRepository(), newMockSealedRepository(f.cardRepo), f.photoRepo, f.blobStore, mockTransactor{})
+
+	ctx := context.Background()
+	for i, username := range []string{"alice", "bob"} {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		f.userRepo.Create(ctx, &entity.User{ID: uint(i + 1), Username: username, Password: string(hash)})
+	}
+
+	cardUseCase := usecase.NewCardUseCase(f.cardRepo, f.auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Sol Ring", Quantity: 1})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Mana Crypt", Quantity: 1})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 2, CardName: "Opt", Quantity: 4})
+
+	photoUseCase := usecase.NewPhotoUseCase(f.photoRepo, f.cardRepo, f.blobStore)
+	if _, err := photoUseCase.UploadPhoto(ctx, usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))}); err != nil {
+		t.Fatalf("Failed to upload photo: %v", err)
+	}
+
+	return f
+}
+
+func TestAccountUseCase_Export(t *testing.T) {
+	f := newAccountFixture(t)
+
+	var buf bytes.Buffer
+	if err := f.accountUseCase.WriteExport(context.Background(), 1, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expected a valid zip archive, got %v", err)
+	}
+
+	files := make(map[string]bool)
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+	for _, name := range []string{"profile.json", "cards.json", "history.json", "decks.json", "locations.json", "wishlist.json", "trades.json", "lots.json", "sales.json", "tags.json", "card_tags.json", "custom_fields.json", "custom_field_values.json", "loans.json", "sealed_products.json", "photos.json", "photos/1.png"} {
+		if !files[name] {
+			t.Errorf("Expected %s in export", name)
+		}
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("$2a$")) {
+		t.Error("Expected password hash to be excluded from export")
+	}
+}
+
+func TestAccountUseCase_DeletionFlow(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	// Wrong confirmation is rejected
+	if _, err := f.accountUseCase.RequestDeletion(ctx, 1, "bob", "password123"); err == nil {
+		t.Error("Expected error for mismatched username, got nil")
+	}
+	if _, err := f.accountUseCase.RequestDeletion(ctx, 1, "alice", "wrong"); err == nil {
+		t.Error("Expected error for wrong password, got nil")
+	}
+
+	deleteAfter, err := f.accountUseCase.RequestDeletion(ctx, 1, "alice", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	accepted := &entity.TradeProposal{ProposerID: 1, RecipientID: 2, Status: entity.TradeStatusAccepted, Message: "Deal?"}
+	pending := &entity.TradeProposal{ProposerID: 2, RecipientID: 1, Status: entity.TradeStatusPending, Message: "Want my Opt?"}
+	f.tradeRepo.Create(ctx, accepted)
+	f.tradeRepo.Create(ctx, pending)
+
+	// Nothing is purged during the grace period
+	purged, err := f.accountUseCase.PurgeDueAccounts(ctx, time.Now())
+	if err != nil || purged != 0 {
+		t.Fatalf("Expected nothing purged during grace period, got %d (%v)", purged, err)
+	}
+
+	// Purge after the grace period cascades to cards and history
+	purged, err = f.accountUseCase.PurgeDueAccounts(ctx, deleteAfter.Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 account purged, got %d (%v)", purged, err)
+	}
+
+	if _, err := f.userRepo.FindByID(ctx, 1); err == nil {
+		t.Error("Expected user to be deleted")
+	}
+	if cards, _ := f.cardRepo.FindAllByUserID(ctx, 1); len(cards) != 0 {
+		t.Errorf("Expected no orphan cards, got %d", len(cards))
+	}
+	if events, _ := f.auditRepo.FindAllByUserID(ctx, 1); len(events) != 0 {
+		t.Errorf("Expected history to be erased, got %d events", len(events))
+	}
+	if len(f.photoRepo.photos) != 0 || len(f.blobStore.blobs) != 0 {
+		t.Errorf("Expected photos and their files to be deleted, got %d photos and %d files", len(f.photoRepo.photos), len(f.blobStore.blobs))
+	}
+
+	// Other users are untouched
+	if cards, _ := f.cardRepo.FindAllByUserID(ctx, 2); len(cards) != 1 {
+		t.Errorf("Expected other user's cards to remain, got %d", len(cards))
+	}
+
+	// Trades stay in the other party's history without the purged user's
+	// messages, and open ones are cancelled
+	trades, _ := f.tradeRepo.FindByUserID(ctx, 2)
+	if len(trades) != 2 {
+		t.Fatalf("Expected other user to keep 2 trades, got %d", len(trades))
+	}
+	for _, trade := range trades {
+		switch trade.ID {
+		case accepted.ID:
+			if trade.Status != entity.TradeStatusAccepted || trade.Message != "" {
+				t.Errorf("Expected accepted trade kept without message, got %s %q", trade.Status, trade.Message)
+			}
+		case pending.ID:
+			if trade.Status != entity.TradeStatusCancelled || trade.Message != "Want my Opt?" {
+				t.Errorf("Expected pending trade cancelled with message kept, got %s %q", trade.Status, trade.Message)
+			}
+		}
+	}
+}
+
+func TestAccountUseCase_CancelDeletion(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	deleteAfter, _ := f.accountUseCase.RequestDeletion(ctx, 1, "alice", "password123")
+	if err := f.accountUseCase.CancelDeletion(ctx, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	purged, _ := f.accountUseCase.PurgeDueAccounts(ctx, deleteAfter.Add(time.Minute))
+	if purged != 0 {
+		t.Errorf("Expected cancelled deletion not to purge, got %d", purged)
+	}
+}
+
+func TestAccountUseCase_SaveCardListView(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	if err := f.accountUseCase.SaveCardListView(ctx, 1, "dir=asc&sort=name"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	user, _ := f.accountUseCase.GetAccount(ctx, 1)
+	if user.CardListView != "dir=asc&sort=name" {
+		t.Errorf("Expected saved view, got %q", user.CardListView)
+	}
+	if other, _ := f.accountUseCase.GetAccount(ctx, 2); other.CardListView != "" {
+		t.Errorf("Expected other user's view to stay empty, got %q", other.CardListView)
+	}
+
+	if err := f.accountUseCase.SaveCardListView(ctx, 99, "sort=name"); err == nil {
+		t.Error("Expected error for unknown user")
+	}
+}
+
+func TestAccountUseCase_SetCostMethod(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	if err := f.accountUseCase.SetCostMethod(ctx, 1, entity.CostMethodLIFO); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user, _ := f.accountUseCase.GetAccount(ctx, 1); user.CostMethod != entity.CostMethodLIFO {
+		t.Errorf("Expected lifo, got %q", user.CostMethod)
+	}
+
+	if err := f.accountUseCase.SetCostMethod(ctx, 1, "hifo"); err == nil {
+		t.Error("Expected error for unknown cost method")
+	}
+}
+
+func TestAccountUseCase_SetCurrency(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+
+	if err := f.accountUseCase.SetCurrency(ctx, 1, "EUR"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user, _ := f.accountUseCase.GetAccount(ctx, 1); user.Currency != "EUR" {
+		t.Errorf("Expected EUR, got %q", user.Currency)
+	}
+
+	if err := f.accountUseCase.SetCurrency(ctx, 1, "eur"); err == nil {
+		t.Error("Expected error for unsupported currency")
+	}
+}
@@ -1,8 +1,11 @@
 package usecase_test
 
 import (
+"context"
 "errors"
+"sort"
 "testing"
+"time"
 
 "github.com/enter42/mtg-collection-tracker/internal/domain/entity"
 "github.com/enter42/mtg-collection-tracker/internal/usecase"
@@ -19,12 +22,12 @@ users: make(map[string]*entity.User),
 }
 }
 
-func (m *mockUserRepository) Create(user *entity.User) error {
+func (m *mockUserRepository) Create(ctx context.Context, user *entity.User) error {
 m.users[user.Username] = user
 return nil
 }
 
-func (m *mockUserRepository) FindByUsername(username string) (*entity.User, error) {
+func (m *mockUserRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
 user, ok := m.users[username]
 if !ok {
 return nil, errors.New("record not found")
@@ -32,7 +35,7 @@ return nil, errors.New("record not found")
 return user, nil
 }
 
-func (m *mockUserRepository) FindByID(id uint) (*entity.User, error) {
+func (m *mockUserRepository) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 for _, user := range m.users {
 if user.ID == id {
 return user, nil
@@ -41,18 +44,72 @@ return user, nil
 return nil, errors.New("record not found")
 }
 
+func (m *mockUserRepository) FindAll(ctx context.Context) ([]entity.User, error) {
+var users []entity.User
+for _, user := range m.users {
+users = append(users, *user)
+}
+sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+return users, nil
+}
+
+func (m *mockUserRepository) set(id uint, apply func(user *entity.User)) error {
+user, err := m.FindByID(context.Background(), id)
+if err != nil {
+return err
+}
+apply(user)
+return nil
+}
+
+func (m *mockUserRepository) SetDeleteAfter(ctx context.Context, id uint, deleteAfter *time.Time) error {
+return m.set(id, func(user *entity.User) { user.DeleteAfter = deleteAfter })
+}
+
+func (m *mockUserRepository) SetCardListView(ctx context.Context, id uint, view string) error {
+return m.set(id, func(user *entity.User) { user.CardListView = view })
+}
+
+func (m *mockUserRepository) SetCostMethod(ctx context.Context, id uint, method entity.CostMethod) error {
+return m.set(id, func(user *entity.User) { user.CostMethod = method })
+}
+
+func (m *mockUserRepository) SetCurrency(ctx context.Context, id uint, currency string) error {
+return m.set(id, func(user *entity.User) { user.Currency = currency })
+}
+
+// Anonymize drops the user, since the finders never return soft-deleted rows.
+func (m *mockUserRepository) Anonymize(ctx context.Context, id uint, at time.Time) error {
+for username, user := range m.users {
+if user.ID == id {
+delete(m.users, username)
+}
+}
+return nil
+}
+
+func (m *mockUserRepository) FindDueForDeletion(ctx context.Context, before time.Time) ([]entity.User, error) {
+var users []entity.User
+for _, user := range m.users {
+if user.DeleteAfter != nil && !user.DeleteAfter.After(before) {
+users = append(users, *user)
+}
+}
+return users, nil
+}
+
 func TestAuthUseCase_Register(t *testing.T) {
 repo := newMockUserRepository()
 authUseCase := usecase.NewAuthUseCase(repo)
 
 // Test successful registration
-err := authUseCase.Register("testuser", "password123")
+err := authUseCase.Register(context.Background(), "testuser", "password123")
 if err != nil {
 t.Errorf("Expected no error, got %v", err)
 }
 
 // Verify user was created
-user, err := repo.FindByUsername("testuser")
+user, err := repo.FindByUsername(context.Background(), "testuser")
 if err != nil {
 t.Fatalf("Expected user to be found, got error: %v", err)
 }
@@ -61,7 +118,7 @@ t.Errorf("Expected username 'testuser', got %s", user.Username)
 }
 
 // Test duplicate username
-err = authUseCase.Register("testuser", "password456")
+err = authUseCase.Register(context.Background(), "testuser", "password456")
 if err == nil {
 t.Error("Expected error for duplicate username, got nil")
 }
@@ -72,10 +129,10 @@ repo := newMockUserRepository()
 authUseCase := usecase.NewAuthUseCase(repo)
 
 // Register a user first
-authUseCase.Register("testuser", "password123")
+authUseCase.Register(context.Background(), "testuser", "password123")
 
 // Test successful login
-user, err := authUseCase.Login("testuser", "password123")
+user, err := authUseCase.Login(context.Background(), "testuser", "password123")
 if err != nil {
 t.Errorf("Expected no error, got %v", err)
 }
@@ -87,14 +144,25 @@ t.Errorf("Expected username 'testuser', got %s", user.Username)
 }
 
 // Test login with wrong password
-_, err = authUseCase.Login("testuser", "wrongpassword")
+_, err = authUseCase.Login(context.Background(), "testuser", "wrongpassword")
 if err == nil {
 t.Error("Expected error for wrong password, got nil")
 }
 
 // Test login with non-existent user
-_, err = authUseCase.Login("nonexistent", "password123")
+_, err = authUseCase.Login(context.Background(), "nonexistent", "password123")
 if err == nil {
 t.Error("Expected error for non-existent user, got nil")
 }
 }
+
+func TestAuthUseCase_RegisterReservedUsername(t *testing.T) {
+repo := newMockUserRepository()
+authUseCase := usecase.NewAuthUseCase(repo)
+
+// Purged accounts are renamed with this prefix
+err := authUseCase.Register(context.Background(), entity.DeletedUsername(7), "password123")
+if err == nil {
+t.Error("Expected error for reserved username, got nil")
+}
+}
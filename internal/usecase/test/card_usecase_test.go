@@ -0,0 +1,756 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+// Mock repositories for testing. Like the real repository, changes that
+// leave a card with fewer copies than are lent out on loanRepo, if set, fail,
+// and deleted cards take their photos on photoRepo, if set, with them.
+type mockCardRepository struct {
+	cards     map[uint]*entity.Card
+	nextID    uint
+	loanRepo  *mockLoanRepository
+	photoRepo *mockCardPhotoRepository
+}
+
+func newMockCardRepository() *mockCardRepository {
+	return &mockCardRepository{
+		cards:  make(map[uint]*entity.Card),
+		nextID: 1,
+	}
+}
+
+func (m *mockCardRepository) Create(ctx context.Context, card *entity.Card) error {
+	card.ID = m.nextID
+	m.nextID++
+	stored := *card
+	m.cards[card.ID] = &stored
+	return nil
+}
+
+func (m *mockCardRepository) Update(ctx context.Context, card *entity.Card) error {
+	existing, ok := m.cards[card.ID]
+	if !ok {
+		return errors.New("record not found")
+	}
+	if existing.Version != card.Version {
+		return repository.ErrVersionConflict
+	}
+	if card.Quantity < m.loanRepo.lent(card.ID) {
+		return repository.ErrCopiesLent
+	}
+	card.Version++
+	stored := *card
+	m.cards[card.ID] = &stored
+	return nil
+}
+
+func (m *mockCardRepository) Split(ctx context.Context, source *entity.Card, part *entity.Card) error {
+	if source.Quantity < m.loanRepo.lent(source.ID) {
+		return repository.ErrCopiesLent
+	}
+	if err := m.Update(ctx, source); err != nil {
+		return err
+	}
+	return m.Create(ctx, part)
+}
+
+func (m *mockCardRepository) Merge(ctx context.Context, target *entity.Card, merged []entity.Card) error {
+	for _, card := range merged {
+		if existing, ok := m.cards[card.ID]; !ok || existing.Version != card.Version {
+			return repository.ErrVersionConflict
+		}
+	}
+	if err := m.Update(ctx, target); err != nil {
+		return err
+	}
+	for _, card := range merged {
+		delete(m.cards, card.ID)
+	}
+	return nil
+}
+
+// UpdateBatch saves the cards that still hold their version and cover their
+// loans and reports the others, like the real transaction does.
+func (m *mockCardRepository) UpdateBatch(ctx context.Context, userID uint, updated []entity.Card, deleted []entity.Card) (*repository.BatchResult, error) {
+	result := &repository.BatchResult{Skipped: make(map[uint]error)}
+	skipped := result.Skipped
+	for i := range updated {
+		if updated[i].UserID != userID {
+			return nil, errors.New("record not found")
+		}
+		if err := m.Update(ctx, &updated[i]); err != nil {
+			skipped[updated[i].ID] = err
+		}
+	}
+	for _, card := range deleted {
+		existing, ok := m.cards[card.ID]
+		if !ok || existing.UserID != userID || existing.Version != card.Version {
+			skipped[card.ID] = repository.ErrVersionConflict
+			continue
+		}
+		if m.loanRepo.lent(card.ID) > 0 {
+			skipped[card.ID] = repository.ErrCopiesLent
+			continue
+		}
+		delete(m.cards, card.ID)
+		result.Photos = append(result.Photos, m.photoRepo.deleteCard(card.ID)...)
+	}
+	return result, nil
+}
+
+func (m *mockCardRepository) Delete(ctx context.Context, id uint, userID uint) ([]entity.CardPhoto, error) {
+	card, ok := m.cards[id]
+	if !ok || card.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	if m.loanRepo.lent(id) > 0 {
+		return nil, repository.ErrCopiesLent
+	}
+	delete(m.cards, id)
+	return m.photoRepo.deleteCard(id), nil
+}
+
+func (m *mockCardRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Card, error) {
+	card, ok := m.cards[id]
+	if !ok || card.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *card
+	return &found, nil
+}
+
+func (m *mockCardRepository) FindByUserID(ctx context.Context, userID uint, page, pageSize int, filter repository.CardFilter) ([]entity.Card, int64, error) {
+	var cards []entity.Card
+	for _, card := range m.cards {
+		if card.UserID != userID {
+			continue
+		}
+		if filter.Unassigned && card.LocationID != nil {
+			continue
+		}
+		if len(filter.LocationIDs) > 0 && !containsID(filter.LocationIDs, card.LocationID) {
+			continue
+		}
+		cards = append(cards, *card)
+	}
+	return cards, int64(len(cards)), nil
+}
+
+// FindPageByUserID lists cards newest first, which for the mock means by
+// descending ID, continuing from the cursor's ID.
+func (m *mockCardRepository) FindPageByUserID(ctx context.Context, userID uint, limit int, filter repository.CardFilter, cursor *repository.CardCursor) ([]entity.Card, error) {
+	cards, _, _ := m.FindByUserID(ctx, userID, 1, len(m.cards), filter)
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID > cards[j].ID })
+
+	var page []entity.Card
+	if cursor != nil && cursor.Backward {
+		for i := len(cards) - 1; i >= 0 && len(page) < limit; i-- {
+			if cards[i].ID > cursor.ID {
+				page = append([]entity.Card{cards[i]}, page...)
+			}
+		}
+		return page, nil
+	}
+	for _, card := range cards {
+		if len(page) < limit && (cursor == nil || card.ID < cursor.ID) {
+			page = append(page, card)
+		}
+	}
+	return page, nil
+}
+
+func (m *mockCardRepository) CountByUserID(ctx context.Context, userID uint, filter repository.CardFilter) (int64, error) {
+	_, total, err := m.FindByUserID(ctx, userID, 1, len(m.cards), filter)
+	return total, err
+}
+
+func containsID(ids []uint, id *uint) bool {
+	if id == nil {
+		return false
+	}
+	for _, candidate := range ids {
+		if candidate == *id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockCardRepository) FindAllByUserID(ctx context.Context, userID uint) ([]entity.Card, error) {
+	cards, _, err := m.FindByUserID(ctx, userID, 1, len(m.cards), repository.CardFilter{})
+	return cards, err
+}
+
+func (m *mockCardRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, card := range m.cards {
+		if card.UserID == userID {
+			delete(m.cards, id)
+		}
+	}
+	return nil
+}
+
+type mockAuditRepository struct {
+	events []entity.AuditEvent
+}
+
+func (m *mockAuditRepository) Create(ctx context.Context, event *entity.AuditEvent) error {
+	event.ID = uint(len(m.events) + 1)
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *mockAuditRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.AuditEvent, error) {
+	var events []entity.AuditEvent
+	for _, event := range m.events {
+		if event.CardID == cardID && event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockAuditRepository) FindByUserID(ctx context.Context, userID uint, page, pageSize int) ([]entity.AuditEvent, int64, error) {
+	var events []entity.AuditEvent
+	for _, event := range m.events {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, int64(len(events)), nil
+}
+
+func (m *mockAuditRepository) FindAllByUserID(ctx context.Context, userID uint) ([]entity.AuditEvent, error) {
+	events, _, err := m.FindByUserID(ctx, userID, 1, len(m.events))
+	return events, err
+}
+
+func (m *mockAuditRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	var kept []entity.AuditEvent
+	for _, event := range m.events {
+		if event.UserID != userID {
+			kept = append(kept, event)
+		}
+	}
+	m.events = kept
+	return nil
+}
+
+func findChange(changes []entity.AuditChange, field string) *entity.AuditChange {
+	for i := range changes {
+		if changes[i].Field == field {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestCardUseCase_AuditTrail(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	auditRepo := &mockAuditRepository{}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+
+	err := cardUseCase.CreateCard(context.Background(), usecase.CreateCardInput{
+		UserID:   1,
+		CardName: "Lightning Bolt",
+		SetCode:  "m10",
+		Quantity: 2,
+		Source:   entity.AuditSourceTrade,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(auditRepo.events) != 1 {
+		t.Fatalf("Expected 1 audit event, got %d", len(auditRepo.events))
+	}
+	created := auditRepo.events[0]
+	if created.Action != entity.AuditActionCreate || created.Source != entity.AuditSourceTrade {
+		t.Errorf("Expected create event from trade, got %s from %s", created.Action, created.Source)
+	}
+
+	// Update only the quantity
+	err = cardUseCase.UpdateCard(context.Background(), usecase.UpdateCardInput{
+		ID:       created.CardID,
+		UserID:   1,
+		Version:  1,
+		CardName: "Lightning Bolt",
+		SetCode:  "m10",
+		Quantity: 4,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	updated := auditRepo.events[1]
+	if updated.Action != entity.AuditActionUpdate {
+		t.Errorf("Expected update event, got %s", updated.Action)
+	}
+	if updated.Source != entity.AuditSourceWeb {
+		t.Errorf("Expected source to default to web, got %s", updated.Source)
+	}
+	if len(updated.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %d: %+v", len(updated.Changes), updated.Changes)
+	}
+	if change := findChange(updated.Changes, "quantity"); change == nil || change.Before != "2" || change.After != "4" {
+		t.Errorf("Expected quantity change 2 -> 4, got %+v", change)
+	}
+
+	// An update that changes nothing is not recorded
+	err = cardUseCase.UpdateCard(context.Background(), usecase.UpdateCardInput{
+		ID:       created.CardID,
+		UserID:   1,
+		Version:  2,
+		CardName: "Lightning Bolt",
+		SetCode:  "m10",
+		Quantity: 4,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(auditRepo.events) != 2 {
+		t.Errorf("Expected no-op update to be skipped, got %d events", len(auditRepo.events))
+	}
+
+	// Deleting keeps the history available
+	if err := cardUseCase.DeleteCard(context.Background(), created.CardID, 1, entity.AuditSourceWeb); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	history, err := usecase.NewAuditUseCase(auditRepo).CardHistory(context.Background(), created.CardID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history events, got %d", len(history))
+	}
+	if change := findChange(history[2].Changes, "card_name"); change == nil || change.After != "" {
+		t.Errorf("Expected delete event to clear card_name, got %+v", change)
+	}
+}
+
+func TestCardUseCase_DeleteOtherUsersCard(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	auditRepo := &mockAuditRepository{}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+
+	cardUseCase.CreateCard(context.Background(), usecase.CreateCardInput{UserID: 1, CardName: "Counterspell", Quantity: 1})
+
+	if err := cardUseCase.DeleteCard(context.Background(), 1, 2, entity.AuditSourceWeb); err == nil {
+		t.Error("Expected error deleting another user's card, got nil")
+	}
+	if len(auditRepo.events) != 1 {
+		t.Errorf("Expected only the create event, got %d events", len(auditRepo.events))
+	}
+}
+
+func TestCardUseCase_Language(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+
+	tests := []struct {
+		language string
+		want     string
+		wantErr  bool
+	}{
+		{"ja", "ja", false},
+		{" Japanese ", "ja", false},
+		{"JP", "ja", false},
+		{"Chinese (Traditional)", "zht", false},
+		{"", "", false},
+		{"Klingon", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			err := cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Opt", Language: tt.language, Quantity: 1})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil {
+				if card := cardRepo.cards[cardRepo.nextID-1]; card.Language != tt.want {
+					t.Errorf("Expected language %q, got %q", tt.want, card.Language)
+				}
+			}
+		})
+	}
+
+	err := cardUseCase.UpdateCard(ctx, usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 1, CardName: "Opt", Language: "Elvish", Quantity: 1})
+	if err == nil {
+		t.Error("Expected an unknown language to be rejected on update")
+	}
+	err = cardUseCase.UpdateCard(ctx, usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 1, CardName: "Opt", Language: "german", Quantity: 1})
+	if err != nil || cardRepo.cards[1].Language != "de" {
+		t.Errorf("Expected the language to be stored as de, got %q (%v)", cardRepo.cards[1].Language, err)
+	}
+}
+
+func TestCardUseCase_CatalogMetadata(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	catalogRepo := &mockCatalogRepository{
+		cards: []entity.CatalogCard{
+			{SetCode: "mh2", CollectorNumber: "32", Name: "Solitude", Rarity: entity.RarityMythic, Colors: "W", ColorIdentity: "W", TypeLine: "Creature — Elemental Incarnation", ManaValue: 5, OracleID: "2d8c3a23"},
+			{SetCode: "m10", CollectorNumber: "146", Name: "Lightning Bolt", Rarity: entity.RarityCommon, Colors: "R", ColorIdentity: "R", TypeLine: "Instant", ManaValue: 1, OracleID: "4457ed35"},
+		},
+	}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, catalogRepo, newMockBlobStore())
+
+	err := cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Quantity: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if card := cardRepo.cards[1]; card.Rarity != entity.RarityMythic || card.Colors != "W" || card.TypeLine != "Creature — Elemental Incarnation" || card.ManaValue != 5 || card.OracleID != "2d8c3a23" {
+		t.Errorf("Expected the catalog metadata of MH2 #32, got %+v", card.CardMetadata)
+	}
+
+	err = cardUseCase.UpdateCard(ctx, usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 1, CardName: "Lightning Bolt", SetCode: "m10", CollectorNumber: "146", Quantity: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if card := cardRepo.cards[1]; card.Rarity != entity.RarityCommon || card.Colors != "R" || card.OracleID != "4457ed35" {
+		t.Errorf("Expected the metadata to follow the new printing, got %+v", card.CardMetadata)
+	}
+
+	err = cardUseCase.UpdateCard(ctx, usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 2, CardName: "Homebrew", Quantity: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if card := cardRepo.cards[1]; card.Known() || card.CardMetadata != (entity.CardMetadata{}) {
+		t.Errorf("Expected a card outside the catalog to have no metadata, got %+v", card.CardMetadata)
+	}
+}
+
+func TestCardUseCase_UpdateConflict(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+
+	cardUseCase.CreateCard(context.Background(), usecase.CreateCardInput{UserID: 1, CardName: "Brainstorm", Quantity: 1})
+
+	// First tab saves successfully
+	err := cardUseCase.UpdateCard(context.Background(), usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 1, CardName: "Brainstorm", Quantity: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Second tab still holds version 1
+	err = cardUseCase.UpdateCard(context.Background(), usecase.UpdateCardInput{ID: 1, UserID: 1, Version: 1, CardName: "Brainstorm", Quantity: 2})
+	var conflict *usecase.CardConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected CardConflictError, got %v", err)
+	}
+	if conflict.Current.Quantity != 3 || conflict.Current.Version != 2 {
+		t.Errorf("Expected current card with quantity 3 at version 2, got quantity %d at version %d",
+			conflict.Current.Quantity, conflict.Current.Version)
+	}
+
+	card, _ := cardRepo.FindByID(context.Background(), 1, 1)
+	if card.Quantity != 3 {
+		t.Errorf("Expected stale update to be rejected, quantity is %d", card.Quantity)
+	}
+
+	// Resubmitting with the current version succeeds
+	err = cardUseCase.UpdateCard(context.Background(), usecase.UpdateCardInput{ID: 1, UserID: 1, Version: conflict.Current.Version, CardName: "Brainstorm", Quantity: 2})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestCardUseCase_BulkEdit(t *testing.T) {
+	ctx := context.Background()
+	sellDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   usecase.BulkEditInput
+		applied int
+		failed  map[uint]string
+		check   func(t *testing.T, cards map[uint]*entity.Card)
+	}{
+		{
+			name:    "set language skips other users' cards",
+			input:   usecase.BulkEditInput{CardIDs: []uint{1, 2, 3, 4}, Action: usecase.BulkSetLanguage, Language: " Japanese "},
+			applied: 3,
+			failed:  map[uint]string{4: "card not found"},
+			check: func(t *testing.T, cards map[uint]*entity.Card) {
+				if cards[1].Language != "ja" || cards[3].Language != "ja" {
+					t.Errorf("Expected language ja, got %q and %q", cards[1].Language, cards[3].Language)
+				}
+				if cards[4].Language == "ja" {
+					t.Error("Expected other user's card to stay unchanged")
+				}
+			},
+		},
+		{
+			name:    "mark sold skips sold cards",
+			input:   usecase.BulkEditInput{CardIDs: []uint{1, 3}, Action: usecase.BulkMarkSold, SellDate: &sellDate},
+			applied: 1,
+			failed:  map[uint]string{3: "already sold"},
+			check: func(t *testing.T, cards map[uint]*entity.Card) {
+				if cards[1].SellDate == nil || !cards[1].SellDate.Equal(sellDate) || cards[1].ForTrade != 0 {
+					t.Errorf("Expected card 1 sold on %v and not for trade, got %v with %d for trade", sellDate, cards[1].SellDate, cards[1].ForTrade)
+				}
+			},
+		},
+		{
+			name:    "adjust quantity keeps at least one copy",
+			input:   usecase.BulkEditInput{CardIDs: []uint{1, 2}, Action: usecase.BulkAdjustQuantity, QuantityDelta: -2},
+			applied: 1,
+			failed:  map[uint]string{2: "quantity would drop below 1"},
+			check: func(t *testing.T, cards map[uint]*entity.Card) {
+				if cards[1].Quantity != 2 || cards[1].ForTrade != 2 {
+					t.Errorf("Expected quantity 2 with 2 for trade, got %d with %d", cards[1].Quantity, cards[1].ForTrade)
+				}
+			},
+		},
+		{
+			name:    "delete",
+			input:   usecase.BulkEditInput{CardIDs: []uint{2, 3, 3}, Action: usecase.BulkDelete},
+			applied: 2,
+			check: func(t *testing.T, cards map[uint]*entity.Card) {
+				if _, ok := cards[2]; ok {
+					t.Error("Expected card 2 to be deleted")
+				}
+				if _, ok := cards[1]; !ok {
+					t.Error("Expected unselected card 1 to be kept")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cardRepo := newMockCardRepository()
+			auditRepo := &mockAuditRepository{}
+			cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+
+			cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Ponder", Quantity: 4, ForTrade: 3})
+			cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Preordain", Quantity: 1})
+			cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Opt", Quantity: 1, SellDate: &sellDate})
+			cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 2, CardName: "Ponder", Quantity: 1})
+			created := len(auditRepo.events)
+
+			input := tt.input
+			input.UserID = 1
+			result, err := cardUseCase.BulkEdit(ctx, input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Applied != tt.applied {
+				t.Errorf("Expected %d cards changed, got %d", tt.applied, result.Applied)
+			}
+			if len(result.Failures) != len(tt.failed) {
+				t.Fatalf("Expected %d failures, got %+v", len(tt.failed), result.Failures)
+			}
+			for _, failure := range result.Failures {
+				if tt.failed[failure.CardID] != failure.Reason {
+					t.Errorf("Unexpected failure for card %d: %q", failure.CardID, failure.Reason)
+				}
+			}
+			if got := len(auditRepo.events) - created; got != tt.applied {
+				t.Errorf("Expected %d audit events, got %d", tt.applied, got)
+			}
+			tt.check(t, cardRepo.cards)
+		})
+	}
+}
+
+// racingCardRepository changes a card after BulkEdit read it and before the
+// batch is saved.
+type racingCardRepository struct {
+	*mockCardRepository
+	changed uint
+}
+
+func (r *racingCardRepository) UpdateBatch(ctx context.Context, userID uint, updated []entity.Card, deleted []entity.Card) (*repository.BatchResult, error) {
+	r.cards[r.changed].Version++
+	return r.mockCardRepository.UpdateBatch(ctx, userID, updated, deleted)
+}
+
+func TestCardUseCase_BulkEditConflict(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := &racingCardRepository{mockCardRepository: newMockCardRepository(), changed: 2}
+	auditRepo := &mockAuditRepository{}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Ponder", Quantity: 1})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Opt", Quantity: 1})
+
+	result, err := cardUseCase.BulkEdit(ctx, usecase.BulkEditInput{UserID: 1, CardIDs: []uint{1, 2}, Action: usecase.BulkAdjustQuantity, QuantityDelta: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Applied != 1 || len(result.Failures) != 1 || result.Failures[0].CardID != 2 {
+		t.Fatalf("Expected card 2 to conflict and card 1 to be saved, got %+v", result)
+	}
+	if cardRepo.cards[1].Quantity != 2 || cardRepo.cards[2].Quantity != 1 {
+		t.Errorf("Expected quantities 2 and 1, got %d and %d", cardRepo.cards[1].Quantity, cardRepo.cards[2].Quantity)
+	}
+	if len(auditRepo.events) != 3 {
+		t.Errorf("Expected 2 create events and 1 update event, got %d", len(auditRepo.events))
+	}
+}
+
+func TestCardUseCase_LentCopies(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	cardRepo.loanRepo = newMockLoanRepository()
+	auditRepo := &mockAuditRepository{}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Brainstorm", Quantity: 4})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Ponder", Quantity: 4})
+	cardRepo.loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: 1, CardName: "Brainstorm", Borrower: "Sam", Quantity: 3, LentAt: time.Now()})
+
+	update := usecase.UpdateCardInput{ID: 1, UserID: 1, CardName: "Brainstorm", Quantity: 2, Version: 1}
+	if err := cardUseCase.UpdateCard(ctx, update); !errors.Is(err, repository.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent lowering the quantity below 3, got %v", err)
+	}
+	update.Quantity = 3
+	if err := cardUseCase.UpdateCard(ctx, update); err != nil {
+		t.Errorf("Expected the lent copies to be enough, got %v", err)
+	}
+
+	if err := cardUseCase.DeleteCard(ctx, 1, 1, entity.AuditSourceWeb); !errors.Is(err, repository.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent deleting a lent card, got %v", err)
+	}
+	if _, ok := cardRepo.cards[1]; !ok {
+		t.Fatal("Expected the lent card to be kept")
+	}
+
+	for _, input := range []usecase.BulkEditInput{
+		{UserID: 1, CardIDs: []uint{1, 2}, Action: usecase.BulkAdjustQuantity, QuantityDelta: -1},
+		{UserID: 1, CardIDs: []uint{1, 2}, Action: usecase.BulkDelete},
+	} {
+		result, err := cardUseCase.BulkEdit(ctx, input)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", input.Action, err)
+		}
+		if result.Applied != 1 || len(result.Failures) != 1 || result.Failures[0].CardID != 1 || result.Failures[0].Reason != "copies are lent out" {
+			t.Errorf("%s: expected only the lent card to fail, got %+v", input.Action, result)
+		}
+	}
+	if card := cardRepo.cards[1]; card == nil || card.Quantity != 3 {
+		t.Errorf("Expected the lent card to keep 3 copies, got %+v", card)
+	}
+	if _, ok := cardRepo.cards[2]; ok {
+		t.Error("Expected the card without loans to be deleted")
+	}
+}
+
+func TestCardUseCase_BulkEditValidation(t *testing.T) {
+	cardUseCase := usecase.NewCardUseCase(newMockCardRepository(), &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+
+	tests := []struct {
+		name  string
+		input usecase.BulkEditInput
+	}{
+		{"no cards", usecase.BulkEditInput{Action: usecase.BulkDelete}},
+		{"too many cards", usecase.BulkEditInput{CardIDs: make([]uint, 501), Action: usecase.BulkDelete}},
+		{"unknown action", usecase.BulkEditInput{CardIDs: []uint{1}, Action: "burn"}},
+		{"missing language", usecase.BulkEditInput{CardIDs: []uint{1}, Action: usecase.BulkSetLanguage}},
+		{"unknown language", usecase.BulkEditInput{CardIDs: []uint{1}, Action: usecase.BulkSetLanguage, Language: "Klingon"}},
+		{"missing sell date", usecase.BulkEditInput{CardIDs: []uint{1}, Action: usecase.BulkMarkSold}},
+		{"zero quantity change", usecase.BulkEditInput{CardIDs: []uint{1}, Action: usecase.BulkAdjustQuantity}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			input.UserID = 1
+			if _, err := cardUseCase.BulkEdit(context.Background(), input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestCardUseCase_ListCardPage(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+	ctx := context.Background()
+
+	for _, name := range []string{"One", "Two", "Three", "Four", "Five"} {
+		cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: name, Quantity: 1})
+	}
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 2, CardName: "Other", Quantity: 1})
+
+	names := func(page *usecase.CardPage) []string {
+		var names []string
+		for _, card := range page.Cards {
+			names = append(names, card.CardName)
+		}
+		return names
+	}
+	expectPage := func(step string, page *usecase.CardPage, err error, want []string, hasPrev, hasNext bool) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", step, err)
+		}
+		if got := names(page); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", step, want, got)
+		}
+		if (page.Prev != "") != hasPrev || (page.Next != "") != hasNext {
+			t.Errorf("%s: expected prev=%v next=%v, got %q and %q", step, hasPrev, hasNext, page.Prev, page.Next)
+		}
+		if page.Total != 5 {
+			t.Errorf("%s: expected total 5, got %d", step, page.Total)
+		}
+	}
+
+	filter := repository.CardFilter{}
+	first, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, "", true)
+	expectPage("first", first, err, []string{"Five", "Four"}, false, true)
+
+	second, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, first.Next, true)
+	expectPage("second", second, err, []string{"Three", "Two"}, true, true)
+
+	last, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, second.Next, true)
+	expectPage("last", last, err, []string{"One"}, true, false)
+
+	back, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, last.Prev, true)
+	expectPage("back", back, err, []string{"Three", "Two"}, true, true)
+
+	start, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, back.Prev, true)
+	expectPage("start", start, err, []string{"Five", "Four"}, false, true)
+
+	if _, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, "not a cursor", false); !errors.Is(err, usecase.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for garbage, got %v", err)
+	}
+	sorted := repository.CardFilter{Sort: repository.SortByName, Ascending: true}
+	if _, err := cardUseCase.ListCardPage(ctx, 1, 2, sorted, first.Next, false); !errors.Is(err, usecase.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a different sort, got %v", err)
+	}
+	filtered := repository.CardFilter{Language: "ja"}
+	if _, err := cardUseCase.ListCardPage(ctx, 1, 2, filtered, first.Next, false); !errors.Is(err, usecase.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a different filter, got %v", err)
+	}
+	searched := repository.CardFilter{Query: &cardquery.Term{Field: cardquery.FieldName, Op: cardquery.OpMatch, Text: "o"}}
+	if _, err := cardUseCase.ListCardPage(ctx, 1, 2, searched, first.Next, false); !errors.Is(err, usecase.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a different search, got %v", err)
+	}
+
+	// The total is counted again rather than taken from the cursor
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Six", Quantity: 1})
+	if recounted, err := cardUseCase.ListCardPage(ctx, 1, 2, filter, first.Next, true); err != nil || recounted.Total != 6 {
+		t.Errorf("Expected a recounted total of 6, got %+v (%v)", recounted, err)
+	}
+
+	uncounted, _ := cardUseCase.ListCardPage(ctx, 1, 2, filter, "", false)
+	if uncounted.Total != -1 {
+		t.Errorf("Expected uncounted total -1, got %d", uncounted.Total)
+	}
+}
@@ -0,0 +1,248 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockDeckRepository struct {
+	decks   map[uint]*entity.Deck
+	entries []entity.DeckEntry
+	nextID  uint
+}
+
+func newMockDeckRepository() *mockDeckRepository {
+	return &mockDeckRepository{
+		decks:  make(map[uint]*entity.Deck),
+		nextID: 1,
+	}
+}
+
+func (m *mockDeckRepository) Create(ctx context.Context, deck *entity.Deck) error {
+	deck.ID = m.nextID
+	m.nextID++
+	stored := *deck
+	m.decks[deck.ID] = &stored
+	return nil
+}
+
+func (m *mockDeckRepository) Update(ctx context.Context, deck *entity.Deck) error {
+	stored := *deck
+	m.decks[deck.ID] = &stored
+	return nil
+}
+
+func (m *mockDeckRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	delete(m.decks, id)
+	return nil
+}
+
+func (m *mockDeckRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Deck, error) {
+	deck, ok := m.decks[id]
+	if !ok || deck.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *deck
+	found.Entries = nil
+	for _, entry := range m.entries {
+		if entry.DeckID == id {
+			found.Entries = append(found.Entries, entry)
+		}
+	}
+	return &found, nil
+}
+
+func (m *mockDeckRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Deck, error) {
+	var decks []entity.Deck
+	for id, deck := range m.decks {
+		if deck.UserID == userID {
+			found, _ := m.FindByID(ctx, id, userID)
+			decks = append(decks, *found)
+		}
+	}
+	return decks, nil
+}
+
+func (m *mockDeckRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, deck := range m.decks {
+		if deck.UserID == userID {
+			delete(m.decks, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockDeckRepository) CreateEntry(ctx context.Context, entry *entity.DeckEntry) error {
+	entry.ID = m.nextID
+	m.nextID++
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *mockDeckRepository) DeleteEntry(ctx context.Context, id uint, deckID uint, userID uint) error {
+	var kept []entity.DeckEntry
+	for _, entry := range m.entries {
+		if entry.ID != id || entry.DeckID != deckID || entry.UserID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	m.entries = kept
+	return nil
+}
+
+func (m *mockDeckRepository) FindEntriesByCardNames(ctx context.Context, userID uint, names []string) ([]entity.DeckEntry, error) {
+	var entries []entity.DeckEntry
+	for _, entry := range m.entries {
+		deck, ok := m.decks[entry.DeckID]
+		if !ok || entry.UserID != userID {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(entry.CardName, name) {
+				entry.Deck = deck
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockCardRepository) OwnedQuantities(ctx context.Context, userID uint, names []string) ([]repository.OwnedQuantity, error) {
+	var owned []repository.OwnedQuantity
+	for _, card := range m.cards {
+		if card.UserID != userID || card.SellDate != nil {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(card.CardName, name) {
+				owned = append(owned, repository.OwnedQuantity{
+					CardName:        card.CardName,
+					SetCode:         card.SetCode,
+					CollectorNumber: card.CollectorNumber,
+					Quantity:        card.Quantity,
+				})
+				break
+			}
+		}
+	}
+	return owned, nil
+}
+
+func findStatus(report *usecase.DeckOwnershipReport, name string) *usecase.DeckCardStatus {
+	for i := range report.Cards {
+		if report.Cards[i].CardName == name {
+			return &report.Cards[i]
+		}
+	}
+	return nil
+}
+
+func TestDeckUseCase_OwnershipReport(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	deckRepo := newMockDeckRepository()
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+	deckUseCase := usecase.NewDeckUseCase(deckRepo, cardRepo, newMockLoanRepository())
+
+	// Two printings of Lightning Bolt, one Counterspell
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Lightning Bolt", SetCode: "m10", Quantity: 2})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Lightning Bolt", SetCode: "2xm", Quantity: 1})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Counterspell", Quantity: 1})
+
+	burn, _ := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: "Burn", Format: "modern"})
+	izzet, _ := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: "Izzet", Format: "modern"})
+
+	deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: burn.ID, UserID: 1, CardName: "Lightning Bolt", Quantity: 4, Zone: entity.DeckZoneMainboard})
+	deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: burn.ID, UserID: 1, CardName: "Counterspell", Quantity: 1, Zone: entity.DeckZoneSideboard})
+	deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: izzet.ID, UserID: 1, CardName: "lightning bolt", Quantity: 2, Zone: entity.DeckZoneMainboard})
+
+	report, err := deckUseCase.OwnershipReport(ctx, burn.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	bolt := findStatus(report, "Lightning Bolt")
+	if bolt == nil {
+		t.Fatal("Expected Lightning Bolt in report")
+	}
+	if bolt.Owned != 3 || bolt.Committed != 2 || bolt.Available != 1 || bolt.Missing != 3 {
+		t.Errorf("Expected owned 3, committed 2, available 1, missing 3; got %+v", bolt)
+	}
+	if len(bolt.Commitments) != 1 || bolt.Commitments[0].DeckName != "Izzet" {
+		t.Errorf("Expected commitment to Izzet, got %+v", bolt.Commitments)
+	}
+
+	counterspell := findStatus(report, "Counterspell")
+	if counterspell == nil || counterspell.Missing != 0 {
+		t.Errorf("Expected Counterspell to be fully owned, got %+v", counterspell)
+	}
+
+	if report.TotalNeeded != 5 || report.TotalMissing != 3 {
+		t.Errorf("Expected 3 of 5 missing, got %d of %d", report.TotalMissing, report.TotalNeeded)
+	}
+
+	// Missing cards are listed first
+	if report.Cards[0].CardName != "Lightning Bolt" {
+		t.Errorf("Expected missing cards first, got %s", report.Cards[0].CardName)
+	}
+}
+
+func TestDeckUseCase_OwnershipReportExcludesLentCopies(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	deckRepo := newMockDeckRepository()
+	loanRepo := newMockLoanRepository()
+	deckUseCase := usecase.NewDeckUseCase(deckRepo, cardRepo, loanRepo)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Lightning Bolt", Quantity: 4})
+	loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: 1, CardName: "Lightning Bolt", Borrower: "Sam", Quantity: 3})
+	returned := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: 1, CardName: "Lightning Bolt", Borrower: "Kim", Quantity: 1, ReturnedAt: &returned})
+	// A borrowed copy is someone else's and neither owned nor lent
+	loanRepo.Create(ctx, &entity.Loan{UserID: 1, Direction: entity.LoanBorrowed, CardName: "Lightning Bolt", Borrower: "Alex", Quantity: 2})
+
+	burn, _ := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: "Burn", Format: "modern"})
+	deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: burn.ID, UserID: 1, CardName: "Lightning Bolt", Quantity: 4, Zone: entity.DeckZoneMainboard})
+
+	report, err := deckUseCase.OwnershipReport(ctx, burn.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	bolt := findStatus(report, "Lightning Bolt")
+	if bolt == nil || bolt.Owned != 4 || bolt.Lent != 3 || bolt.Available != 1 || bolt.Missing != 3 {
+		t.Errorf("Expected owned 4, lent 3, available 1, missing 3; got %+v", bolt)
+	}
+}
+
+func TestDeckUseCase_Validation(t *testing.T) {
+	ctx := context.Background()
+	deckUseCase := usecase.NewDeckUseCase(newMockDeckRepository(), newMockCardRepository(), newMockLoanRepository())
+
+	if _, err := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: " "}); err == nil {
+		t.Error("Expected error for empty deck name, got nil")
+	}
+	if _, err := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: "Deck", Format: "freeform"}); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+
+	deck, _ := deckUseCase.CreateDeck(ctx, usecase.DeckInput{UserID: 1, Name: "Deck"})
+	err := deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: deck.ID, UserID: 1, CardName: "Opt", Quantity: 1, Zone: "graveyard"})
+	if err == nil {
+		t.Error("Expected error for unknown zone, got nil")
+	}
+
+	// Another user cannot add to the deck
+	err = deckUseCase.AddEntry(ctx, usecase.AddDeckEntryInput{DeckID: deck.ID, UserID: 2, CardName: "Opt", Quantity: 1, Zone: entity.DeckZoneMainboard})
+	if err == nil {
+		t.Error("Expected error adding to another user's deck, got nil")
+	}
+}
@@ -0,0 +1,123 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+func TestDuplicateUseCase_FindAndMerge(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	auditRepo := &mockAuditRepository{}
+	duplicateUseCase := usecase.NewDuplicateUseCase(cardRepo, auditRepo)
+	ctx := context.Background()
+
+	early := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	binder := uint(7)
+	for _, card := range []entity.Card{
+		{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Quantity: 1, BuyingPrice: 900, BoughtDate: &late},
+		{UserID: 1, CardName: "solitude ", SetCode: "mh2", CollectorNumber: "32", Language: "english", Quantity: 3, ForTrade: 1, BuyingPrice: 500, BoughtDate: &early},
+		// Different finish, location, sold state or owner are not duplicates.
+		{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Foil: true, Quantity: 1},
+		{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Quantity: 1, LocationID: &binder},
+		{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Quantity: 1, SellDate: &late},
+		{UserID: 2, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Quantity: 1},
+		{UserID: 1, CardName: "Opt", Quantity: 2, BuyingPrice: 10},
+		{UserID: 1, CardName: "Opt", Quantity: 2, BuyingPrice: 15},
+	} {
+		card := card
+		card.Version = 1
+		cardRepo.Create(ctx, &card)
+	}
+
+	groups, err := duplicateUseCase.FindDuplicates(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 duplicate groups, got %d", len(groups))
+	}
+	if groups[0].Merged.CardName != "Opt" || groups[1].Merged.CardName != "Solitude" {
+		t.Errorf("Expected groups sorted by name, got %s and %s", groups[0].Merged.CardName, groups[1].Merged.CardName)
+	}
+
+	solitude := groups[1]
+	if ids := solitude.IDs(); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("Expected rows 1 and 2, got %v", ids)
+	}
+
+	merged, err := duplicateUseCase.MergeCards(ctx, 1, solitude.IDs())
+	if err != nil {
+		t.Fatalf("Expected merge to succeed, got %v", err)
+	}
+	if merged.ID != 1 || merged.Quantity != 4 || merged.ForTrade != 1 {
+		t.Errorf("Expected row 1 with 4 copies and 1 for trade, got %+v", merged)
+	}
+	// (1 x 900 + 3 x 500) / 4
+	if merged.BuyingPrice != 600 {
+		t.Errorf("Expected weighted average price 600, got %.2f", merged.BuyingPrice)
+	}
+	if !merged.BoughtDate.Equal(early) {
+		t.Errorf("Expected earliest bought date, got %v", merged.BoughtDate)
+	}
+	if _, err := cardRepo.FindByID(ctx, 2, 1); err == nil {
+		t.Error("Expected merged row to be deleted")
+	}
+	if stored, _ := cardRepo.FindByID(ctx, 1, 1); stored.Quantity != 4 {
+		t.Errorf("Expected stored quantity 4, got %d", stored.Quantity)
+	}
+
+	var updates, deletes int
+	for _, event := range auditRepo.events {
+		switch event.Action {
+		case entity.AuditActionUpdate:
+			updates++
+		case entity.AuditActionDelete:
+			deletes++
+		}
+	}
+	if updates != 1 || deletes != 1 {
+		t.Errorf("Expected one update and one delete event, got %d and %d", updates, deletes)
+	}
+}
+
+func TestDuplicateUseCase_MergeValidation(t *testing.T) {
+	cardRepo := newMockCardRepository()
+	duplicateUseCase := usecase.NewDuplicateUseCase(cardRepo, &mockAuditRepository{})
+	ctx := context.Background()
+
+	sold := time.Now()
+	for _, card := range []entity.Card{
+		{UserID: 1, CardName: "Opt", Quantity: 1},
+		{UserID: 1, CardName: "Opt", Quantity: 1, Foil: true},
+		{UserID: 1, CardName: "Opt", Quantity: 1, SellDate: &sold},
+		{UserID: 2, CardName: "Opt", Quantity: 1},
+	} {
+		card := card
+		cardRepo.Create(ctx, &card)
+	}
+
+	tests := []struct {
+		name string
+		ids  []uint
+	}{
+		{"single card", []uint{1}},
+		{"same card twice", []uint{1, 1}},
+		{"different finish", []uint{1, 2}},
+		{"sold card", []uint{1, 3}},
+		{"other user's card", []uint{1, 4}},
+	}
+	for _, tt := range tests {
+		if _, err := duplicateUseCase.MergeCards(ctx, 1, tt.ids); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	if _, err := cardRepo.FindByID(ctx, 1, 1); err != nil {
+		t.Errorf("Expected rejected merges to leave cards alone, got %v", err)
+	}
+}
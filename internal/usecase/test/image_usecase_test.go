@@ -0,0 +1,301 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockCardImageRepository struct {
+	images   map[string]*entity.CardImage
+	cardRepo *mockCardRepository
+}
+
+func newMockCardImageRepository(cardRepo *mockCardRepository) *mockCardImageRepository {
+	return &mockCardImageRepository{images: make(map[string]*entity.CardImage), cardRepo: cardRepo}
+}
+
+func (m *mockCardImageRepository) Create(ctx context.Context, image *entity.CardImage) error {
+	if existing, ok := m.images[image.Hash]; ok {
+		if existing.SourceURL == "" {
+			existing.SourceURL = image.SourceURL
+		}
+		*image = *existing
+		return nil
+	}
+	image.ID = uint(len(m.images) + 1)
+	stored := *image
+	m.images[image.Hash] = &stored
+	return nil
+}
+
+func (m *mockCardImageRepository) FindByHash(ctx context.Context, hash string) (*entity.CardImage, error) {
+	image, ok := m.images[hash]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	found := *image
+	return &found, nil
+}
+
+func (m *mockCardImageRepository) FindBySourceURL(ctx context.Context, url string) (*entity.CardImage, error) {
+	for _, image := range m.images {
+		if image.SourceURL == url {
+			found := *image
+			return &found, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *mockCardImageRepository) FindPendingCards(ctx context.Context, limit int) ([]entity.Card, error) {
+	var cards []entity.Card
+	for _, card := range m.cardRepo.cards {
+		if card.CardImageURL != "" && card.CardImageURL != card.ImageSourceURL {
+			cards = append(cards, *card)
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	if len(cards) > limit {
+		cards = cards[:limit]
+	}
+	return cards, nil
+}
+
+func (m *mockCardImageRepository) SetCardImage(ctx context.Context, cardID uint, sourceURL string, hash string) error {
+	card, ok := m.cardRepo.cards[cardID]
+	if !ok {
+		return errors.New("record not found")
+	}
+	card.ImageSourceURL = sourceURL
+	card.ImageHash = hash
+	return nil
+}
+
+type mockBlobStore struct {
+	blobs map[string][]byte
+}
+
+func newMockBlobStore() *mockBlobStore {
+	return &mockBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (m *mockBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *mockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, repository.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *mockBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
+
+// testPNG encodes a width x height red PNG whose top quarter is transparent.
+func testPNG(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := height / 4; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 30, B: 30, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestImageUseCase_CachePending(t *testing.T) {
+	ctx := context.Background()
+	picture := testPNG(745, 1040)
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bolt.png" {
+			http.NotFound(w, r)
+			return
+		}
+		downloads++
+		w.Write(picture)
+	}))
+	defer server.Close()
+
+	cardRepo := newMockCardRepository()
+	blobStore := newMockBlobStore()
+	imageUseCase := usecase.NewImageUseCase(newMockCardImageRepository(cardRepo), cardRepo, &mockCatalogRepository{}, blobStore, server.Client())
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Lightning Bolt", CardImageURL: server.URL + "/bolt.png", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 2, CardName: "Lightning Bolt", CardImageURL: server.URL + "/bolt.png", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Opt", CardImageURL: server.URL + "/missing.png", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Ponder", Quantity: 1})
+
+	cached, err := imageUseCase.CachePending(ctx, 50)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cached != 2 || downloads != 1 {
+		t.Errorf("Expected 2 cards cached from 1 download, got %d from %d", cached, downloads)
+	}
+	if cardRepo.cards[1].ImageHash == "" || cardRepo.cards[1].ImageHash != cardRepo.cards[2].ImageHash {
+		t.Errorf("Expected both Bolts to share one image, got %q and %q", cardRepo.cards[1].ImageHash, cardRepo.cards[2].ImageHash)
+	}
+	if cardRepo.cards[3].ImageHash != "" || cardRepo.cards[3].ImageSourceURL != cardRepo.cards[3].CardImageURL {
+		t.Errorf("Expected the failed download to be marked as fetched without an image, got %+v", cardRepo.cards[3])
+	}
+
+	blob, err := imageUseCase.OpenCardImage(ctx, 1, 1, entity.ImageSizeSmall)
+	if err != nil {
+		t.Fatalf("Expected the small thumbnail, got %v", err)
+	}
+	thumbnail, format, err := image.Decode(blob.Body)
+	if err != nil || format != "jpeg" || blob.ContentType != "image/jpeg" {
+		t.Fatalf("Expected a JPEG thumbnail, got %s (%v)", format, err)
+	}
+	if bounds := thumbnail.Bounds(); bounds.Dx() != 146 || bounds.Dy() != 203 {
+		t.Errorf("Expected a 146x203 thumbnail, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if r, g, b, _ := thumbnail.At(70, 0).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("Expected the transparent area to turn white, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+
+	original, err := imageUseCase.OpenCardImage(ctx, 1, 1, entity.ImageSizeOriginal)
+	if err != nil || original.ContentType != "image/png" {
+		t.Fatalf("Expected the original PNG, got %+v (%v)", original, err)
+	}
+	if data, _ := io.ReadAll(original.Body); !bytes.Equal(data, picture) {
+		t.Error("Expected the original file unchanged")
+	}
+	if len(blobStore.blobs) != 4 {
+		t.Errorf("Expected 3 thumbnails and the original, got %d blobs", len(blobStore.blobs))
+	}
+
+	if _, err := imageUseCase.OpenCardImage(ctx, 2, 1, entity.ImageSizeSmall); err == nil {
+		t.Error("Expected another user's card image to be rejected")
+	}
+	if _, err := imageUseCase.OpenCardImage(ctx, 1, 4, entity.ImageSizeSmall); !errors.Is(err, usecase.ErrNoCardImage) {
+		t.Errorf("Expected ErrNoCardImage, got %v", err)
+	}
+	if _, err := imageUseCase.OpenCardImage(ctx, 1, 1, "huge"); err == nil {
+		t.Error("Expected an unknown size to be rejected")
+	}
+
+	cached, _ = imageUseCase.CachePending(ctx, 50)
+	if cached != 0 || downloads != 1 {
+		t.Errorf("Expected nothing left to fetch, got %d cached and %d downloads", cached, downloads)
+	}
+}
+
+func TestImageUseCase_UploadCardImage(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	imageUseCase := usecase.NewImageUseCase(newMockCardImageRepository(cardRepo), cardRepo, &mockCatalogRepository{}, newMockBlobStore(), http.DefaultClient)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Black Lotus", CardImageURL: "https://example.com/lotus.jpg", Quantity: 1})
+
+	tests := []struct {
+		name    string
+		userID  uint
+		data    []byte
+		wantErr error
+	}{
+		{"not an image", 1, []byte("just some text"), usecase.ErrUnsupportedImage},
+		{"too large", 1, bytes.Repeat([]byte{0}, 10<<20+1), usecase.ErrImageTooLarge},
+		{"other user", 2, testPNG(20, 28), nil},
+		{"valid", 1, testPNG(20, 28), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := imageUseCase.UploadCardImage(ctx, tt.userID, 1, bytes.NewReader(tt.data))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+			case tt.userID != 1:
+				if err == nil {
+					t.Error("Expected another user's card to be rejected")
+				}
+			case err != nil:
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+
+	card := cardRepo.cards[1]
+	if card.ImageHash == "" || card.ImageSourceURL != card.CardImageURL {
+		t.Errorf("Expected the upload to be shown in place of the image URL, got %+v", card)
+	}
+
+	blob, err := imageUseCase.OpenCardImage(ctx, 1, 1, entity.ImageSizeLarge)
+	if err != nil {
+		t.Fatalf("Expected the large rendition, got %v", err)
+	}
+	if img, _, err := image.Decode(blob.Body); err != nil || img.Bounds().Dx() != 20 {
+		t.Errorf("Expected small uploads not to be scaled up, got %v", err)
+	}
+	if !strings.HasPrefix(blob.ContentType, "image/") {
+		t.Errorf("Expected an image content type, got %q", blob.ContentType)
+	}
+}
+
+func TestImageUseCase_OpenTranslatedImage(t *testing.T) {
+	ctx := context.Background()
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write(testPNG(745, 1040))
+	}))
+	defer server.Close()
+
+	cardRepo := newMockCardRepository()
+	catalogRepo := &mockCatalogRepository{translations: []entity.CatalogTranslation{
+		{ID: 1, SetCode: "m10", CollectorNumber: "146", Lang: "ja", Name: "稲妻", ImageURL: server.URL + "/ja/bolt.png"},
+	}}
+	imageUseCase := usecase.NewImageUseCase(newMockCardImageRepository(cardRepo), cardRepo, catalogRepo, newMockBlobStore(), server.Client())
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Lightning Bolt", SetCode: "M10", CollectorNumber: "146", Language: "ja", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Lightning Bolt", SetCode: "M10", CollectorNumber: "146", Language: "en", Quantity: 1})
+
+	for i := 0; i < 2; i++ {
+		blob, err := imageUseCase.OpenTranslatedImage(ctx, 1, 1, entity.ImageSizeNormal)
+		if err != nil {
+			t.Fatalf("Expected the Japanese image, got %v", err)
+		}
+		if img, _, err := image.Decode(blob.Body); err != nil || img.Bounds().Dx() != 488 {
+			t.Errorf("Expected a 488 pixel wide thumbnail, got %v", err)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("Expected the image to be downloaded once, got %d downloads", downloads)
+	}
+
+	if _, err := imageUseCase.OpenTranslatedImage(ctx, 1, 2, entity.ImageSizeNormal); !errors.Is(err, usecase.ErrNoCardImage) {
+		t.Errorf("Expected ErrNoCardImage for an English card, got %v", err)
+	}
+	if _, err := imageUseCase.OpenTranslatedImage(ctx, 2, 1, entity.ImageSizeNormal); err == nil {
+		t.Error("Expected another user's card to be rejected")
+	}
+}
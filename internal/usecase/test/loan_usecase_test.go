@@ -0,0 +1,281 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockLoanRepository struct {
+	loans  map[uint]*entity.Loan
+	nextID uint
+}
+
+func newMockLoanRepository() *mockLoanRepository {
+	return &mockLoanRepository{loans: make(map[uint]*entity.Loan), nextID: 1}
+}
+
+func (m *mockLoanRepository) Create(ctx context.Context, loan *entity.Loan) error {
+	loan.ID = m.nextID
+	m.nextID++
+	stored := *loan
+	m.loans[loan.ID] = &stored
+	return nil
+}
+
+// lent returns the copies of a card on outstanding loans. A nil repository
+// has none, so mocks can leave their loan repository unset.
+func (m *mockLoanRepository) lent(cardID uint) int {
+	if m == nil {
+		return 0
+	}
+	lent := 0
+	for _, loan := range m.loans {
+		if loan.CardID == cardID && loan.IsOutstanding() {
+			lent += loan.Quantity
+		}
+	}
+	return lent
+}
+
+func (m *mockLoanRepository) Update(ctx context.Context, loan *entity.Loan) error {
+	stored := *loan
+	m.loans[loan.ID] = &stored
+	return nil
+}
+
+func (m *mockLoanRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	loan, ok := m.loans[id]
+	if !ok || loan.UserID != userID {
+		return errors.New("record not found")
+	}
+	delete(m.loans, id)
+	return nil
+}
+
+func (m *mockLoanRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Loan, error) {
+	loan, ok := m.loans[id]
+	if !ok || loan.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *loan
+	return &found, nil
+}
+
+func (m *mockLoanRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Loan, error) {
+	var loans []entity.Loan
+	for _, loan := range m.loans {
+		if loan.UserID == userID {
+			loans = append(loans, *loan)
+		}
+	}
+	sort.Slice(loans, func(i, j int) bool { return loans[i].ID > loans[j].ID })
+	return loans, nil
+}
+
+func (m *mockLoanRepository) FindOutstanding(ctx context.Context, userID uint) ([]entity.Loan, error) {
+	var loans []entity.Loan
+	for _, loan := range m.loans {
+		if loan.UserID == userID && loan.ReturnedAt == nil {
+			loans = append(loans, *loan)
+		}
+	}
+	sort.Slice(loans, func(i, j int) bool {
+		a, b := loans[i].DueAt, loans[j].DueAt
+		if (a == nil) != (b == nil) {
+			return b == nil
+		}
+		if a != nil && !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return loans[i].ID < loans[j].ID
+	})
+	return loans, nil
+}
+
+func (m *mockLoanRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, loan := range m.loans {
+		if loan.UserID == userID {
+			delete(m.loans, id)
+		}
+	}
+	return nil
+}
+
+func TestLoanUseCase_Lend(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	loanUseCase := usecase.NewLoanUseCase(newMockLoanRepository(), cardRepo)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Sol Ring", Quantity: 3})
+	sold := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Mana Crypt", Quantity: 1, SellDate: &sold})
+
+	lentAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	tooEarly := time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)
+	valid := usecase.LendInput{UserID: 1, CardID: 1, Borrower: " Sam ", Quantity: 2, LentAt: lentAt, DueAt: &dueAt}
+
+	tests := []struct {
+		name   string
+		modify func(input *usecase.LendInput)
+	}{
+		{"missing borrower", func(input *usecase.LendInput) { input.Borrower = "  " }},
+		{"zero quantity", func(input *usecase.LendInput) { input.Quantity = 0 }},
+		{"missing lent date", func(input *usecase.LendInput) { input.LentAt = time.Time{} }},
+		{"due before lent", func(input *usecase.LendInput) { input.DueAt = &tooEarly }},
+		{"more copies than owned", func(input *usecase.LendInput) { input.Quantity = 4 }},
+		{"sold card", func(input *usecase.LendInput) { input.CardID = 2; input.Quantity = 1 }},
+		{"another user's card", func(input *usecase.LendInput) { input.UserID = 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid
+			tt.modify(&input)
+			if _, err := loanUseCase.Lend(ctx, input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	loan, err := loanUseCase.Lend(ctx, valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if loan.Borrower != "Sam" || loan.CardName != "Sol Ring" || !loan.IsOutstanding() {
+		t.Errorf("Expected an outstanding loan of Sol Ring to Sam, got %+v", loan)
+	}
+
+	// Only the copy that is not lent out can be lent again
+	if _, err := loanUseCase.Lend(ctx, valid); err == nil {
+		t.Error("Expected error when lending copies already lent out, got nil")
+	}
+	valid.Quantity = 1
+	if _, err := loanUseCase.Lend(ctx, valid); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lent, err := loanUseCase.LentQuantities(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lent[1] != 3 {
+		t.Errorf("Expected 3 copies lent, got %d", lent[1])
+	}
+}
+
+func TestLoanUseCase_Borrow(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	loanUseCase := usecase.NewLoanUseCase(newMockLoanRepository(), cardRepo)
+
+	borrowedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	tooEarly := time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)
+	valid := usecase.BorrowInput{UserID: 1, CardName: " Force of Will ", SetCode: "all", Lender: " Kim ", Quantity: 2, BorrowedAt: borrowedAt, DueAt: &dueAt}
+
+	tests := []struct {
+		name   string
+		modify func(input *usecase.BorrowInput)
+	}{
+		{"missing card name", func(input *usecase.BorrowInput) { input.CardName = " " }},
+		{"missing lender", func(input *usecase.BorrowInput) { input.Lender = "" }},
+		{"zero quantity", func(input *usecase.BorrowInput) { input.Quantity = 0 }},
+		{"missing borrowed date", func(input *usecase.BorrowInput) { input.BorrowedAt = time.Time{} }},
+		{"due before borrowed", func(input *usecase.BorrowInput) { input.DueAt = &tooEarly }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid
+			tt.modify(&input)
+			if _, err := loanUseCase.Borrow(ctx, input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	loan, err := loanUseCase.Borrow(ctx, valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !loan.IsBorrowed() || loan.CardID != 0 || loan.CardName != "Force of Will" || loan.Borrower != "Kim" {
+		t.Errorf("Expected Force of Will borrowed from Kim without a card row, got %+v", loan)
+	}
+	if len(cardRepo.cards) != 0 {
+		t.Errorf("Expected borrowed copies to stay out of the collection, got %d cards", len(cardRepo.cards))
+	}
+
+	lent, _ := loanUseCase.LentQuantities(ctx, 1)
+	if len(lent) != 0 {
+		t.Errorf("Expected borrowed copies not to count as lent, got %v", lent)
+	}
+	overdue, _ := loanUseCase.Overdue(ctx, 1, dueAt.AddDate(0, 0, 2))
+	if len(overdue) != 1 || overdue[0].ID != loan.ID {
+		t.Errorf("Expected the borrowed copies to be due back, got %+v", overdue)
+	}
+	if err := loanUseCase.MarkReturned(ctx, loan.ID, 1, dueAt); err != nil {
+		t.Errorf("Expected no error giving the copies back, got %v", err)
+	}
+}
+
+func TestLoanUseCase_MarkReturnedAndOverdue(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	loanUseCase := usecase.NewLoanUseCase(newMockLoanRepository(), cardRepo)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Sol Ring", Quantity: 3})
+	lentAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	earlyDue := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	todayDue := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	late, _ := loanUseCase.Lend(ctx, usecase.LendInput{UserID: 1, CardID: 1, Borrower: "Sam", Quantity: 1, LentAt: lentAt, DueAt: &earlyDue})
+	dueToday, _ := loanUseCase.Lend(ctx, usecase.LendInput{UserID: 1, CardID: 1, Borrower: "Kim", Quantity: 1, LentAt: lentAt, DueAt: &todayDue})
+	loanUseCase.Lend(ctx, usecase.LendInput{UserID: 1, CardID: 1, Borrower: "Alex", Quantity: 1, LentAt: lentAt})
+
+	now := todayDue.Add(12 * time.Hour)
+	overdue, err := loanUseCase.Overdue(ctx, 1, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(overdue) != 1 || overdue[0].ID != late.ID {
+		t.Errorf("Expected only Sam's loan to be overdue, got %+v", overdue)
+	}
+
+	if err := loanUseCase.MarkReturned(ctx, late.ID, 1, lentAt.AddDate(0, 0, -1)); err == nil {
+		t.Error("Expected error for a return before the loan, got nil")
+	}
+	if err := loanUseCase.MarkReturned(ctx, late.ID, 2, now); err == nil {
+		t.Error("Expected error for another user's loan, got nil")
+	}
+	if err := loanUseCase.MarkReturned(ctx, late.ID, 1, todayDue); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := loanUseCase.MarkReturned(ctx, late.ID, 1, todayDue); err == nil {
+		t.Error("Expected error for a loan already returned, got nil")
+	}
+
+	overdue, _ = loanUseCase.Overdue(ctx, 1, now)
+	if len(overdue) != 0 {
+		t.Errorf("Expected no overdue loans after the return, got %+v", overdue)
+	}
+
+	outstanding, returned, err := loanUseCase.Loans(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(outstanding) != 2 || outstanding[0].ID != dueToday.ID {
+		t.Errorf("Expected 2 outstanding loans, dated first, got %+v", outstanding)
+	}
+	if len(returned) != 1 || returned[0].ID != late.ID {
+		t.Errorf("Expected Sam's loan to be returned, got %+v", returned)
+	}
+
+	// The returned copy can be lent again
+	if _, err := loanUseCase.Lend(ctx, usecase.LendInput{UserID: 1, CardID: 1, Borrower: "Sam", Quantity: 1, LentAt: todayDue.AddDate(0, 0, 1)}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
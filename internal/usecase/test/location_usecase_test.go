@@ -0,0 +1,220 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockLocationRepository struct {
+	locations map[uint]*entity.Location
+	nextID    uint
+}
+
+func newMockLocationRepository() *mockLocationRepository {
+	return &mockLocationRepository{
+		locations: make(map[uint]*entity.Location),
+		nextID:    1,
+	}
+}
+
+func (m *mockLocationRepository) Create(ctx context.Context, location *entity.Location) error {
+	location.ID = m.nextID
+	m.nextID++
+	stored := *location
+	m.locations[location.ID] = &stored
+	return nil
+}
+
+func (m *mockLocationRepository) Update(ctx context.Context, location *entity.Location) error {
+	stored := *location
+	m.locations[location.ID] = &stored
+	return nil
+}
+
+func (m *mockLocationRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	delete(m.locations, id)
+	return nil
+}
+
+func (m *mockLocationRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Location, error) {
+	location, ok := m.locations[id]
+	if !ok || location.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *location
+	return &found, nil
+}
+
+func (m *mockLocationRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Location, error) {
+	var locations []entity.Location
+	for _, location := range m.locations {
+		if location.UserID == userID {
+			locations = append(locations, *location)
+		}
+	}
+	return locations, nil
+}
+
+func (m *mockLocationRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, location := range m.locations {
+		if location.UserID == userID {
+			delete(m.locations, id)
+		}
+	}
+	return nil
+}
+
+type locationFixture struct {
+	cardRepo        *mockCardRepository
+	auditRepo       *mockAuditRepository
+	locationUseCase *usecase.LocationUseCase
+	shelf           *entity.Location
+	box             *entity.Location
+	binder          *entity.Location
+}
+
+func newLocationFixture(t *testing.T) *locationFixture {
+	ctx := context.Background()
+	f := &locationFixture{
+		cardRepo:  newMockCardRepository(),
+		auditRepo: &mockAuditRepository{},
+	}
+	f.locationUseCase = usecase.NewLocationUseCase(newMockLocationRepository(), f.cardRepo, f.auditRepo, mockTransactor{})
+
+	var err error
+	f.shelf, err = f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, Kind: entity.LocationKindShelf, Name: "Shelf A"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	f.box, err = f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, ParentID: &f.shelf.ID, Kind: entity.LocationKindBox, Name: "Box 1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	f.binder, err = f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, Kind: entity.LocationKindBinder, Name: "Trade Binder"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return f
+}
+
+func TestLocationUseCase_Hierarchy(t *testing.T) {
+	f := newLocationFixture(t)
+	ctx := context.Background()
+
+	// A shelf cannot go inside a box
+	_, err := f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, ParentID: &f.box.ID, Kind: entity.LocationKindShelf, Name: "Shelf B"})
+	if err == nil {
+		t.Error("Expected error for shelf inside box, got nil")
+	}
+
+	// A slot is the innermost level and holds nothing else
+	slot, err := f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, ParentID: &f.binder.ID, Kind: entity.LocationKindSlot, Name: "Slot 1"})
+	if err != nil {
+		t.Fatalf("Expected slot inside binder, got %v", err)
+	}
+	_, err = f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 1, ParentID: &slot.ID, Kind: entity.LocationKindSlot, Name: "Slot 2"})
+	if err == nil {
+		t.Error("Expected error for slot inside slot, got nil")
+	}
+	f.locationUseCase.DeleteLocation(ctx, slot.ID, 1)
+
+	// Another user's location cannot be used as a parent
+	_, err = f.locationUseCase.CreateLocation(ctx, usecase.CreateLocationInput{UserID: 2, ParentID: &f.shelf.ID, Kind: entity.LocationKindBox, Name: "Box"})
+	if err == nil {
+		t.Error("Expected error for another user's parent, got nil")
+	}
+
+	nodes, err := f.locationUseCase.ListLocations(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("Expected 3 locations, got %d", len(nodes))
+	}
+	if nodes[0].Path != "Shelf A" || nodes[1].Path != "Shelf A > Box 1" || nodes[1].Depth != 1 || nodes[2].Path != "Trade Binder" {
+		t.Errorf("Unexpected tree order: %q, %q, %q", nodes[0].Path, nodes[1].Path, nodes[2].Path)
+	}
+
+	ids, err := f.locationUseCase.Subtree(ctx, f.shelf.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected shelf subtree to contain 2 locations, got %v", ids)
+	}
+
+	// Non-empty locations cannot be deleted
+	if err := f.locationUseCase.DeleteLocation(ctx, f.shelf.ID, 1); err == nil {
+		t.Error("Expected error deleting a location with children, got nil")
+	}
+}
+
+func TestLocationUseCase_MoveCards(t *testing.T) {
+	f := newLocationFixture(t)
+	ctx := context.Background()
+
+	cardUseCase := usecase.NewCardUseCase(f.cardRepo, f.auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Lightning Bolt", Quantity: 4})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Counterspell", Quantity: 2})
+
+	// Move the whole Counterspell row into the box
+	moved, err := f.locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{2}, LocationID: &f.box.ID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if moved != 1 || f.cardRepo.cards[2].LocationID == nil || *f.cardRepo.cards[2].LocationID != f.box.ID {
+		t.Errorf("Expected Counterspell in the box, got %+v", f.cardRepo.cards[2])
+	}
+
+	// Split one Lightning Bolt off into the binder
+	_, err = f.locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{1}, LocationID: &f.binder.ID, Quantity: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if f.cardRepo.cards[1].Quantity != 3 || f.cardRepo.cards[1].LocationID != nil {
+		t.Errorf("Expected 3 unassigned copies to remain, got %+v", f.cardRepo.cards[1])
+	}
+	inBinder, _, _ := f.cardRepo.FindByUserID(ctx, 1, 1, 20, repository.CardFilter{LocationIDs: []uint{f.binder.ID}})
+	if len(inBinder) != 1 || inBinder[0].Quantity != 1 || inBinder[0].CardName != "Lightning Bolt" {
+		t.Errorf("Expected 1 Lightning Bolt in the binder, got %+v", inBinder)
+	}
+
+	// Filtering by the shelf includes cards in the box below it
+	ids, _ := f.locationUseCase.Subtree(ctx, f.shelf.ID, 1)
+	onShelf, _, _ := f.cardRepo.FindByUserID(ctx, 1, 1, 20, repository.CardFilter{LocationIDs: ids})
+	if len(onShelf) != 1 || onShelf[0].CardName != "Counterspell" {
+		t.Errorf("Expected Counterspell on the shelf, got %+v", onShelf)
+	}
+
+	// The split is recorded as an update of the source and a new row
+	var created int
+	for _, event := range f.auditRepo.events {
+		if event.Action == entity.AuditActionCreate {
+			created++
+		}
+	}
+	if created != 3 {
+		t.Errorf("Expected 3 create events, got %d", created)
+	}
+
+	// A split cannot take copies that are lent out, as the loan stays behind
+	f.cardRepo.loanRepo = newMockLoanRepository()
+	f.cardRepo.loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: 1, Quantity: 3, LentAt: time.Now()})
+	_, err = f.locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 1, CardIDs: []uint{1}, LocationID: &f.box.ID, Quantity: 1})
+	if !errors.Is(err, repository.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent, got %v", err)
+	}
+
+	// Other users' locations are rejected
+	_, err = f.locationUseCase.MoveCards(ctx, usecase.MoveCardsInput{UserID: 2, CardIDs: []uint{1}, LocationID: &f.box.ID})
+	if err == nil {
+		t.Error("Expected error moving to another user's location, got nil")
+	}
+}
@@ -0,0 +1,319 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+// mockLotRepository saves cards through a mock card repository so lot
+// changes and the card rows they derive can be checked together.
+type mockLotRepository struct {
+	lots     map[uint]*entity.PurchaseLot
+	sales    []entity.CardSale
+	nextID   uint
+	cardRepo *mockCardRepository
+}
+
+func newMockLotRepository(cardRepo *mockCardRepository) *mockLotRepository {
+	return &mockLotRepository{lots: make(map[uint]*entity.PurchaseLot), nextID: 1, cardRepo: cardRepo}
+}
+
+func (m *mockLotRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.PurchaseLot, error) {
+	var lots []entity.PurchaseLot
+	for _, lot := range m.lots {
+		if lot.CardID == cardID && lot.UserID == userID {
+			lots = append(lots, *lot)
+		}
+	}
+	sortMockLots(lots)
+	return lots, nil
+}
+
+func (m *mockLotRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.PurchaseLot, error) {
+	var lots []entity.PurchaseLot
+	for _, lot := range m.lots {
+		if lot.UserID == userID {
+			lots = append(lots, *lot)
+		}
+	}
+	sortMockLots(lots)
+	return lots, nil
+}
+
+func sortMockLots(lots []entity.PurchaseLot) {
+	sort.Slice(lots, func(i, j int) bool {
+		a, b := lots[i].AcquiredAt, lots[j].AcquiredAt
+		if (a == nil) != (b == nil) {
+			return a == nil
+		}
+		if a != nil && !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return lots[i].ID < lots[j].ID
+	})
+}
+
+func (m *mockLotRepository) SaveLots(ctx context.Context, card *entity.Card, lots []entity.PurchaseLot) error {
+	if err := m.cardRepo.Update(ctx, card); err != nil {
+		return err
+	}
+	m.saveLots(lots)
+	return nil
+}
+
+func (m *mockLotRepository) saveLots(lots []entity.PurchaseLot) {
+	for i := range lots {
+		if lots[i].ID == 0 {
+			lots[i].ID = m.nextID
+			m.nextID++
+		}
+		stored := lots[i]
+		m.lots[stored.ID] = &stored
+	}
+}
+
+func (m *mockLotRepository) RecordSale(ctx context.Context, card *entity.Card, sold *entity.Card, lots []entity.PurchaseLot, sale *entity.CardSale) error {
+	if sold == nil && m.cardRepo.loanRepo.lent(card.ID) > 0 {
+		return repository.ErrCopiesLent
+	}
+	if err := m.cardRepo.Update(ctx, card); err != nil {
+		return err
+	}
+	sale.SoldCardID = card.ID
+	if sold != nil {
+		m.cardRepo.Create(ctx, sold)
+		sale.SoldCardID = sold.ID
+	}
+	m.saveLots(lots)
+	sale.ID = uint(len(m.sales) + 1)
+	m.sales = append(m.sales, *sale)
+	return nil
+}
+
+func (m *mockLotRepository) FindSalesByUserID(ctx context.Context, userID uint) ([]entity.CardSale, error) {
+	var sales []entity.CardSale
+	for _, sale := range m.sales {
+		if sale.UserID == userID {
+			sales = append(sales, sale)
+		}
+	}
+	return sales, nil
+}
+
+func (m *mockLotRepository) FindSalesByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardSale, error) {
+	var sales []entity.CardSale
+	for _, sale := range m.sales {
+		if sale.UserID == userID && (sale.CardID == cardID || sale.SoldCardID == cardID) {
+			sales = append(sales, sale)
+		}
+	}
+	return sales, nil
+}
+
+func (m *mockLotRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, lot := range m.lots {
+		if lot.UserID == userID {
+			delete(m.lots, id)
+		}
+	}
+	var kept []entity.CardSale
+	for _, sale := range m.sales {
+		if sale.UserID != userID {
+			kept = append(kept, sale)
+		}
+	}
+	m.sales = kept
+	return nil
+}
+
+type lotFixture struct {
+	cardRepo   *mockCardRepository
+	userRepo   *mockUserRepository
+	lotRepo    *mockLotRepository
+	lotUseCase *usecase.LotUseCase
+}
+
+// newLotFixture sets up a user holding two Sol Rings bought at 10 in January
+// 2024, with two more bought at 20 in June recorded as a lot.
+func newLotFixture(t *testing.T, method entity.CostMethod) *lotFixture {
+	f := &lotFixture{cardRepo: newMockCardRepository(), userRepo: newMockUserRepository()}
+	f.lotRepo = newMockLotRepository(f.cardRepo)
+	f.lotUseCase = usecase.NewLotUseCase(f.lotRepo, f.cardRepo, f.userRepo, &mockAuditRepository{})
+
+	ctx := context.Background()
+	f.userRepo.Create(ctx, &entity.User{ID: 1, Username: "alice", CostMethod: method})
+
+	january := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	cardUseCase := usecase.NewCardUseCase(f.cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Sol Ring", Quantity: 2, BuyingPrice: 10, BoughtDate: &january})
+
+	june := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	err := f.lotUseCase.AddLot(ctx, usecase.AddLotInput{UserID: 1, CardID: 1, Quantity: 2, UnitCost: 20, AcquiredAt: &june, Source: "LGS"})
+	if err != nil {
+		t.Fatalf("Expected no error adding lot, got %v", err)
+	}
+	return f
+}
+
+func TestLotUseCase_AddLot(t *testing.T) {
+	f := newLotFixture(t, entity.CostMethodFIFO)
+	ctx := context.Background()
+
+	lots, err := f.lotUseCase.Lots(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(lots) != 2 || lots[0].Source != "opening balance" || lots[0].Remaining != 2 || lots[1].UnitCost != 20 {
+		t.Fatalf("Expected an opening lot and the new lot, got %+v", lots)
+	}
+
+	card, _ := f.cardRepo.FindByID(ctx, 1, 1)
+	if card.Quantity != 4 || card.BuyingPrice != 15 {
+		t.Errorf("Expected 4 copies at an average of 15, got %d at %.2f", card.Quantity, card.BuyingPrice)
+	}
+	if card.BoughtDate == nil || card.BoughtDate.Month() != time.January {
+		t.Errorf("Expected the oldest lot's date to be kept, got %v", card.BoughtDate)
+	}
+
+	if err := f.lotUseCase.AddLot(ctx, usecase.AddLotInput{UserID: 1, CardID: 1, Quantity: 0, UnitCost: 5}); err == nil {
+		t.Error("Expected error for zero copies, got nil")
+	}
+	if err := f.lotUseCase.AddLot(ctx, usecase.AddLotInput{UserID: 2, CardID: 1, Quantity: 1, UnitCost: 5}); err == nil {
+		t.Error("Expected error for another user's card, got nil")
+	}
+}
+
+func TestLotUseCase_RecordSale(t *testing.T) {
+	tests := []struct {
+		method        entity.CostMethod
+		costBasis     float64
+		leftUnitPrice float64
+	}{
+		{entity.CostMethodFIFO, 2*10 + 20, 20},
+		{entity.CostMethodLIFO, 2*20 + 10, 10},
+		{entity.CostMethodAverage, 3 * 15, 15},
+	}
+
+	for _, tt := range tests {
+		f := newLotFixture(t, tt.method)
+		ctx := context.Background()
+
+		sale, err := f.lotUseCase.RecordSale(ctx, usecase.RecordSaleInput{
+			UserID: 1, CardID: 1, Quantity: 3, UnitPrice: 30,
+			SoldAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.method, err)
+		}
+		if sale.Method != tt.method || math.Abs(sale.CostBasis()-tt.costBasis) > 0.001 {
+			t.Errorf("%s: expected cost basis %.2f, got %.2f", tt.method, tt.costBasis, sale.CostBasis())
+		}
+		if math.Abs(sale.Gain()-(90-tt.costBasis)) > 0.001 {
+			t.Errorf("%s: expected gain %.2f, got %.2f", tt.method, 90-tt.costBasis, sale.Gain())
+		}
+
+		left, _ := f.cardRepo.FindByID(ctx, 1, 1)
+		if left.Quantity != 1 || left.SellDate != nil || left.BuyingPrice != tt.leftUnitPrice {
+			t.Errorf("%s: expected 1 unsold copy at %.2f left, got %+v", tt.method, tt.leftUnitPrice, left)
+		}
+
+		sold, err := f.cardRepo.FindByID(ctx, sale.SoldCardID, 1)
+		if err != nil || sold.ID == 1 || sold.Quantity != 3 || sold.SellDate == nil || sold.LocationID != nil {
+			t.Errorf("%s: expected the sold copies in a new sold row, got %+v (%v)", tt.method, sold, err)
+		}
+
+		// Selling the last copy marks the stack itself as sold.
+		last, err := f.lotUseCase.RecordSale(ctx, usecase.RecordSaleInput{
+			UserID: 1, CardID: 1, Quantity: 1, UnitPrice: 25,
+			SoldAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.method, err)
+		}
+		if last.SoldCardID != 1 || math.Abs(last.CostBasis()-tt.leftUnitPrice) > 0.001 {
+			t.Errorf("%s: expected the last copy at %.2f from card 1, got %+v", tt.method, tt.leftUnitPrice, last)
+		}
+
+		gains, _ := f.lotUseCase.RealizedGains(ctx, 1)
+		if len(gains.Sales) != 2 || math.Abs(gains.CostBasis-60) > 0.001 || math.Abs(gains.Gain-(115-60)) > 0.001 {
+			t.Errorf("%s: expected a total cost basis of 60 over two sales, got %+v", tt.method, gains)
+		}
+	}
+}
+
+func TestLotUseCase_RecordSaleReconcilesQuantity(t *testing.T) {
+	f := newLotFixture(t, entity.CostMethodFIFO)
+	ctx := context.Background()
+
+	// Two copies leave the stack outside of the lot tracking, e.g. in a
+	// trade; the oldest lot gives them up.
+	card, _ := f.cardRepo.FindByID(ctx, 1, 1)
+	card.Quantity = 2
+	f.cardRepo.Update(ctx, card)
+
+	sale, err := f.lotUseCase.RecordSale(ctx, usecase.RecordSaleInput{
+		UserID: 1, CardID: 1, Quantity: 1, UnitPrice: 30, SoldAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sale.CostBasis() != 20 {
+		t.Errorf("Expected the sale to come from the June lot, got cost %.2f", sale.CostBasis())
+	}
+}
+
+func TestLotUseCase_RecordSaleKeepsLentCopies(t *testing.T) {
+	f := newLotFixture(t, entity.CostMethodFIFO)
+	ctx := context.Background()
+	f.cardRepo.loanRepo = newMockLoanRepository()
+	f.cardRepo.loanRepo.Create(ctx, &entity.Loan{UserID: 1, CardID: 1, CardName: "Sol Ring", Borrower: "Sam", Quantity: 2, LentAt: time.Now()})
+
+	sale := usecase.RecordSaleInput{UserID: 1, CardID: 1, Quantity: 3, UnitPrice: 30, SoldAt: time.Now()}
+	if _, err := f.lotUseCase.RecordSale(ctx, sale); !errors.Is(err, repository.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent selling lent copies, got %v", err)
+	}
+	sale.Quantity = 2
+	if _, err := f.lotUseCase.RecordSale(ctx, sale); err != nil {
+		t.Fatalf("Expected the copies not lent out to sell, got %v", err)
+	}
+	// Only the lent copies are left, so the stack cannot be sold as a whole
+	if _, err := f.lotUseCase.RecordSale(ctx, sale); !errors.Is(err, repository.ErrCopiesLent) {
+		t.Errorf("Expected ErrCopiesLent selling the rest of the stack, got %v", err)
+	}
+	if len(f.lotRepo.sales) != 1 {
+		t.Errorf("Expected 1 sale recorded, got %d", len(f.lotRepo.sales))
+	}
+}
+
+func TestLotUseCase_RecordSaleValidation(t *testing.T) {
+	f := newLotFixture(t, entity.CostMethodFIFO)
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		input usecase.RecordSaleInput
+	}{
+		{"no copies", usecase.RecordSaleInput{UserID: 1, CardID: 1, Quantity: 0, UnitPrice: 1, SoldAt: now}},
+		{"too many copies", usecase.RecordSaleInput{UserID: 1, CardID: 1, Quantity: 5, UnitPrice: 1, SoldAt: now}},
+		{"negative price", usecase.RecordSaleInput{UserID: 1, CardID: 1, Quantity: 1, UnitPrice: -1, SoldAt: now}},
+		{"no date", usecase.RecordSaleInput{UserID: 1, CardID: 1, Quantity: 1, UnitPrice: 1}},
+		{"missing card", usecase.RecordSaleInput{UserID: 1, CardID: 9, Quantity: 1, UnitPrice: 1, SoldAt: now}},
+	}
+	for _, tt := range tests {
+		if _, err := f.lotUseCase.RecordSale(ctx, tt.input); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+	if len(f.lotRepo.sales) != 0 {
+		t.Errorf("Expected no sales recorded, got %d", len(f.lotRepo.sales))
+	}
+}
@@ -0,0 +1,223 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockCardPhotoRepository struct {
+	photos map[uint]*entity.CardPhoto
+	nextID uint
+}
+
+func newMockCardPhotoRepository() *mockCardPhotoRepository {
+	return &mockCardPhotoRepository{photos: make(map[uint]*entity.CardPhoto), nextID: 1}
+}
+
+func (m *mockCardPhotoRepository) Create(ctx context.Context, photo *entity.CardPhoto) error {
+	photo.ID = m.nextID
+	m.nextID++
+	stored := *photo
+	m.photos[photo.ID] = &stored
+	return nil
+}
+
+func (m *mockCardPhotoRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	photo, ok := m.photos[id]
+	if !ok || photo.UserID != userID {
+		return errors.New("record not found")
+	}
+	delete(m.photos, id)
+	return nil
+}
+
+func (m *mockCardPhotoRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.CardPhoto, error) {
+	photo, ok := m.photos[id]
+	if !ok || photo.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *photo
+	return &found, nil
+}
+
+func (m *mockCardPhotoRepository) FindByCardID(ctx context.Context, cardID uint, userID uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	for id := uint(1); id < m.nextID; id++ {
+		if photo, ok := m.photos[id]; ok && photo.CardID == cardID && photo.UserID == userID {
+			photos = append(photos, *photo)
+		}
+	}
+	return photos, nil
+}
+
+// deleteCard removes the photos of a deleted card and returns them. A nil
+// repository has none, so card mocks can leave their photo repository unset.
+func (m *mockCardPhotoRepository) deleteCard(cardID uint) []entity.CardPhoto {
+	if m == nil {
+		return nil
+	}
+	var photos []entity.CardPhoto
+	for id := uint(1); id < m.nextID; id++ {
+		if photo, ok := m.photos[id]; ok && photo.CardID == cardID {
+			photos = append(photos, *photo)
+			delete(m.photos, id)
+		}
+	}
+	return photos
+}
+
+func (m *mockCardPhotoRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.CardPhoto, error) {
+	var photos []entity.CardPhoto
+	for id := uint(1); id < m.nextID; id++ {
+		if photo, ok := m.photos[id]; ok && photo.UserID == userID {
+			photos = append(photos, *photo)
+		}
+	}
+	return photos, nil
+}
+
+func (m *mockCardPhotoRepository) CountByCardID(ctx context.Context, cardID uint, userID uint) (int64, error) {
+	photos, _ := m.FindByCardID(ctx, cardID, userID)
+	return int64(len(photos)), nil
+}
+
+func (m *mockCardPhotoRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, photo := range m.photos {
+		if photo.UserID == userID {
+			delete(m.photos, id)
+		}
+	}
+	return nil
+}
+
+func TestPhotoUseCase_UploadPhoto(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	photoRepo := newMockCardPhotoRepository()
+	blobStore := newMockBlobStore()
+	photoUseCase := usecase.NewPhotoUseCase(photoRepo, cardRepo, blobStore)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Black Lotus", Quantity: 1})
+
+	tests := []struct {
+		name    string
+		input   usecase.UploadPhotoInput
+		wantErr bool
+	}{
+		{"front", usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, Caption: " PSA 9 ", File: bytes.NewReader(testPNG(1200, 1680))}, false},
+		{"back", usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideBack, File: bytes.NewReader(testPNG(300, 420))}, false},
+		{"unknown side", usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: "edge", File: bytes.NewReader(testPNG(30, 42))}, true},
+		{"not an image", usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader([]byte("%PDF-1.7"))}, true},
+		{"other user's card", usecase.UploadPhotoInput{UserID: 2, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := photoUseCase.UploadPhoto(ctx, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	photos, _ := photoUseCase.CardPhotos(ctx, 1, 1)
+	if len(photos) != 2 || photos[0].Side != entity.PhotoSideFront || photos[0].Caption != "PSA 9" || photos[0].Width != 1200 {
+		t.Fatalf("Expected the front and back photos, got %+v", photos)
+	}
+	if len(blobStore.blobs) != 4 {
+		t.Errorf("Expected an original and a thumbnail per photo, got %d blobs", len(blobStore.blobs))
+	}
+
+	blob, err := photoUseCase.OpenPhoto(ctx, photos[0].ID, 1, entity.ImageSizeNormal)
+	if err != nil {
+		t.Fatalf("Expected the thumbnail, got %v", err)
+	}
+	if thumbnail, _, err := image.Decode(blob.Body); err != nil || thumbnail.Bounds().Dx() != 488 || blob.ContentType != "image/jpeg" {
+		t.Errorf("Expected a 488 pixel wide JPEG thumbnail, got %v", err)
+	}
+	original, err := photoUseCase.OpenPhoto(ctx, photos[0].ID, 1, entity.ImageSizeOriginal)
+	if err != nil || original.ContentType != "image/png" {
+		t.Fatalf("Expected the original PNG, got %v", err)
+	}
+	if data, _ := io.ReadAll(original.Body); len(data) != int(photos[0].Size) {
+		t.Errorf("Expected %d bytes, got %d", photos[0].Size, len(data))
+	}
+	if _, err := photoUseCase.OpenPhoto(ctx, photos[0].ID, 1, entity.ImageSizeSmall); err == nil {
+		t.Error("Expected sizes that are not stored to be rejected")
+	}
+	if _, err := photoUseCase.OpenPhoto(ctx, photos[0].ID, 2, entity.ImageSizeOriginal); err == nil {
+		t.Error("Expected another user's photo to be rejected")
+	}
+
+	if err := photoUseCase.DeletePhoto(ctx, photos[0].ID, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(photoRepo.photos) != 1 || len(blobStore.blobs) != 2 {
+		t.Errorf("Expected the photo and its files to be removed, got %d photos and %d blobs", len(photoRepo.photos), len(blobStore.blobs))
+	}
+}
+
+func TestPhotoUseCase_UploadPhotoLimit(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	photoRepo := newMockCardPhotoRepository()
+	photoUseCase := usecase.NewPhotoUseCase(photoRepo, cardRepo, newMockBlobStore())
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Black Lotus", Quantity: 1})
+	for i := 0; i < 20; i++ {
+		photoRepo.Create(ctx, &entity.CardPhoto{UserID: 1, CardID: 1, Side: entity.PhotoSideDetail})
+	}
+
+	_, err := photoUseCase.UploadPhoto(ctx, usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))})
+	if err == nil {
+		t.Error("Expected the 21st photo to be rejected")
+	}
+}
+
+func TestCardUseCase_DeleteRemovesPhotos(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	cardRepo.photoRepo = newMockCardPhotoRepository()
+	blobStore := newMockBlobStore()
+	photoUseCase := usecase.NewPhotoUseCase(cardRepo.photoRepo, cardRepo, blobStore)
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, blobStore)
+
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Black Lotus", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Mox Pearl", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Mox Sapphire", Quantity: 1})
+	for _, input := range []usecase.UploadPhotoInput{
+		{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))},
+		{UserID: 1, CardID: 1, Side: entity.PhotoSideBack, File: bytes.NewReader(testPNG(30, 42))},
+		{UserID: 1, CardID: 2, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))},
+		{UserID: 1, CardID: 3, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))},
+	} {
+		if _, err := photoUseCase.UploadPhoto(ctx, input); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := cardUseCase.DeleteCard(ctx, 1, 1, entity.AuditSourceWeb); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cardRepo.photoRepo.photos) != 2 || len(blobStore.blobs) != 4 {
+		t.Errorf("Expected only the Mox photos to remain, got %d photos and %d blobs", len(cardRepo.photoRepo.photos), len(blobStore.blobs))
+	}
+
+	result, err := cardUseCase.BulkEdit(ctx, usecase.BulkEditInput{UserID: 1, CardIDs: []uint{2}, Action: usecase.BulkDelete})
+	if err != nil || result.Applied != 1 {
+		t.Fatalf("Expected Mox Pearl to be deleted, got %+v (%v)", result, err)
+	}
+	if len(cardRepo.photoRepo.photos) != 1 || len(blobStore.blobs) != 2 {
+		t.Errorf("Expected only the Mox Sapphire photo to remain, got %d photos and %d blobs", len(cardRepo.photoRepo.photos), len(blobStore.blobs))
+	}
+	if photos, _ := photoUseCase.CardPhotos(ctx, 3, 1); len(photos) != 1 {
+		t.Errorf("Expected Mox Sapphire to keep its photo, got %d", len(photos))
+	}
+}
@@ -0,0 +1,218 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockSealedRepository struct {
+	products map[uint]*entity.SealedProduct
+	nextID   uint
+	cardRepo *mockCardRepository
+}
+
+func newMockSealedRepository(cardRepo *mockCardRepository) *mockSealedRepository {
+	return &mockSealedRepository{products: make(map[uint]*entity.SealedProduct), nextID: 1, cardRepo: cardRepo}
+}
+
+func (m *mockSealedRepository) Create(ctx context.Context, product *entity.SealedProduct) error {
+	product.ID = m.nextID
+	m.nextID++
+	stored := *product
+	m.products[product.ID] = &stored
+	return nil
+}
+
+func (m *mockSealedRepository) Update(ctx context.Context, product *entity.SealedProduct) error {
+	stored := *product
+	m.products[product.ID] = &stored
+	return nil
+}
+
+func (m *mockSealedRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	product, ok := m.products[id]
+	if !ok || product.UserID != userID {
+		return errors.New("record not found")
+	}
+	delete(m.products, id)
+	return nil
+}
+
+func (m *mockSealedRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.SealedProduct, error) {
+	product, ok := m.products[id]
+	if !ok || product.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *product
+	return &found, nil
+}
+
+func (m *mockSealedRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.SealedProduct, error) {
+	var products []entity.SealedProduct
+	for _, product := range m.products {
+		if product.UserID == userID {
+			products = append(products, *product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID > products[j].ID })
+	return products, nil
+}
+
+func (m *mockSealedRepository) Open(ctx context.Context, product *entity.SealedProduct, cards []entity.Card) error {
+	stored, ok := m.products[product.ID]
+	if !ok || stored.UserID != product.UserID || stored.Quantity < 1 {
+		return repository.ErrNothingToOpen
+	}
+	stored.Quantity--
+	stored.Opened++
+	for i := range cards {
+		m.cardRepo.Create(ctx, &cards[i])
+	}
+	*product = *stored
+	return nil
+}
+
+func (m *mockSealedRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, product := range m.products {
+		if product.UserID == userID {
+			delete(m.products, id)
+		}
+	}
+	return nil
+}
+
+func TestSealedUseCase_CreateProduct(t *testing.T) {
+	ctx := context.Background()
+	sealedUseCase := usecase.NewSealedUseCase(newMockSealedRepository(newMockCardRepository()), &mockAuditRepository{}, &mockCatalogRepository{})
+
+	tests := []struct {
+		name    string
+		input   usecase.SealedProductInput
+		wantErr bool
+	}{
+		{"valid", usecase.SealedProductInput{UserID: 1, Name: " MH3 Play Booster Box ", Type: entity.SealedTypeBoosterBox, Quantity: 1, UnitCost: 5400}, false},
+		{"missing name", usecase.SealedProductInput{UserID: 1, Name: " ", Type: entity.SealedTypeBoosterBox, Quantity: 1}, true},
+		{"unknown type", usecase.SealedProductInput{UserID: 1, Name: "Mystery Box", Type: "crate", Quantity: 1}, true},
+		{"zero quantity", usecase.SealedProductInput{UserID: 1, Name: "Bundle", Type: entity.SealedTypeBundle}, true},
+		{"negative cost", usecase.SealedProductInput{UserID: 1, Name: "Bundle", Type: entity.SealedTypeBundle, Quantity: 1, UnitCost: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := sealedUseCase.CreateProduct(ctx, tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got product %+v", product)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if product.ID == 0 || product.Name != "MH3 Play Booster Box" {
+				t.Errorf("Expected a stored, trimmed product, got %+v", product)
+			}
+		})
+	}
+
+	inventory, err := sealedUseCase.ListProducts(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(inventory.Products) != 1 || inventory.HeldUnits != 1 || inventory.HeldCost != 5400 {
+		t.Errorf("Expected one held unit costing 5400, got %+v", inventory)
+	}
+}
+
+func TestSealedUseCase_OpenProduct(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	auditRepo := &mockAuditRepository{}
+	catalogRepo := &mockCatalogRepository{
+		cards: []entity.CatalogCard{{SetCode: "mh3", CollectorNumber: "15", Name: "Ulamog, the Defiler", Rarity: entity.RarityMythic, TypeLine: "Legendary Creature — Eldrazi", ManaValue: 10}},
+	}
+	sealedUseCase := usecase.NewSealedUseCase(newMockSealedRepository(cardRepo), auditRepo, catalogRepo)
+
+	bought := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	pack, _ := sealedUseCase.CreateProduct(ctx, usecase.SealedProductInput{UserID: 1, Name: "MH3 Collector Booster", SetCode: "MH3", Type: entity.SealedTypeBoosterPack, Quantity: 1, UnitCost: 1000, BoughtDate: &bought})
+	sleeves, _ := sealedUseCase.CreateProduct(ctx, usecase.SealedProductInput{UserID: 1, Name: "Dragon Shield Matte", Type: entity.SealedTypeAccessory, Quantity: 1})
+
+	cards, err := sealedUseCase.OpenProduct(ctx, usecase.OpenProductInput{
+		UserID:    1,
+		ProductID: pack.ID,
+		Cards:     "2x Flare of Duplication #132\n\nUlamog, the Defiler (MH3) #15 *F*\nSnapcaster Mage (MUL)",
+		Language:  "English",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cards) != 3 || len(cardRepo.cards) != 3 {
+		t.Fatalf("Expected 3 card rows, got %d (%d stored)", len(cards), len(cardRepo.cards))
+	}
+	if cards[0].Quantity != 2 || cards[0].SetCode != "MH3" || cards[0].CollectorNumber != "132" {
+		t.Errorf("Expected 2 Flare of Duplication from the product's set, got %+v", cards[0])
+	}
+	if !cards[1].Foil || cards[2].SetCode != "MUL" {
+		t.Errorf("Expected a foil Ulamog and a Snapcaster from MUL, got %+v and %+v", cards[1], cards[2])
+	}
+	if cards[1].Rarity != entity.RarityMythic || cards[1].ManaValue != 10 || cards[0].Known() {
+		t.Errorf("Expected catalog metadata for Ulamog only, got %+v and %+v", cards[1].CardMetadata, cards[0].CardMetadata)
+	}
+	for _, card := range cards {
+		if card.BuyingPrice != 250 || card.BoughtDate == nil || !card.BoughtDate.Equal(bought) || card.UserID != 1 {
+			t.Errorf("Expected the pack's cost split over 4 copies and its bought date, got %+v", card)
+		}
+	}
+	if len(auditRepo.events) != 3 {
+		t.Errorf("Expected a create event per card, got %d", len(auditRepo.events))
+	}
+
+	opened, _ := sealedUseCase.GetProduct(ctx, pack.ID, 1)
+	if opened.Quantity != 0 || opened.Opened != 1 {
+		t.Errorf("Expected no unopened and 1 opened unit, got %d and %d", opened.Quantity, opened.Opened)
+	}
+
+	_, err = sealedUseCase.OpenProduct(ctx, usecase.OpenProductInput{UserID: 1, ProductID: pack.ID, Cards: "Opt"})
+	if !errors.Is(err, repository.ErrNothingToOpen) {
+		t.Errorf("Expected ErrNothingToOpen, got %v", err)
+	}
+	if _, err := sealedUseCase.OpenProduct(ctx, usecase.OpenProductInput{UserID: 1, ProductID: sleeves.ID, Cards: "Opt"}); err == nil {
+		t.Error("Expected accessories to be rejected")
+	}
+	if _, err := sealedUseCase.OpenProduct(ctx, usecase.OpenProductInput{UserID: 2, ProductID: pack.ID, Cards: "Opt"}); err == nil {
+		t.Error("Expected another user's product to be rejected")
+	}
+}
+
+func TestParseOpenedCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    int
+		wantErr bool
+	}{
+		{"plain names", "Opt\nPonder", 2, false},
+		{"quantities", "4 Opt\n3x Ponder", 2, false},
+		{"empty", "\n  \n", 0, true},
+		{"zero quantity", "0 Opt", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, err := usecase.ParseOpenedCards(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(cards) != tt.want {
+				t.Errorf("Expected %d cards, got %d", tt.want, len(cards))
+			}
+		})
+	}
+}
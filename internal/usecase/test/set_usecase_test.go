@@ -0,0 +1,222 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockCatalogRepository struct {
+	sets         []entity.CatalogSet
+	cards        []entity.CatalogCard
+	translations []entity.CatalogTranslation
+}
+
+func (m *mockCatalogRepository) UpsertSets(ctx context.Context, sets []entity.CatalogSet) error {
+	m.sets = append(m.sets, sets...)
+	return nil
+}
+
+func (m *mockCatalogRepository) UpsertCards(ctx context.Context, cards []entity.CatalogCard, overwrite bool) error {
+	m.cards = append(m.cards, cards...)
+	return nil
+}
+
+func (m *mockCatalogRepository) FindSets(ctx context.Context) ([]entity.CatalogSet, error) {
+	return m.sets, nil
+}
+
+func (m *mockCatalogRepository) FindSet(ctx context.Context, code string) (*entity.CatalogSet, error) {
+	for _, set := range m.sets {
+		if set.Code == code {
+			return &set, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *mockCatalogRepository) FindCardsBySet(ctx context.Context, codes []string) ([]entity.CatalogCard, error) {
+	var cards []entity.CatalogCard
+	for _, card := range m.cards {
+		for _, code := range codes {
+			if card.SetCode == code {
+				cards = append(cards, card)
+			}
+		}
+	}
+	return cards, nil
+}
+
+func (m *mockCatalogRepository) FindPrintings(ctx context.Context, cards []entity.Card) ([]entity.CatalogCard, error) {
+	var printings []entity.CatalogCard
+	for _, printing := range m.cards {
+		for _, card := range cards {
+			if strings.EqualFold(card.SetCode, printing.SetCode) && card.CollectorNumber == printing.CollectorNumber {
+				printings = append(printings, printing)
+				break
+			}
+		}
+	}
+	return printings, nil
+}
+
+func (m *mockCatalogRepository) ApplyMetadata(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockCatalogRepository) UpsertTranslations(ctx context.Context, translations []entity.CatalogTranslation) error {
+	m.translations = append(m.translations, translations...)
+	return nil
+}
+
+func (m *mockCatalogRepository) FindTranslations(ctx context.Context, cards []entity.Card) ([]entity.CatalogTranslation, error) {
+	var translations []entity.CatalogTranslation
+	for _, translation := range m.translations {
+		for _, card := range cards {
+			if strings.EqualFold(card.SetCode, translation.SetCode) && card.CollectorNumber == translation.CollectorNumber && card.Language == translation.Lang {
+				translations = append(translations, translation)
+				break
+			}
+		}
+	}
+	return translations, nil
+}
+
+func (m *mockCardRepository) OwnedPrintings(ctx context.Context, userID uint) ([]repository.OwnedPrinting, error) {
+	var owned []repository.OwnedPrinting
+	for _, card := range m.cards {
+		if card.UserID != userID || card.SellDate != nil || card.SetCode == "" {
+			continue
+		}
+		owned = append(owned, repository.OwnedPrinting{
+			SetCode:         card.SetCode,
+			CollectorNumber: card.CollectorNumber,
+			Foil:            card.Foil,
+			Quantity:        card.Quantity,
+		})
+	}
+	return owned, nil
+}
+
+// newSetFixture builds a four-card catalog set where the user owns a nonfoil
+// #1, a foil #2, a copy of #3 that was sold, and a #99 the catalog does not
+// list, plus a card from a set that is not in the catalog at all.
+func newSetFixture() *usecase.SetUseCase {
+	ctx := context.Background()
+	released := time.Date(2021, 6, 18, 0, 0, 0, 0, time.UTC)
+	catalogRepo := &mockCatalogRepository{
+		sets: []entity.CatalogSet{{Code: "mh2", Name: "Modern Horizons 2", ReleasedAt: &released}},
+		cards: []entity.CatalogCard{
+			{SetCode: "mh2", CollectorNumber: "10", Name: "Solitude", Rarity: entity.RarityMythic, Nonfoil: true, Foil: true},
+			{SetCode: "mh2", CollectorNumber: "1", Name: "Arcbound Javelineer", Rarity: entity.RarityUncommon, Nonfoil: true, Foil: true},
+			{SetCode: "mh2", CollectorNumber: "2", Name: "Arcbound Mouser", Rarity: entity.RarityCommon, Nonfoil: true, Foil: true},
+			{SetCode: "mh2", CollectorNumber: "3", Name: "Barbed Spike", Rarity: entity.RarityUncommon, Foil: true},
+		},
+	}
+
+	cardRepo := newMockCardRepository()
+	sold := time.Now()
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Arcbound Javelineer", SetCode: "MH2", CollectorNumber: "1", Quantity: 2})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Arcbound Mouser", SetCode: "mh2", CollectorNumber: "2", Quantity: 1, Foil: true})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Barbed Spike", SetCode: "mh2", CollectorNumber: "3", Quantity: 1, Foil: true, SellDate: &sold})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Typo", SetCode: "mh2", CollectorNumber: "99", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Custom Proxy", SetCode: "PRX", CollectorNumber: "1", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 2, CardName: "Solitude", SetCode: "mh2", CollectorNumber: "10", Quantity: 1})
+
+	return usecase.NewSetUseCase(catalogRepo, cardRepo)
+}
+
+func TestSetUseCase_Overview(t *testing.T) {
+	setUseCase := newSetFixture()
+
+	overview, err := setUseCase.Overview(context.Background(), 1, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(overview) != 2 {
+		t.Fatalf("Expected 2 sets, got %d", len(overview))
+	}
+
+	mh2 := overview[0]
+	if mh2.Code != "mh2" || mh2.Set == nil || mh2.Owned != 2 || mh2.Total != 4 || mh2.Percent() != 50 {
+		t.Errorf("Expected mh2 at 2 of 4, got %+v", mh2)
+	}
+	if overview[1].Code != "prx" || overview[1].Set != nil || overview[1].Name() != "PRX" {
+		t.Errorf("Expected uncatalogued set last, got %+v", overview[1])
+	}
+
+	// Master set: the nonfoil Javelineer and foil Mouser out of 2 + 2 + 1 + 2
+	// printed finishes
+	master, _ := setUseCase.Overview(context.Background(), 1, true)
+	if master[0].Owned != 2 || master[0].Total != 7 {
+		t.Errorf("Expected master set at 2 of 7, got %d of %d", master[0].Owned, master[0].Total)
+	}
+}
+
+func TestSetUseCase_Detail(t *testing.T) {
+	setUseCase := newSetFixture()
+
+	detail, err := setUseCase.Detail(context.Background(), 1, "MH2", true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var numbers []string
+	for _, status := range detail.Cards {
+		numbers = append(numbers, status.Card.CollectorNumber)
+	}
+	if len(numbers) != 4 || numbers[0] != "1" || numbers[1] != "2" || numbers[2] != "3" || numbers[3] != "10" {
+		t.Errorf("Expected collector number order 1, 2, 3, 10, got %v", numbers)
+	}
+
+	javelineer, mouser, spike := detail.Cards[0], detail.Cards[1], detail.Cards[2]
+	if javelineer.Nonfoil != 2 || javelineer.MissingNonfoil || !javelineer.MissingFoil || !javelineer.Missing {
+		t.Errorf("Expected Javelineer to miss only the foil, got %+v", javelineer)
+	}
+	if mouser.Foil != 1 || !mouser.MissingNonfoil || mouser.MissingFoil {
+		t.Errorf("Expected Mouser to miss only the nonfoil, got %+v", mouser)
+	}
+	if spike.MissingNonfoil || !spike.MissingFoil {
+		t.Errorf("Expected foil-only Spike to miss its foil despite the sold copy, got %+v", spike)
+	}
+
+	if len(detail.Unknown) != 1 || detail.Unknown[0] != "99" {
+		t.Errorf("Expected #99 to be reported as unknown, got %v", detail.Unknown)
+	}
+
+	uncommons := detail.Rarities[1]
+	if uncommons.Rarity != entity.RarityUncommon || uncommons.Owned != 1 || uncommons.Total != 3 {
+		t.Errorf("Expected 1 of 3 uncommon finishes, got %+v", uncommons)
+	}
+
+	if _, err := setUseCase.Detail(context.Background(), 1, "prx", false); err == nil {
+		t.Error("Expected error for a set that is not in the catalog, got nil")
+	}
+}
+
+func TestSetUseCase_Translations(t *testing.T) {
+	catalogRepo := &mockCatalogRepository{translations: []entity.CatalogTranslation{
+		{SetCode: "mh2", CollectorNumber: "32", Lang: "ja", Name: "孤独"},
+		{SetCode: "mh2", CollectorNumber: "32", Lang: "de", Name: "Einsamkeit"},
+	}}
+	setUseCase := usecase.NewSetUseCase(catalogRepo, newMockCardRepository())
+
+	translations, err := setUseCase.Translations(context.Background(), []entity.Card{
+		{ID: 1, SetCode: "MH2", CollectorNumber: "32", Language: "ja"},
+		{ID: 2, SetCode: "mh2", CollectorNumber: "32", Language: "en"},
+		{ID: 3, SetCode: "mh2", CollectorNumber: "32", Language: "de"},
+		{ID: 4, SetCode: "mh2", CollectorNumber: "33", Language: "ja"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(translations) != 2 || translations[1].Name != "孤独" || translations[3].Name != "Einsamkeit" {
+		t.Errorf("Expected the Japanese and German names for cards 1 and 3, got %+v", translations)
+	}
+}
@@ -0,0 +1,84 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+func TestStatsUseCase_Stats(t *testing.T) {
+	ctx := context.Background()
+	catalogRepo := &mockCatalogRepository{
+		sets: []entity.CatalogSet{{Code: "mh2", Name: "Modern Horizons 2"}},
+	}
+	solitude := entity.CardMetadata{Colors: "W", ColorIdentity: "W", TypeLine: "Creature — Elemental Incarnation", ManaValue: 5, Rarity: entity.RarityMythic}
+	dakkon := entity.CardMetadata{Colors: "WUB", ColorIdentity: "WUB", TypeLine: "Legendary Planeswalker — Dakkon", ManaValue: 6, Rarity: entity.RarityMythic}
+	solRing := entity.CardMetadata{TypeLine: "Artifact", ManaValue: 1, Rarity: entity.RarityUncommon}
+	cardRepo := newMockCardRepository()
+	sealedRepo := newMockSealedRepository(cardRepo)
+	statsUseCase := usecase.NewStatsUseCase(cardRepo, catalogRepo, sealedRepo)
+
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Solitude", SetCode: "MH2", CollectorNumber: "32", Language: "English", Quantity: 2, BuyingPrice: 1500, BoughtDate: &jan, CardMetadata: solitude})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "solitude", SetCode: "mh2", CollectorNumber: "32", Language: "Japanese", Quantity: 1, BuyingPrice: 1800, BoughtDate: &feb, CardMetadata: solitude})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Dakkon, Shadow Slayer", SetCode: "mh2", CollectorNumber: "192", Language: "English", Quantity: 1, BuyingPrice: 100, BoughtDate: &jan, SellDate: &feb, CardMetadata: dakkon})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Sol Ring", SetCode: "mh2", CollectorNumber: "227", Language: "English", Quantity: 4, BuyingPrice: 50, CardMetadata: solRing})
+	cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Homebrew", Quantity: 1})
+	cardRepo.Create(ctx, &entity.Card{UserID: 2, CardName: "Black Lotus", Quantity: 1, BuyingPrice: 1000000})
+	sealedRepo.Create(ctx, &entity.SealedProduct{UserID: 1, Name: "MH2 Draft Booster Box", Type: entity.SealedTypeBoosterBox, Quantity: 2, UnitCost: 6000, BoughtDate: &feb})
+	sealedRepo.Create(ctx, &entity.SealedProduct{UserID: 1, Name: "MH2 Collector Booster", Type: entity.SealedTypeBoosterPack, Quantity: 1, UnitCost: 900, SellDate: &feb})
+
+	stats, err := statsUseCase.Stats(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stats.TotalCopies != 9 || stats.HeldCopies != 8 || stats.SoldCopies != 1 {
+		t.Errorf("Expected 9 copies (8 held, 1 sold), got %d (%d held, %d sold)", stats.TotalCopies, stats.HeldCopies, stats.SoldCopies)
+	}
+	if stats.UniqueCards != 3 {
+		t.Errorf("Expected 3 unique held cards, got %d", stats.UniqueCards)
+	}
+	if stats.TotalSpend != 18000 || stats.HeldCost != 5000 || stats.UndatedSpend != 1100 {
+		t.Errorf("Expected spend 18000 (held 5000, undated 1100), got %.2f (%.2f, %.2f)", stats.TotalSpend, stats.HeldCost, stats.UndatedSpend)
+	}
+	if stats.SealedUnits != 2 || stats.SealedCost != 12000 || stats.HeldValue != 17000 {
+		t.Errorf("Expected 2 sealed units costing 12000 and held value 17000, got %d, %.2f, %.2f", stats.SealedUnits, stats.SealedCost, stats.HeldValue)
+	}
+
+	if len(stats.SpendByMonth) != 2 || stats.SpendByMonth[0].Month != "2024-01" || stats.SpendByMonth[0].Amount != 3100 || stats.SpendByMonth[1].Amount != 13800 || stats.SpendByMonth[1].Copies != 1 {
+		t.Errorf("Expected January 3100 and February 13800 over 1 copy, got %+v", stats.SpendByMonth)
+	}
+
+	if len(stats.BySet) != 2 || stats.BySet[0].Label != "Modern Horizons 2" || stats.BySet[0].Copies != 7 || stats.BySet[1].Label != "No set" {
+		t.Errorf("Expected Modern Horizons 2 then no set, got %+v", stats.BySet)
+	}
+	if stats.BySet[0].Share != 87.5 {
+		t.Errorf("Expected 87.5%% share, got %v", stats.BySet[0].Share)
+	}
+
+	var colors []string
+	for _, bucket := range stats.ByColor {
+		colors = append(colors, bucket.Label)
+	}
+	if len(colors) != 3 || colors[0] != "White" || colors[1] != "Colorless" || colors[2] != "Unknown" {
+		t.Errorf("Expected White, Colorless, Unknown, got %v", colors)
+	}
+	if stats.ByRarity[0].Label != "Uncommon" || stats.ByRarity[1].Label != "Mythic" || stats.ByRarity[1].Copies != 3 {
+		t.Errorf("Expected Uncommon then Mythic with 3 copies, got %+v", stats.ByRarity)
+	}
+	if len(stats.ByType) != 3 || stats.ByType[0].Label != "Creature" || stats.ByType[1].Label != "Artifact" || stats.ByType[2].Label != "Unknown" {
+		t.Errorf("Expected Creature, Artifact, Unknown, got %+v", stats.ByType)
+	}
+	if len(stats.ByManaValue) != 3 || stats.ByManaValue[0].Key != "1" || stats.ByManaValue[0].Copies != 4 || stats.ByManaValue[1].Key != "5" {
+		t.Errorf("Expected mana values 1 and 5 then unknown, got %+v", stats.ByManaValue)
+	}
+
+	if len(stats.TopHoldings) != 3 || stats.TopHoldings[0].UnitPrice != 1800 || stats.TopHoldings[2].CardName != "Sol Ring" {
+		t.Errorf("Expected held cards with a price by unit price, got %+v", stats.TopHoldings)
+	}
+}
@@ -0,0 +1,400 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/cardquery"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockTagRepository struct {
+	tags     map[uint]*entity.Tag
+	cardTags []entity.CardTag
+	nextID   uint
+}
+
+func newMockTagRepository() *mockTagRepository {
+	return &mockTagRepository{tags: make(map[uint]*entity.Tag), nextID: 1}
+}
+
+func (m *mockTagRepository) Create(ctx context.Context, tag *entity.Tag) error {
+	tag.ID = m.nextID
+	m.nextID++
+	stored := *tag
+	m.tags[tag.ID] = &stored
+	return nil
+}
+
+func (m *mockTagRepository) Update(ctx context.Context, tag *entity.Tag) error {
+	stored := *tag
+	m.tags[tag.ID] = &stored
+	return nil
+}
+
+func (m *mockTagRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	tag, ok := m.tags[id]
+	if !ok || tag.UserID != userID {
+		return errors.New("record not found")
+	}
+	delete(m.tags, id)
+	var kept []entity.CardTag
+	for _, cardTag := range m.cardTags {
+		if cardTag.TagID != id {
+			kept = append(kept, cardTag)
+		}
+	}
+	m.cardTags = kept
+	return nil
+}
+
+func (m *mockTagRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.Tag, error) {
+	tag, ok := m.tags[id]
+	if !ok || tag.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *tag
+	return &found, nil
+}
+
+func (m *mockTagRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	for _, tag := range m.tags {
+		if tag.UserID == userID {
+			tags = append(tags, *tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (m *mockTagRepository) FindCardTags(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CardTag, error) {
+	var cardTags []entity.CardTag
+	for _, cardTag := range m.cardTags {
+		tag, ok := m.tags[cardTag.TagID]
+		if ok && tag.UserID == userID && (cardIDs == nil || containsID(cardIDs, &cardTag.CardID)) {
+			cardTags = append(cardTags, cardTag)
+		}
+	}
+	return cardTags, nil
+}
+
+func (m *mockTagRepository) SetCardTags(ctx context.Context, cardID uint, tagIDs []uint) error {
+	var kept []entity.CardTag
+	for _, cardTag := range m.cardTags {
+		if cardTag.CardID != cardID {
+			kept = append(kept, cardTag)
+		}
+	}
+	for _, tagID := range tagIDs {
+		kept = append(kept, entity.CardTag{CardID: cardID, TagID: tagID})
+	}
+	m.cardTags = kept
+	return nil
+}
+
+func (m *mockTagRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, tag := range m.tags {
+		if tag.UserID == userID {
+			m.Delete(ctx, id, userID)
+		}
+	}
+	return nil
+}
+
+type mockCustomFieldRepository struct {
+	fields map[uint]*entity.CustomField
+	values []entity.CustomFieldValue
+	nextID uint
+}
+
+func newMockCustomFieldRepository() *mockCustomFieldRepository {
+	return &mockCustomFieldRepository{fields: make(map[uint]*entity.CustomField), nextID: 1}
+}
+
+func (m *mockCustomFieldRepository) Create(ctx context.Context, field *entity.CustomField) error {
+	field.ID = m.nextID
+	m.nextID++
+	stored := *field
+	m.fields[field.ID] = &stored
+	return nil
+}
+
+func (m *mockCustomFieldRepository) Update(ctx context.Context, field *entity.CustomField) error {
+	stored := *field
+	m.fields[field.ID] = &stored
+	return nil
+}
+
+func (m *mockCustomFieldRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	field, ok := m.fields[id]
+	if !ok || field.UserID != userID {
+		return errors.New("record not found")
+	}
+	delete(m.fields, id)
+	var kept []entity.CustomFieldValue
+	for _, value := range m.values {
+		if value.FieldID != id {
+			kept = append(kept, value)
+		}
+	}
+	m.values = kept
+	return nil
+}
+
+func (m *mockCustomFieldRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.CustomField, error) {
+	field, ok := m.fields[id]
+	if !ok || field.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *field
+	return &found, nil
+}
+
+func (m *mockCustomFieldRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.CustomField, error) {
+	var fields []entity.CustomField
+	for _, field := range m.fields {
+		if field.UserID == userID {
+			fields = append(fields, *field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+func (m *mockCustomFieldRepository) FindValues(ctx context.Context, userID uint, cardIDs []uint) ([]entity.CustomFieldValue, error) {
+	var values []entity.CustomFieldValue
+	for _, value := range m.values {
+		field, ok := m.fields[value.FieldID]
+		if ok && field.UserID == userID && (cardIDs == nil || containsID(cardIDs, &value.CardID)) {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func (m *mockCustomFieldRepository) SetCardValues(ctx context.Context, cardID uint, values []entity.CustomFieldValue) error {
+	var kept []entity.CustomFieldValue
+	for _, value := range m.values {
+		if value.CardID != cardID {
+			kept = append(kept, value)
+		}
+	}
+	for _, value := range values {
+		value.CardID = cardID
+		kept = append(kept, value)
+	}
+	m.values = kept
+	return nil
+}
+
+func (m *mockCustomFieldRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, field := range m.fields {
+		if field.UserID == userID {
+			m.Delete(ctx, id, userID)
+		}
+	}
+	return nil
+}
+
+type tagFixture struct {
+	cardRepo   *mockCardRepository
+	tagRepo    *mockTagRepository
+	fieldRepo  *mockCustomFieldRepository
+	tagUseCase *usecase.TagUseCase
+}
+
+func newTagFixture(t *testing.T) *tagFixture {
+	f := &tagFixture{
+		cardRepo:  newMockCardRepository(),
+		tagRepo:   newMockTagRepository(),
+		fieldRepo: newMockCustomFieldRepository(),
+	}
+	f.tagUseCase = usecase.NewTagUseCase(f.tagRepo, f.fieldRepo, f.cardRepo)
+
+	cardUseCase := usecase.NewCardUseCase(f.cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+	cardUseCase.CreateCard(context.Background(), usecase.CreateCardInput{UserID: 1, CardName: "Sol Ring", Quantity: 1})
+	cardUseCase.CreateCard(context.Background(), usecase.CreateCardInput{UserID: 2, CardName: "Opt", Quantity: 1})
+	return f
+}
+
+func TestTagUseCase_CreateTag(t *testing.T) {
+	f := newTagFixture(t)
+	ctx := context.Background()
+
+	tag, err := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 1, Name: " proxy ", Color: "#FFCC00"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tag.Name != "proxy" || tag.Color != "#ffcc00" {
+		t.Errorf("Expected trimmed name and lowercase color, got %q %q", tag.Name, tag.Color)
+	}
+	if tag.TextColor() != "#000" {
+		t.Errorf("Expected dark text on a light tag, got %s", tag.TextColor())
+	}
+
+	plain, err := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 1, Name: "for cube"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if plain.Color != entity.DefaultTagColor {
+		t.Errorf("Expected default color, got %s", plain.Color)
+	}
+
+	// Another user may use the same name
+	if _, err := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 2, Name: "Proxy"}); err != nil {
+		t.Errorf("Expected no error for another user, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input usecase.TagInput
+	}{
+		{"missing name", usecase.TagInput{UserID: 1, Name: "  "}},
+		{"duplicate name", usecase.TagInput{UserID: 1, Name: "PROXY"}},
+		{"invalid color", usecase.TagInput{UserID: 1, Name: "lent", Color: "red"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.tagUseCase.CreateTag(ctx, tt.input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	// Renaming a tag to its own name is fine
+	if err := f.tagUseCase.UpdateTag(ctx, tag.ID, usecase.TagInput{UserID: 1, Name: "Proxy", Color: "#000000"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestTagUseCase_SetCardTags(t *testing.T) {
+	f := newTagFixture(t)
+	ctx := context.Background()
+
+	proxy, _ := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 1, Name: "proxy"})
+	cube, _ := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 1, Name: "cube"})
+	foreign, _ := f.tagUseCase.CreateTag(ctx, usecase.TagInput{UserID: 2, Name: "mine"})
+	grade, _ := f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "grade", Kind: entity.CustomFieldNumber})
+	condition, _ := f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "condition", Kind: entity.CustomFieldEnum, Options: "NM\n LP \n\nMP"})
+	graded, _ := f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "graded_on", Kind: entity.CustomFieldDate})
+
+	if got := condition.Choices(); len(got) != 3 || got[1] != "LP" {
+		t.Errorf("Expected choices NM, LP, MP, got %v", got)
+	}
+
+	err := f.tagUseCase.SetCardTags(ctx, usecase.SetCardTagsInput{
+		UserID: 1,
+		CardID: 1,
+		TagIDs: []uint{proxy.ID, cube.ID, proxy.ID},
+		Fields: map[uint]string{grade.ID: "9.50", condition.ID: "lp", graded.ID: ""},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tags, _ := f.tagUseCase.CardTags(ctx, 1, []uint{1})
+	if len(tags[1]) != 2 || tags[1][0].Name != "cube" || tags[1][1].Name != "proxy" {
+		t.Errorf("Expected tags cube and proxy, got %+v", tags[1])
+	}
+	values, _ := f.tagUseCase.CardFieldValues(ctx, 1, 1)
+	if len(values) != 2 || values[grade.ID] != "9.5" || values[condition.ID] != "LP" {
+		t.Errorf("Expected grade 9.5 and condition LP, got %v", values)
+	}
+
+	tests := []struct {
+		name  string
+		input usecase.SetCardTagsInput
+	}{
+		{"other user's card", usecase.SetCardTagsInput{UserID: 1, CardID: 2}},
+		{"other user's tag", usecase.SetCardTagsInput{UserID: 1, CardID: 1, TagIDs: []uint{foreign.ID}}},
+		{"unknown field", usecase.SetCardTagsInput{UserID: 1, CardID: 1, Fields: map[uint]string{99: "x"}}},
+		{"not a number", usecase.SetCardTagsInput{UserID: 1, CardID: 1, Fields: map[uint]string{grade.ID: "mint"}}},
+		{"not a date", usecase.SetCardTagsInput{UserID: 1, CardID: 1, Fields: map[uint]string{graded.ID: "01/02/2024"}}},
+		{"not a choice", usecase.SetCardTagsInput{UserID: 1, CardID: 1, Fields: map[uint]string{condition.ID: "HP"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.tagUseCase.SetCardTags(ctx, tt.input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	// Failed updates leave the card as it was
+	if values, _ := f.tagUseCase.CardFieldValues(ctx, 1, 1); len(values) != 2 {
+		t.Errorf("Expected values to be kept, got %v", values)
+	}
+
+	// Deleting a tag takes it off the card
+	if err := f.tagUseCase.DeleteTag(ctx, cube.ID, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tags, _ := f.tagUseCase.CardTags(ctx, 1, []uint{1}); len(tags[1]) != 1 {
+		t.Errorf("Expected one tag left, got %+v", tags[1])
+	}
+}
+
+func TestTagUseCase_CreateField(t *testing.T) {
+	f := newTagFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "signed_by", Kind: entity.CustomFieldText, Options: "ignored"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if field, _ := f.fieldRepo.FindByID(ctx, 1, 1); field.Options != "" {
+		t.Errorf("Expected options to be dropped for a text field, got %q", field.Options)
+	}
+
+	tests := []struct {
+		name  string
+		input usecase.CustomFieldInput
+	}{
+		{"missing name", usecase.CustomFieldInput{UserID: 1, Kind: entity.CustomFieldText}},
+		{"name with spaces", usecase.CustomFieldInput{UserID: 1, Name: "signed by", Kind: entity.CustomFieldText}},
+		{"duplicate name", usecase.CustomFieldInput{UserID: 1, Name: "Signed_By", Kind: entity.CustomFieldText}},
+		{"unknown kind", usecase.CustomFieldInput{UserID: 1, Name: "grade", Kind: "color"}},
+		{"enum without choices", usecase.CustomFieldInput{UserID: 1, Name: "condition", Kind: entity.CustomFieldEnum, Options: " \n "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.tagUseCase.CreateField(ctx, tt.input); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTagUseCase_ResolveSearch(t *testing.T) {
+	f := newTagFixture(t)
+	ctx := context.Background()
+
+	grade, _ := f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "Grade", Kind: entity.CustomFieldNumber})
+	f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 1, Name: "signed", Kind: entity.CustomFieldText})
+	f.tagUseCase.CreateField(ctx, usecase.CustomFieldInput{UserID: 2, Name: "theirs", Kind: entity.CustomFieldText})
+
+	query, err := cardquery.Parse("tag:proxy cf.grade>=9")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := f.tagUseCase.ResolveSearch(ctx, 1, query); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	term := cardquery.Terms(query)[1]
+	if term.FieldID != grade.ID || term.Kind != string(entity.CustomFieldNumber) || term.Number != 9 {
+		t.Errorf("Expected the grade field resolved with number 9, got %+v", term)
+	}
+
+	for _, search := range []string{"cf.grade>high", "cf.signed>a", "cf.theirs:x", "cf.missing:x"} {
+		query, err := cardquery.Parse(search)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", search, err)
+		}
+		if err := f.tagUseCase.ResolveSearch(ctx, 1, query); err == nil {
+			t.Errorf("ResolveSearch(%q): expected error, got nil", search)
+		}
+	}
+}
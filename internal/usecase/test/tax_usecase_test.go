@@ -0,0 +1,103 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+func TestTaxUseCase_Report(t *testing.T) {
+	f := newLotFixture(t, entity.CostMethodFIFO)
+	taxUseCase := usecase.NewTaxUseCase(f.lotRepo, f.cardRepo, f.userRepo)
+	ctx := context.Background()
+
+	// Three copies sold in February 2025: two from the January 2024 lot, held
+	// more than a year, and one from the June 2024 lot.
+	_, err := f.lotUseCase.RecordSale(ctx, usecase.RecordSaleInput{
+		UserID: 1, CardID: 1, Quantity: 3, UnitPrice: 30,
+		SoldAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A card marked sold by hand has no proceeds to report.
+	soldByHand := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	f.cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Opt", Quantity: 1, SellDate: &soldByHand})
+
+	years, err := taxUseCase.TaxYears(ctx, 1)
+	if err != nil || len(years) != 1 || years[0] != 2025 {
+		t.Fatalf("Expected only 2025, got %v (%v)", years, err)
+	}
+
+	report, err := taxUseCase.Report(ctx, 1, 2025)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Currency != "THB" {
+		t.Errorf("Expected the default currency, got %q", report.Currency)
+	}
+	if len(report.Disposals) != 2 {
+		t.Fatalf("Expected one disposal per lot, got %+v", report.Disposals)
+	}
+
+	long, short := report.Disposals[0], report.Disposals[1]
+	if long.Quantity != 2 || long.Term() != "long" || long.HoldingDays() != 388 || long.CostBasis != 20 || long.Proceeds != 60 {
+		t.Errorf("Expected 2 long-term copies at a cost of 20, got %+v", long)
+	}
+	if short.Quantity != 1 || short.Term() != "short" || short.CostBasis != 20 || short.Gain != 10 {
+		t.Errorf("Expected 1 short-term copy with a gain of 10, got %+v", short)
+	}
+	if report.Proceeds != 90 || report.CostBasis != 40 || report.Gain != 50 || report.LongTermGain != 40 || report.ShortTermGain != 10 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Unrecorded) != 1 || report.Unrecorded[0].CardName != "Opt" {
+		t.Errorf("Expected the hand-marked sale listed as unrecorded, got %+v", report.Unrecorded)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("Expected no error writing CSV, got %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	if len(records) != 4 || records[0][12] != "Gain (THB)" || records[1][6] != "2024-01-10" || records[3][12] != "50.00" {
+		t.Errorf("Unexpected CSV: %v", records)
+	}
+
+	empty, err := taxUseCase.Report(ctx, 1, 2024)
+	if err != nil || len(empty.Disposals) != 0 || empty.Gain != 0 {
+		t.Errorf("Expected an empty 2024 report, got %+v (%v)", empty, err)
+	}
+}
+
+func TestTaxDisposal_TermAcrossLeapYear(t *testing.T) {
+	bought := time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)
+
+	// 2024 has 366 days, so one year after purchase is day 366
+	tests := []struct {
+		soldAt time.Time
+		want   string
+	}{
+		{time.Date(2025, 2, 27, 0, 0, 0, 0, time.UTC), "short"},
+		{time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), "short"},
+		{time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "long"},
+	}
+	for _, tt := range tests {
+		disposal := usecase.TaxDisposal{AcquiredAt: &bought, SoldAt: tt.soldAt}
+		if got := disposal.Term(); got != tt.want {
+			t.Errorf("Sold %s: expected %q, got %q", tt.soldAt.Format("2006-01-02"), tt.want, got)
+		}
+	}
+
+	if got := (usecase.TaxDisposal{SoldAt: bought}).Term(); got != "unknown" {
+		t.Errorf("Expected unknown term without an acquisition date, got %q", got)
+	}
+}
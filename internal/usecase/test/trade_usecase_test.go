@@ -0,0 +1,384 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+// mockTradeRepository performs transfers against a mock card repository so
+// accepted trades can be checked end to end. Copies on outstanding loans of
+// loanRepo, if set, cannot be transferred, and photos of photoRepo, if set,
+// move with used-up rows.
+type mockTradeRepository struct {
+	proposals map[uint]*entity.TradeProposal
+	nextID    uint
+	cardRepo  *mockCardRepository
+	loanRepo  *mockLoanRepository
+	photoRepo *mockCardPhotoRepository
+}
+
+func newMockTradeRepository(cardRepo *mockCardRepository) *mockTradeRepository {
+	return &mockTradeRepository{
+		proposals: make(map[uint]*entity.TradeProposal),
+		nextID:    1,
+		cardRepo:  cardRepo,
+	}
+}
+
+func (m *mockTradeRepository) Create(ctx context.Context, proposal *entity.TradeProposal) error {
+	if proposal.ParentID != nil {
+		if err := m.Resolve(ctx, *proposal.ParentID, entity.TradeStatusCountered, time.Now()); err != nil {
+			return err
+		}
+	}
+	proposal.ID = m.nextID
+	m.nextID++
+	stored := *proposal
+	m.proposals[proposal.ID] = &stored
+	return nil
+}
+
+func (m *mockTradeRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.TradeProposal, error) {
+	proposal, ok := m.proposals[id]
+	if !ok || (proposal.ProposerID != userID && proposal.RecipientID != userID) {
+		return nil, errors.New("record not found")
+	}
+	found := *proposal
+	return &found, nil
+}
+
+func (m *mockTradeRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.TradeProposal, error) {
+	var proposals []entity.TradeProposal
+	for id := m.nextID - 1; id >= 1; id-- {
+		if proposal, ok := m.proposals[id]; ok && (proposal.ProposerID == userID || proposal.RecipientID == userID) {
+			proposals = append(proposals, *proposal)
+		}
+	}
+	return proposals, nil
+}
+
+func (m *mockTradeRepository) Resolve(ctx context.Context, id uint, status entity.TradeStatus, at time.Time) error {
+	proposal, ok := m.proposals[id]
+	if !ok || !proposal.IsOpen() {
+		return repository.ErrProposalNotPending
+	}
+	proposal.Status = status
+	proposal.ResolvedAt = &at
+	return nil
+}
+
+func (m *mockTradeRepository) Accept(ctx context.Context, id uint, transfers []repository.CardTransfer, at time.Time) ([]repository.CardTransferResult, error) {
+	proposal, ok := m.proposals[id]
+	if !ok || !proposal.IsOpen() {
+		return nil, repository.ErrProposalNotPending
+	}
+	for _, transfer := range transfers {
+		card, ok := m.cardRepo.cards[transfer.Line.CardID]
+		if !ok || card.ForTrade < transfer.Line.Quantity || card.Quantity-m.loanRepo.lent(card.ID) < transfer.Line.Quantity {
+			return nil, repository.ErrTradeUnavailable
+		}
+	}
+
+	var results []repository.CardTransferResult
+	for _, transfer := range transfers {
+		source := m.cardRepo.cards[transfer.Line.CardID]
+		result := repository.CardTransferResult{Before: *source}
+		source.Quantity -= transfer.Line.Quantity
+		source.ForTrade -= transfer.Line.Quantity
+		result.After = *source
+		if source.Quantity == 0 {
+			delete(m.cardRepo.cards, source.ID)
+		}
+
+		created := &entity.Card{
+			UserID:          transfer.ToUserID,
+			CardName:        transfer.Line.CardName,
+			SetCode:         transfer.Line.SetCode,
+			CollectorNumber: transfer.Line.CollectorNumber,
+			Quantity:        transfer.Line.Quantity,
+			BuyingPrice:     transfer.Line.UnitValue,
+			BoughtDate:      &at,
+		}
+		m.cardRepo.Create(ctx, created)
+		result.Created = *created
+		if source.Quantity == 0 && m.photoRepo != nil {
+			result.Photos, _ = m.photoRepo.FindByCardID(ctx, source.ID, source.UserID)
+			for _, photo := range result.Photos {
+				m.photoRepo.photos[photo.ID].CardID = created.ID
+				m.photoRepo.photos[photo.ID].UserID = created.UserID
+			}
+		}
+		results = append(results, result)
+	}
+
+	proposal.Status = entity.TradeStatusAccepted
+	proposal.ResolvedAt = &at
+	return results, nil
+}
+
+func (m *mockTradeRepository) DetachUser(ctx context.Context, userID uint, at time.Time) error {
+	for _, proposal := range m.proposals {
+		if proposal.ProposerID != userID && proposal.RecipientID != userID {
+			continue
+		}
+		if proposal.IsOpen() {
+			proposal.Status = entity.TradeStatusCancelled
+			proposal.ResolvedAt = &at
+		}
+		if proposal.ProposerID == userID {
+			proposal.Message = ""
+		}
+	}
+	return nil
+}
+
+type tradeFixture struct {
+	cardRepo     *mockCardRepository
+	auditRepo    *mockAuditRepository
+	tradeRepo    *mockTradeRepository
+	loanRepo     *mockLoanRepository
+	photoRepo    *mockCardPhotoRepository
+	blobStore    *mockBlobStore
+	tradeUseCase *usecase.TradeUseCase
+}
+
+// newTradeFixture sets up alice (1), who trades away a Ragavan and wants a
+// Force of Will, and bob (2), who trades away two Force of Will and wants a
+// Ragavan.
+func newTradeFixture(t *testing.T) *tradeFixture {
+	ctx := context.Background()
+	userRepo := newMockUserRepository()
+	userRepo.Create(ctx, &entity.User{ID: 1, Username: "alice"})
+	userRepo.Create(ctx, &entity.User{ID: 2, Username: "bob"})
+
+	f := &tradeFixture{
+		cardRepo:  newMockCardRepository(),
+		auditRepo: &mockAuditRepository{},
+		loanRepo:  newMockLoanRepository(),
+		photoRepo: newMockCardPhotoRepository(),
+		blobStore: newMockBlobStore(),
+	}
+	f.tradeRepo = newMockTradeRepository(f.cardRepo)
+	f.tradeRepo.loanRepo = f.loanRepo
+	f.tradeRepo.photoRepo = f.photoRepo
+	wishlistRepo := newMockWishlistRepository()
+	f.tradeUseCase = usecase.NewTradeUseCase(f.tradeRepo, f.cardRepo, wishlistRepo, userRepo, f.auditRepo, f.loanRepo, f.blobStore)
+
+	f.cardRepo.Create(ctx, &entity.Card{UserID: 1, CardName: "Ragavan, Nimble Pilferer", SetCode: "MH2", Quantity: 1, ForTrade: 1, BuyingPrice: 2000})
+	f.cardRepo.Create(ctx, &entity.Card{UserID: 2, CardName: "Force of Will", SetCode: "2XM", Quantity: 3, ForTrade: 2, BuyingPrice: 1500})
+
+	wishlistRepo.Create(ctx, &entity.WishlistItem{UserID: 1, CardName: "Force of Will", Quantity: 4, Priority: entity.WishlistPriorityHigh})
+	wishlistRepo.Create(ctx, &entity.WishlistItem{UserID: 2, CardName: "Ragavan, Nimble Pilferer", Quantity: 1, Priority: entity.WishlistPriorityNormal})
+
+	return f
+}
+
+func TestTradeUseCase_Match(t *testing.T) {
+	f := newTradeFixture(t)
+
+	match, err := f.tradeUseCase.Match(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(match.Give) != 1 || match.Give[0].Card.CardName != "Ragavan, Nimble Pilferer" {
+		t.Fatalf("Expected Ragavan on the give side, got %+v", match.Give)
+	}
+	if len(match.Receive) != 1 || match.Receive[0].Quantity != 2 {
+		t.Fatalf("Expected both Force of Will copies for trade on the receive side, got %+v", match.Receive)
+	}
+
+	// 2000 given against 1500 received; adding a second Force of Will would
+	// overshoot but the give side has nothing left, so the suggestion stops
+	// once receive is the higher side.
+	if match.Give[0].Suggested != 1 || match.Receive[0].Suggested != 2 {
+		t.Errorf("Expected suggestion of 1 Ragavan for 2 Force of Will, got %d for %d", match.Give[0].Suggested, match.Receive[0].Suggested)
+	}
+	if match.GiveValue != 2000 || match.ReceiveValue != 3000 || match.Balance() != 1000 {
+		t.Errorf("Expected values 2000/3000, got %.2f/%.2f", match.GiveValue, match.ReceiveValue)
+	}
+
+	if _, err := f.tradeUseCase.Match(context.Background(), 1, 1); err == nil {
+		t.Error("Expected error when matching with yourself, got nil")
+	}
+}
+
+func TestTradeUseCase_ProposeValidation(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2}); err == nil {
+		t.Error("Expected error for empty proposal, got nil")
+	}
+	if _, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2, Lines: []usecase.TradeLineInput{{CardID: 2, Quantity: 3}}}); err == nil {
+		t.Error("Expected error for more copies than are for trade, got nil")
+	}
+	if _, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2, Lines: []usecase.TradeLineInput{{CardID: 99, Quantity: 1}}}); err == nil {
+		t.Error("Expected error for unknown card, got nil")
+	}
+
+	// Duplicate rows for the same card are merged
+	proposal, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{
+		ProposerID:  1,
+		RecipientID: 2,
+		Lines:       []usecase.TradeLineInput{{CardID: 1, Quantity: 1}, {CardID: 2, Quantity: 1}, {CardID: 2, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(proposal.Lines) != 2 || proposal.Lines[1].Quantity != 2 || proposal.Lines[1].FromUserID != 2 {
+		t.Errorf("Expected 2 lines with 2 Force of Will from bob, got %+v", proposal.Lines)
+	}
+}
+
+func TestTradeUseCase_Accept(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+
+	photoUseCase := usecase.NewPhotoUseCase(f.photoRepo, f.cardRepo, f.blobStore)
+	photo, err := photoUseCase.UploadPhoto(ctx, usecase.UploadPhotoInput{UserID: 1, CardID: 1, Side: entity.PhotoSideFront, File: bytes.NewReader(testPNG(30, 42))})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	proposal, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{
+		ProposerID:  1,
+		RecipientID: 2,
+		Lines:       []usecase.TradeLineInput{{CardID: 1, Quantity: 1}, {CardID: 2, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := f.tradeUseCase.Accept(ctx, proposal.ID, 1); err == nil {
+		t.Error("Expected proposer not to be able to accept, got nil")
+	}
+	if err := f.tradeUseCase.Accept(ctx, proposal.ID, 2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := f.cardRepo.cards[1]; ok {
+		t.Error("Expected alice's only Ragavan to be gone")
+	}
+	if bob := f.cardRepo.cards[2]; bob.Quantity != 1 || bob.ForTrade != 0 {
+		t.Errorf("Expected bob to keep 1 Force of Will not for trade, got %d (%d for trade)", bob.Quantity, bob.ForTrade)
+	}
+
+	alice, _ := f.cardRepo.FindAllByUserID(ctx, 1)
+	if len(alice) != 1 || alice[0].CardName != "Force of Will" || alice[0].Quantity != 2 {
+		t.Errorf("Expected alice to receive 2 Force of Will, got %+v", alice)
+	}
+
+	// The photo of the used-up Ragavan goes to bob's new row with its files
+	moved, err := photoUseCase.GetPhoto(ctx, photo.ID, 2)
+	if err != nil || f.cardRepo.cards[moved.CardID].CardName != "Ragavan, Nimble Pilferer" {
+		t.Fatalf("Expected the photo on bob's Ragavan, got %+v (%v)", moved, err)
+	}
+	if _, err := photoUseCase.OpenPhoto(ctx, photo.ID, 2, entity.ImageSizeOriginal); err != nil {
+		t.Errorf("Expected the photo file to have moved, got %v", err)
+	}
+	if len(f.blobStore.blobs) != 2 {
+		t.Errorf("Expected only the moved files to remain, got %d blobs", len(f.blobStore.blobs))
+	}
+
+	// delete + create for Ragavan, update + create for Force of Will
+	if len(f.auditRepo.events) != 4 {
+		t.Errorf("Expected 4 audit events, got %d", len(f.auditRepo.events))
+	}
+	for _, event := range f.auditRepo.events {
+		if event.Source != entity.AuditSourceTrade || event.ActorID != 2 {
+			t.Errorf("Expected trade events by bob, got %+v", event)
+		}
+	}
+
+	if err := f.tradeUseCase.Accept(ctx, proposal.ID, 2); !errors.Is(err, repository.ErrProposalNotPending) {
+		t.Errorf("Expected ErrProposalNotPending, got %v", err)
+	}
+}
+
+func TestTradeUseCase_LentCopies(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+
+	// Bob lends out two of his three Force of Will, leaving one to trade
+	f.loanRepo.Create(ctx, &entity.Loan{UserID: 2, CardID: 2, CardName: "Force of Will", Borrower: "Sam", Quantity: 2})
+
+	match, err := f.tradeUseCase.Match(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(match.Receive) != 1 || match.Receive[0].Quantity != 1 {
+		t.Fatalf("Expected one Force of Will for trade, got %+v", match.Receive)
+	}
+
+	if _, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2, Lines: []usecase.TradeLineInput{{CardID: 2, Quantity: 2}}}); err == nil {
+		t.Error("Expected error for lent copies, got nil")
+	}
+
+	proposal, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 2, RecipientID: 1, Lines: []usecase.TradeLineInput{{CardID: 2, Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Lending the last free copy before the proposal is accepted blocks it
+	f.loanRepo.Create(ctx, &entity.Loan{UserID: 2, CardID: 2, CardName: "Force of Will", Borrower: "Kim", Quantity: 1})
+	if err := f.tradeUseCase.Accept(ctx, proposal.ID, 1); !errors.Is(err, repository.ErrTradeUnavailable) {
+		t.Errorf("Expected ErrTradeUnavailable, got %v", err)
+	}
+	if bob := f.cardRepo.cards[2]; bob.Quantity != 3 {
+		t.Errorf("Expected bob to keep 3 Force of Will, got %d", bob.Quantity)
+	}
+}
+
+func TestTradeUseCase_CounterDeclineCancel(t *testing.T) {
+	f := newTradeFixture(t)
+	ctx := context.Background()
+	lines := []usecase.TradeLineInput{{CardID: 1, Quantity: 1}, {CardID: 2, Quantity: 1}}
+
+	first, _ := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2, Lines: lines})
+
+	if err := f.tradeUseCase.Decline(ctx, first.ID, 1); err == nil {
+		t.Error("Expected proposer not to be able to decline, got nil")
+	}
+	if err := f.tradeUseCase.Cancel(ctx, first.ID, 2); err == nil {
+		t.Error("Expected recipient not to be able to cancel, got nil")
+	}
+
+	// Only the recipient can counter
+	if _, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{ProposerID: 1, RecipientID: 2, Lines: lines, CounterOf: &first.ID}); err == nil {
+		t.Error("Expected proposer not to be able to counter, got nil")
+	}
+
+	counter, err := f.tradeUseCase.Propose(ctx, usecase.ProposeTradeInput{
+		ProposerID:  2,
+		RecipientID: 1,
+		Lines:       []usecase.TradeLineInput{{CardID: 1, Quantity: 1}},
+		CounterOf:   &first.ID,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if f.tradeRepo.proposals[first.ID].Status != entity.TradeStatusCountered {
+		t.Errorf("Expected original to be countered, got %s", f.tradeRepo.proposals[first.ID].Status)
+	}
+	if err := f.tradeUseCase.Accept(ctx, first.ID, 2); !errors.Is(err, repository.ErrProposalNotPending) {
+		t.Errorf("Expected countered proposal not to be acceptable, got %v", err)
+	}
+
+	if err := f.tradeUseCase.Decline(ctx, counter.ID, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if f.tradeRepo.proposals[counter.ID].Status != entity.TradeStatusDeclined {
+		t.Errorf("Expected counter to be declined, got %s", f.tradeRepo.proposals[counter.ID].Status)
+	}
+	if err := f.tradeUseCase.Cancel(ctx, counter.ID, 2); err == nil {
+		t.Error("Expected resolved proposal not to be cancellable, got nil")
+	}
+}
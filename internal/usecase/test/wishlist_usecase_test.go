@@ -0,0 +1,140 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/usecase"
+)
+
+type mockWishlistRepository struct {
+	items  map[uint]*entity.WishlistItem
+	nextID uint
+}
+
+func newMockWishlistRepository() *mockWishlistRepository {
+	return &mockWishlistRepository{
+		items:  make(map[uint]*entity.WishlistItem),
+		nextID: 1,
+	}
+}
+
+func (m *mockWishlistRepository) Create(ctx context.Context, item *entity.WishlistItem) error {
+	item.ID = m.nextID
+	m.nextID++
+	stored := *item
+	m.items[item.ID] = &stored
+	return nil
+}
+
+func (m *mockWishlistRepository) Update(ctx context.Context, item *entity.WishlistItem) error {
+	stored := *item
+	m.items[item.ID] = &stored
+	return nil
+}
+
+func (m *mockWishlistRepository) Delete(ctx context.Context, id uint, userID uint) error {
+	delete(m.items, id)
+	return nil
+}
+
+func (m *mockWishlistRepository) FindByID(ctx context.Context, id uint, userID uint) (*entity.WishlistItem, error) {
+	item, ok := m.items[id]
+	if !ok || item.UserID != userID {
+		return nil, errors.New("record not found")
+	}
+	found := *item
+	return &found, nil
+}
+
+func (m *mockWishlistRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.WishlistItem, error) {
+	var items []entity.WishlistItem
+	for id := uint(1); id < m.nextID; id++ {
+		if item, ok := m.items[id]; ok && item.UserID == userID {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
+func (m *mockWishlistRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	for id, item := range m.items {
+		if item.UserID == userID {
+			delete(m.items, id)
+		}
+	}
+	return nil
+}
+
+func TestWishlistUseCase_Ownership(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	cardUseCase := usecase.NewCardUseCase(cardRepo, &mockAuditRepository{}, &mockCatalogRepository{}, newMockBlobStore())
+	wishlistUseCase := usecase.NewWishlistUseCase(newMockWishlistRepository(), cardRepo, cardUseCase)
+
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Thoughtseize", SetCode: "THS", Quantity: 1})
+	cardUseCase.CreateCard(ctx, usecase.CreateCardInput{UserID: 1, CardName: "Thoughtseize", SetCode: "2XM", Quantity: 1})
+
+	// Any printing counts
+	wishlistUseCase.AddItem(ctx, usecase.WishlistItemInput{UserID: 1, CardName: "thoughtseize", Quantity: 4, Priority: entity.WishlistPriorityHigh})
+	// Only the THS printing counts
+	wishlistUseCase.AddItem(ctx, usecase.WishlistItemInput{UserID: 1, CardName: "Thoughtseize", SetCode: "ths", Quantity: 2, Priority: entity.WishlistPriorityNormal})
+
+	open, _, err := wishlistUseCase.ListItems(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(open) != 2 {
+		t.Fatalf("Expected 2 open items, got %d", len(open))
+	}
+	if open[0].Owned != 2 || open[0].Remaining != 2 || !open[0].PartiallyOwned() {
+		t.Errorf("Expected any-printing item to be partially owned (2 of 4), got %+v", open[0])
+	}
+	if open[1].Owned != 1 || open[1].Remaining != 1 {
+		t.Errorf("Expected printing item to be 1 of 2 owned, got %+v", open[1])
+	}
+
+	if err := wishlistUseCase.AddItem(ctx, usecase.WishlistItemInput{UserID: 1, CardName: "Opt", Quantity: 1}); err == nil {
+		t.Error("Expected error for missing priority, got nil")
+	}
+}
+
+func TestWishlistUseCase_Acquire(t *testing.T) {
+	ctx := context.Background()
+	cardRepo := newMockCardRepository()
+	auditRepo := &mockAuditRepository{}
+	cardUseCase := usecase.NewCardUseCase(cardRepo, auditRepo, &mockCatalogRepository{}, newMockBlobStore())
+	wishlistRepo := newMockWishlistRepository()
+	wishlistUseCase := usecase.NewWishlistUseCase(wishlistRepo, cardRepo, cardUseCase)
+
+	wishlistUseCase.AddItem(ctx, usecase.WishlistItemInput{UserID: 1, CardName: "Ragavan, Nimble Pilferer", SetCode: "MH2", CollectorNumber: "138", Quantity: 2, MaxPrice: 2500, Priority: entity.WishlistPriorityHigh})
+
+	err := wishlistUseCase.Acquire(ctx, usecase.AcquireWishlistItemInput{ItemID: 1, UserID: 1, Quantity: 1, Price: 2300})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	card := cardRepo.cards[1]
+	if card == nil || card.CardName != "Ragavan, Nimble Pilferer" || card.SetCode != "MH2" || card.CollectorNumber != "138" || card.BuyingPrice != 2300 {
+		t.Fatalf("Expected purchased printing in collection, got %+v", card)
+	}
+	if len(auditRepo.events) != 1 || auditRepo.events[0].Action != entity.AuditActionCreate {
+		t.Errorf("Expected the purchase to be audited as a card creation, got %+v", auditRepo.events)
+	}
+	if item := wishlistRepo.items[1]; item.Acquired != 1 || item.FulfilledAt != nil {
+		t.Errorf("Expected item to stay open after 1 of 2, got %+v", item)
+	}
+
+	wishlistUseCase.Acquire(ctx, usecase.AcquireWishlistItemInput{ItemID: 1, UserID: 1, Quantity: 1, Price: 2400})
+	open, fulfilled, _ := wishlistUseCase.ListItems(ctx, 1)
+	if len(open) != 0 || len(fulfilled) != 1 {
+		t.Errorf("Expected item to be fulfilled, got %d open and %d fulfilled", len(open), len(fulfilled))
+	}
+
+	// Another user cannot acquire the item
+	if err := wishlistUseCase.Acquire(ctx, usecase.AcquireWishlistItemInput{ItemID: 1, UserID: 2, Quantity: 1}); err == nil {
+		t.Error("Expected error acquiring another user's item, got nil")
+	}
+}
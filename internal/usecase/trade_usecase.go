@@ -0,0 +1,510 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type TradeUseCase struct {
+	tradeRepo    repository.TradeRepository
+	cardRepo     repository.CardRepository
+	wishlistRepo repository.WishlistRepository
+	userRepo     repository.UserRepository
+	auditRepo    repository.AuditRepository
+	loanRepo     repository.LoanRepository
+	blobStore    repository.BlobStore
+}
+
+func NewTradeUseCase(tradeRepo repository.TradeRepository, cardRepo repository.CardRepository, wishlistRepo repository.WishlistRepository, userRepo repository.UserRepository, auditRepo repository.AuditRepository, loanRepo repository.LoanRepository, blobStore repository.BlobStore) *TradeUseCase {
+	return &TradeUseCase{
+		tradeRepo:    tradeRepo,
+		cardRepo:     cardRepo,
+		wishlistRepo: wishlistRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		loanRepo:     loanRepo,
+		blobStore:    blobStore,
+	}
+}
+
+// tradeableCopies is how many copies of a card can change hands: those
+// offered for trade, as long as enough copies are not lent out.
+func tradeableCopies(card *entity.Card, lent int) int {
+	return max(min(card.ForTrade, card.Quantity-lent), 0)
+}
+
+// TradeCandidate is a card row one user has for trade that matches an open
+// wishlist entry of the other user.
+type TradeCandidate struct {
+	Card     entity.Card
+	Want     entity.WishlistItem
+	Quantity int
+	// Suggested is the quantity picked for a value-balanced proposal.
+	Suggested int
+}
+
+func (c TradeCandidate) UnitValue() float64 {
+	return c.Card.BuyingPrice
+}
+
+// TradeMatch lists what a user can give to a partner and receive from them.
+// Values are based on the stored buying prices of the giving side's cards.
+type TradeMatch struct {
+	Partner      *entity.User
+	Give         []TradeCandidate
+	Receive      []TradeCandidate
+	GiveValue    float64
+	ReceiveValue float64
+}
+
+// Balance is the suggested receive value minus the suggested give value.
+func (m *TradeMatch) Balance() float64 {
+	return m.ReceiveValue - m.GiveValue
+}
+
+type TradeLineInput struct {
+	CardID   uint
+	Quantity int
+}
+
+type ProposeTradeInput struct {
+	ProposerID  uint
+	RecipientID uint
+	Lines       []TradeLineInput
+	Message     string
+	// CounterOf is set when answering a proposal with a counter-offer.
+	CounterOf *uint
+}
+
+// TradeProposalView is a proposal as seen by one of its parties.
+type TradeProposalView struct {
+	Proposal     *entity.TradeProposal
+	Incoming     bool
+	Partner      entity.User
+	Give         []entity.TradeLine
+	Receive      []entity.TradeLine
+	GiveValue    float64
+	ReceiveValue float64
+}
+
+func newTradeProposalView(proposal *entity.TradeProposal, userID uint) TradeProposalView {
+	view := TradeProposalView{
+		Proposal: proposal,
+		Incoming: proposal.RecipientID == userID,
+		Partner:  proposal.Recipient,
+	}
+	if view.Incoming {
+		view.Partner = proposal.Proposer
+	}
+
+	for _, line := range proposal.Lines {
+		if line.FromUserID == userID {
+			view.Give = append(view.Give, line)
+			view.GiveValue += line.Value()
+		} else {
+			view.Receive = append(view.Receive, line)
+			view.ReceiveValue += line.Value()
+		}
+	}
+	return view
+}
+
+// Partners returns every other user a trade can be proposed to.
+func (uc *TradeUseCase) Partners(ctx context.Context, userID uint) ([]entity.User, error) {
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var partners []entity.User
+	for _, user := range users {
+		if user.ID != userID && user.DeleteAfter == nil {
+			partners = append(partners, user)
+		}
+	}
+	return partners, nil
+}
+
+// Match finds what the user has for trade that the partner wants and the
+// other way round, and suggests quantities that keep both sides' values
+// close to each other.
+func (uc *TradeUseCase) Match(ctx context.Context, userID uint, partnerID uint) (*TradeMatch, error) {
+	if userID == partnerID {
+		return nil, errors.New("cannot trade with yourself")
+	}
+
+	partner, err := uc.userRepo.FindByID(ctx, partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	give, err := uc.candidates(ctx, userID, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	receive, err := uc.candidates(ctx, partnerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &TradeMatch{Partner: partner, Give: give, Receive: receive}
+	match.GiveValue, match.ReceiveValue = suggestBalanced(match.Give, match.Receive)
+	return match, nil
+}
+
+// candidates pairs the giver's cards for trade with the receiver's open
+// wishlist entries, highest priority first. Copies the receiver already owns
+// are not wanted again, and each card copy is offered only once.
+func (uc *TradeUseCase) candidates(ctx context.Context, giverID uint, receiverID uint) ([]TradeCandidate, error) {
+	cards, err := uc.cardRepo.FindAllByUserID(ctx, giverID)
+	if err != nil {
+		return nil, err
+	}
+
+	lent, err := lentQuantities(ctx, uc.loanRepo, giverID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[uint]int)
+	var tradeable []entity.Card
+	for i := range cards {
+		card := &cards[i]
+		if copies := tradeableCopies(card, lent[card.ID]); copies > 0 && card.SellDate == nil {
+			tradeable = append(tradeable, *card)
+			available[card.ID] = copies
+		}
+	}
+	if len(tradeable) == 0 {
+		return nil, nil
+	}
+
+	items, err := uc.wishlistRepo.FindByUserID(ctx, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	var open []entity.WishlistItem
+	for _, item := range items {
+		if item.FulfilledAt == nil {
+			open = append(open, item)
+		}
+	}
+	wants, err := wishlistOwnership(ctx, uc.cardRepo, receiverID, open)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(wants, func(i, j int) bool {
+		return wants[i].Item.Priority > wants[j].Item.Priority
+	})
+
+	var candidates []TradeCandidate
+	for _, want := range wants {
+		remaining := want.Remaining
+		for _, card := range tradeable {
+			if remaining == 0 {
+				break
+			}
+			owned := repository.OwnedQuantity{CardName: card.CardName, SetCode: card.SetCode, CollectorNumber: card.CollectorNumber}
+			if available[card.ID] == 0 || !satisfiesWishlistItem(want.Item, owned) {
+				continue
+			}
+
+			quantity := available[card.ID]
+			if quantity > remaining {
+				quantity = remaining
+			}
+			available[card.ID] -= quantity
+			remaining -= quantity
+
+			candidates = append(candidates, TradeCandidate{Card: card, Want: want.Item, Quantity: quantity})
+		}
+	}
+	return candidates, nil
+}
+
+// suggestBalanced picks copies one at a time, always adding to whichever
+// side is currently worth less, and stops once that side has nothing left
+// to add. Candidates are taken in order, so higher priority wants go first.
+func suggestBalanced(give, receive []TradeCandidate) (giveValue, receiveValue float64) {
+	next := func(candidates []TradeCandidate) *TradeCandidate {
+		for i := range candidates {
+			if candidates[i].Suggested < candidates[i].Quantity {
+				return &candidates[i]
+			}
+		}
+		return nil
+	}
+
+	for {
+		side, value := give, &giveValue
+		if receiveValue < giveValue || (receiveValue == giveValue && next(give) == nil) {
+			side, value = receive, &receiveValue
+		}
+
+		candidate := next(side)
+		if candidate == nil {
+			return giveValue, receiveValue
+		}
+		candidate.Suggested++
+		*value += candidate.UnitValue()
+	}
+}
+
+// CounterMatch prepares a counter-offer to a pending proposal the user
+// received: the match with the proposer, with quantities taken from the
+// original proposal instead of the balanced suggestion.
+func (uc *TradeUseCase) CounterMatch(ctx context.Context, proposalID uint, userID uint) (*TradeMatch, error) {
+	proposal, err := uc.tradeRepo.FindByID(ctx, proposalID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.RecipientID != userID {
+		return nil, errors.New("only the recipient can counter a proposal")
+	}
+	if !proposal.IsOpen() {
+		return nil, repository.ErrProposalNotPending
+	}
+
+	match, err := uc.Match(ctx, userID, proposal.ProposerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range match.Give {
+		match.Give[i].Suggested = 0
+	}
+	for i := range match.Receive {
+		match.Receive[i].Suggested = 0
+	}
+	match.GiveValue, match.ReceiveValue = 0, 0
+
+	for _, line := range proposal.Lines {
+		side := &match.Receive
+		value := &match.ReceiveValue
+		if line.FromUserID == userID {
+			side = &match.Give
+			value = &match.GiveValue
+		}
+		*value += line.Value()
+
+		remaining := line.Quantity
+		for i := range *side {
+			candidate := &(*side)[i]
+			if candidate.Card.ID != line.CardID || remaining == 0 {
+				continue
+			}
+			take := candidate.Quantity - candidate.Suggested
+			if take > remaining {
+				take = remaining
+			}
+			candidate.Suggested += take
+			remaining -= take
+		}
+
+		// Keep offered cards the other side does not have on its wishlist
+		if remaining > 0 {
+			*side = append(*side, TradeCandidate{
+				Card: entity.Card{
+					ID:              line.CardID,
+					UserID:          line.FromUserID,
+					CardName:        line.CardName,
+					SetCode:         line.SetCode,
+					CollectorNumber: line.CollectorNumber,
+					BuyingPrice:     line.UnitValue,
+				},
+				Quantity:  remaining,
+				Suggested: remaining,
+			})
+		}
+	}
+
+	return match, nil
+}
+
+func (uc *TradeUseCase) Propose(ctx context.Context, input ProposeTradeInput) (*entity.TradeProposal, error) {
+	if input.ProposerID == input.RecipientID {
+		return nil, errors.New("cannot trade with yourself")
+	}
+	if _, err := uc.userRepo.FindByID(ctx, input.RecipientID); err != nil {
+		return nil, err
+	}
+
+	if input.CounterOf != nil {
+		parent, err := uc.tradeRepo.FindByID(ctx, *input.CounterOf, input.ProposerID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.RecipientID != input.ProposerID || parent.ProposerID != input.RecipientID {
+			return nil, errors.New("only the recipient can counter a proposal")
+		}
+		if !parent.IsOpen() {
+			return nil, repository.ErrProposalNotPending
+		}
+	}
+
+	proposal := &entity.TradeProposal{
+		ProposerID:  input.ProposerID,
+		RecipientID: input.RecipientID,
+		ParentID:    input.CounterOf,
+		Status:      entity.TradeStatusPending,
+		Message:     input.Message,
+	}
+
+	// The same card row may be listed once per matching want
+	quantities := make(map[uint]int)
+	var cardIDs []uint
+	for _, lineInput := range input.Lines {
+		if lineInput.Quantity < 0 {
+			return nil, errors.New("quantity cannot be negative")
+		}
+		if lineInput.Quantity == 0 {
+			continue
+		}
+		if _, ok := quantities[lineInput.CardID]; !ok {
+			cardIDs = append(cardIDs, lineInput.CardID)
+		}
+		quantities[lineInput.CardID] += lineInput.Quantity
+	}
+
+	for _, cardID := range cardIDs {
+		quantity := quantities[cardID]
+
+		card, err := uc.cardRepo.FindByID(ctx, cardID, input.ProposerID)
+		if err != nil {
+			card, err = uc.cardRepo.FindByID(ctx, cardID, input.RecipientID)
+		}
+		if err != nil {
+			return nil, errors.New("card is not owned by either party")
+		}
+		lent, err := lentQuantities(ctx, uc.loanRepo, card.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if available := tradeableCopies(card, lent[card.ID]); quantity > available {
+			return nil, fmt.Errorf("only %d copies of %s are available for trade", available, card.CardName)
+		}
+
+		proposal.Lines = append(proposal.Lines, entity.TradeLine{
+			FromUserID:      card.UserID,
+			CardID:          card.ID,
+			CardName:        card.CardName,
+			SetCode:         card.SetCode,
+			CollectorNumber: card.CollectorNumber,
+			Quantity:        quantity,
+			UnitValue:       card.BuyingPrice,
+		})
+	}
+	if len(proposal.Lines) == 0 {
+		return nil, errors.New("a proposal needs at least one card")
+	}
+
+	if err := uc.tradeRepo.Create(ctx, proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func (uc *TradeUseCase) ListProposals(ctx context.Context, userID uint) ([]TradeProposalView, error) {
+	proposals, err := uc.tradeRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]TradeProposalView, 0, len(proposals))
+	for i := range proposals {
+		views = append(views, newTradeProposalView(&proposals[i], userID))
+	}
+	return views, nil
+}
+
+func (uc *TradeUseCase) GetProposal(ctx context.Context, id uint, userID uint) (*TradeProposalView, error) {
+	proposal, err := uc.tradeRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := newTradeProposalView(proposal, userID)
+	return &view, nil
+}
+
+// Accept carries out a pending proposal addressed to the user. All card
+// movements happen in one transaction; if any card is no longer available,
+// including copies lent out since the proposal was made, the proposal stays
+// pending and nothing moves.
+func (uc *TradeUseCase) Accept(ctx context.Context, id uint, userID uint) error {
+	proposal, err := uc.tradeRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if proposal.RecipientID != userID {
+		return errors.New("only the recipient can accept a proposal")
+	}
+	if !proposal.IsOpen() {
+		return repository.ErrProposalNotPending
+	}
+
+	transfers := make([]repository.CardTransfer, 0, len(proposal.Lines))
+	for _, line := range proposal.Lines {
+		to := proposal.RecipientID
+		if line.FromUserID == proposal.RecipientID {
+			to = proposal.ProposerID
+		}
+		transfers = append(transfers, repository.CardTransfer{Line: line, ToUserID: to})
+	}
+
+	results, err := uc.tradeRepo.Accept(ctx, id, transfers, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		before, after, created := result.Before, result.After, result.Created
+		if after.Quantity == 0 {
+			recordCardEvent(ctx, uc.auditRepo, entity.AuditActionDelete, entity.AuditSourceTrade, userID, &before, nil)
+		} else {
+			recordCardEvent(ctx, uc.auditRepo, entity.AuditActionUpdate, entity.AuditSourceTrade, userID, &before, &after)
+		}
+		recordCardEvent(ctx, uc.auditRepo, entity.AuditActionCreate, entity.AuditSourceTrade, userID, nil, &created)
+	}
+
+	// Photos of used-up rows now belong to the receiver; their files follow
+	// once the trade is committed.
+	for _, result := range results {
+		if err := movePhotoFiles(ctx, uc.blobStore, result.Photos, result.Created.UserID); err != nil {
+			return fmt.Errorf("trade accepted, but moving card photos failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decline rejects a pending proposal addressed to the user.
+func (uc *TradeUseCase) Decline(ctx context.Context, id uint, userID uint) error {
+	return uc.resolve(ctx, id, userID, entity.TradeStatusDeclined)
+}
+
+// Cancel withdraws a pending proposal the user sent.
+func (uc *TradeUseCase) Cancel(ctx context.Context, id uint, userID uint) error {
+	return uc.resolve(ctx, id, userID, entity.TradeStatusCancelled)
+}
+
+func (uc *TradeUseCase) resolve(ctx context.Context, id uint, userID uint, status entity.TradeStatus) error {
+	proposal, err := uc.tradeRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if status == entity.TradeStatusCancelled && proposal.ProposerID != userID {
+		return errors.New("only the proposer can cancel a proposal")
+	}
+	if status == entity.TradeStatusDeclined && proposal.RecipientID != userID {
+		return errors.New("only the recipient can decline a proposal")
+	}
+
+	return uc.tradeRepo.Resolve(ctx, id, status, time.Now())
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/enter42/mtg-collection-tracker/internal/domain/entity"
+	"github.com/enter42/mtg-collection-tracker/internal/domain/repository"
+)
+
+type WishlistUseCase struct {
+	wishlistRepo repository.WishlistRepository
+	cardRepo     repository.CardRepository
+	cardUseCase  *CardUseCase
+}
+
+func NewWishlistUseCase(wishlistRepo repository.WishlistRepository, cardRepo repository.CardRepository, cardUseCase *CardUseCase) *WishlistUseCase {
+	return &WishlistUseCase{wishlistRepo: wishlistRepo, cardRepo: cardRepo, cardUseCase: cardUseCase}
+}
+
+type WishlistItemInput struct {
+	ID              uint
+	UserID          uint
+	CardName        string
+	SetCode         string
+	CollectorNumber string
+	Quantity        int
+	MaxPrice        float64
+	Priority        entity.WishlistPriority
+	Notes           string
+}
+
+// AcquireWishlistItemInput describes a purchase of a wishlist item. Printing
+// fields left empty are taken from the wishlist item.
+type AcquireWishlistItemInput struct {
+	ItemID          uint
+	UserID          uint
+	Quantity        int
+	Price           float64
+	BoughtDate      *time.Time
+	SetCode         string
+	CollectorNumber string
+	Language        string
+	CardImageURL    string
+}
+
+// WishlistItemStatus is a wishlist item together with how many matching
+// copies are already in the collection.
+type WishlistItemStatus struct {
+	Item entity.WishlistItem
+	// Owned counts unsold copies in the collection that satisfy the item:
+	// the same printing if one is set, otherwise any printing of the card.
+	Owned     int
+	Remaining int
+}
+
+func (s WishlistItemStatus) PartiallyOwned() bool {
+	return s.Owned > 0 && s.Remaining > 0
+}
+
+func validateWishlistInput(input WishlistItemInput) error {
+	if strings.TrimSpace(input.CardName) == "" {
+		return errors.New("card name is required")
+	}
+	if input.Quantity < 1 {
+		return errors.New("quantity must be at least 1")
+	}
+	if input.MaxPrice < 0 {
+		return errors.New("max price cannot be negative")
+	}
+	if input.Priority < entity.WishlistPriorityLow || input.Priority > entity.WishlistPriorityHigh {
+		return errors.New("unknown priority")
+	}
+	return nil
+}
+
+func (uc *WishlistUseCase) AddItem(ctx context.Context, input WishlistItemInput) error {
+	if err := validateWishlistInput(input); err != nil {
+		return err
+	}
+
+	item := &entity.WishlistItem{
+		UserID:          input.UserID,
+		CardName:        strings.TrimSpace(input.CardName),
+		SetCode:         input.SetCode,
+		CollectorNumber: input.CollectorNumber,
+		Quantity:        input.Quantity,
+		MaxPrice:        input.MaxPrice,
+		Priority:        input.Priority,
+		Notes:           input.Notes,
+	}
+
+	return uc.wishlistRepo.Create(ctx, item)
+}
+
+func (uc *WishlistUseCase) UpdateItem(ctx context.Context, input WishlistItemInput) error {
+	if err := validateWishlistInput(input); err != nil {
+		return err
+	}
+
+	item, err := uc.wishlistRepo.FindByID(ctx, input.ID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	item.CardName = strings.TrimSpace(input.CardName)
+	item.SetCode = input.SetCode
+	item.CollectorNumber = input.CollectorNumber
+	item.Quantity = input.Quantity
+	item.MaxPrice = input.MaxPrice
+	item.Priority = input.Priority
+	item.Notes = input.Notes
+	updateFulfilled(item, time.Now())
+
+	return uc.wishlistRepo.Update(ctx, item)
+}
+
+func (uc *WishlistUseCase) DeleteItem(ctx context.Context, id uint, userID uint) error {
+	return uc.wishlistRepo.Delete(ctx, id, userID)
+}
+
+func (uc *WishlistUseCase) GetItem(ctx context.Context, id uint, userID uint) (*WishlistItemStatus, error) {
+	item, err := uc.wishlistRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := wishlistOwnership(ctx, uc.cardRepo, userID, []entity.WishlistItem{*item})
+	if err != nil {
+		return nil, err
+	}
+	return &statuses[0], nil
+}
+
+// ListItems returns the wishlist split into open and fulfilled items, each
+// with the number of matching copies already owned.
+func (uc *WishlistUseCase) ListItems(ctx context.Context, userID uint) (open []WishlistItemStatus, fulfilled []WishlistItemStatus, err error) {
+	items, err := uc.wishlistRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statuses, err := wishlistOwnership(ctx, uc.cardRepo, userID, items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, status := range statuses {
+		if status.Item.FulfilledAt != nil {
+			fulfilled = append(fulfilled, status)
+		} else {
+			open = append(open, status)
+		}
+	}
+	return open, fulfilled, nil
+}
+
+// wishlistOwnership counts the copies a user already owns of each item.
+func wishlistOwnership(ctx context.Context, cardRepo repository.CardRepository, userID uint, items []entity.WishlistItem) ([]WishlistItemStatus, error) {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.CardName)
+	}
+
+	owned, err := cardRepo.OwnedQuantities(ctx, userID, names)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]WishlistItemStatus, 0, len(items))
+	for _, item := range items {
+		status := WishlistItemStatus{Item: item}
+		for _, o := range owned {
+			if satisfiesWishlistItem(item, o) {
+				status.Owned += o.Quantity
+			}
+		}
+		if status.Owned < item.Quantity {
+			status.Remaining = item.Quantity - status.Owned
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func satisfiesWishlistItem(item entity.WishlistItem, owned repository.OwnedQuantity) bool {
+	if !strings.EqualFold(item.CardName, owned.CardName) {
+		return false
+	}
+	if item.SetCode != "" && !strings.EqualFold(item.SetCode, owned.SetCode) {
+		return false
+	}
+	if item.CollectorNumber != "" && item.CollectorNumber != owned.CollectorNumber {
+		return false
+	}
+	return true
+}
+
+// Acquire records the purchase of a wishlist item: the copies are added to
+// the collection through CardUseCase.CreateCard and counted against the item,
+// which is marked fulfilled once enough copies have been bought.
+func (uc *WishlistUseCase) Acquire(ctx context.Context, input AcquireWishlistItemInput) error {
+	if input.Quantity < 1 {
+		return errors.New("quantity must be at least 1")
+	}
+	if input.Price < 0 {
+		return errors.New("price cannot be negative")
+	}
+
+	item, err := uc.wishlistRepo.FindByID(ctx, input.ItemID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	setCode := input.SetCode
+	collectorNumber := input.CollectorNumber
+	if setCode == "" {
+		setCode = item.SetCode
+		collectorNumber = item.CollectorNumber
+	}
+
+	err = uc.cardUseCase.CreateCard(ctx, CreateCardInput{
+		UserID:          input.UserID,
+		CardName:        item.CardName,
+		CardImageURL:    input.CardImageURL,
+		SetCode:         setCode,
+		CollectorNumber: collectorNumber,
+		Language:        input.Language,
+		Quantity:        input.Quantity,
+		BuyingPrice:     input.Price,
+		BoughtDate:      input.BoughtDate,
+		Source:          entity.AuditSourceWeb,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add card to collection: %w", err)
+	}
+
+	item.Acquired += input.Quantity
+	updateFulfilled(item, time.Now())
+
+	// The card is already in the collection at this point; a failure here
+	// only leaves the wishlist counter behind.
+	if err := uc.wishlistRepo.Update(context.WithoutCancel(ctx), item); err != nil {
+		log.Printf("Failed to update wishlist item %d after acquisition: %v", item.ID, err)
+	}
+	return nil
+}
+
+func updateFulfilled(item *entity.WishlistItem, now time.Time) {
+	if item.Acquired >= item.Quantity {
+		if item.FulfilledAt == nil {
+			item.FulfilledAt = &now
+		}
+	} else {
+		item.FulfilledAt = nil
+	}
+}
@@ -25,7 +25,7 @@ fi
 
 echo ""
 echo "To run the application:"
-echo "  go run cmd/server/main.go"
+echo "  go run ./cmd/server"
 echo ""
 echo "Or using Make:"
 echo "  make run"